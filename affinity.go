@@ -0,0 +1,225 @@
+package clustering
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// AffinityBuilder computes a DistanceMap by calling a (possibly slow or
+// network-backed) distance function for every pair of items, with bounded
+// concurrency, per-call timeouts, retries and checkpointing of completed pairs.
+type AffinityBuilder struct {
+	// Distance computes the distance between two items. The context is
+	// cancelled when the call times out or the build is aborted.
+	Distance func(ctx context.Context, a, b ClusterItem) (float64, error)
+
+	// Concurrency is the maximum number of concurrent calls to Distance.
+	// Values < 1 use a single call at a time.
+	Concurrency int
+
+	// Timeout limits the duration of each call to Distance, if > 0.
+	Timeout time.Duration
+
+	// Retries is the number of times a failed call is retried before the
+	// build fails. RetryDelay is the delay before the first retry, which is
+	// doubled for each subsequent retry.
+	Retries    int
+	RetryDelay time.Duration
+
+	// Resume contains the distances of pairs completed by a previous build,
+	// which are not computed again. It is typically restored from the pairs
+	// recorded by Checkpoint.
+	Resume DistanceMap
+
+	// Checkpoint, if set, is called for every newly completed pair, so that
+	// progress can be persisted and an interrupted build resumed. Checkpoint
+	// and Progress are never called concurrently. Returning an error aborts
+	// the build.
+	Checkpoint func(a, b ClusterItem, dist float64) error
+
+	// Progress, if set, is called after every completed pair with the number
+	// of pairs done so far (including resumed pairs) and the total.
+	Progress func(done, total int)
+}
+
+// Build computes the distance between every pair of items, and returns the
+// resulting DistanceMap (including any resumed pairs). If any pair fails after
+// all retries, or ctx is cancelled, Build stops and returns the pairs computed
+// so far along with the error.
+func (b *AffinityBuilder) Build(ctx context.Context, items []ClusterItem) (DistanceMap, error) {
+	res := make(DistanceMap, len(items))
+	var todo [][2]ClusterItem
+	total := len(items) * (len(items) - 1) / 2
+	done := 0
+	for i, x := range items {
+		for _, y := range items[i+1:] {
+			if d, ok := b.resumed(x, y); ok {
+				res.set(x, y, d)
+				done++
+				continue
+			}
+			todo = append(todo, [2]ClusterItem{x, y})
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		pair [2]ClusterItem
+		dist float64
+		err  error
+	}
+	pairs := make(chan [2]ClusterItem)
+	results := make(chan result)
+
+	workers := b.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range pairs {
+				d, err := b.call(ctx, p[0], p[1])
+				results <- result{p, d, err}
+			}
+		}()
+	}
+	go func() {
+		defer close(pairs)
+		for _, p := range todo {
+			select {
+			case pairs <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var err error
+	for r := range results {
+		if err != nil {
+			continue
+		}
+		if r.err != nil {
+			err = fmt.Errorf("clustering: distance(%v, %v): %w", r.pair[0], r.pair[1], r.err)
+			cancel()
+			continue
+		}
+		res.set(r.pair[0], r.pair[1], r.dist)
+		done++
+		if b.Checkpoint != nil {
+			if err = b.Checkpoint(r.pair[0], r.pair[1], r.dist); err != nil {
+				cancel()
+				continue
+			}
+		}
+		if b.Progress != nil {
+			b.Progress(done, total)
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return res, err
+}
+
+// call computes a single distance, with timeouts and retries.
+func (b *AffinityBuilder) call(ctx context.Context, x, y ClusterItem) (float64, error) {
+	delay := b.RetryDelay
+	for attempt := 0; ; attempt++ {
+		cctx, cancel := ctx, context.CancelFunc(func() {})
+		if b.Timeout > 0 {
+			cctx, cancel = context.WithTimeout(ctx, b.Timeout)
+		}
+		d, err := b.Distance(cctx, x, y)
+		cancel()
+		if err == nil || attempt >= b.Retries || ctx.Err() != nil {
+			return d, err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+func (b *AffinityBuilder) resumed(x, y ClusterItem) (float64, bool) {
+	if d, ok := b.Resume[x][y]; ok {
+		return d, true
+	}
+	d, ok := b.Resume[y][x]
+	return d, ok
+}
+
+// set records the distance between x and y.
+func (d DistanceMap) set(x, y ClusterItem, dist float64) {
+	m, ok := d[x]
+	if !ok {
+		m = make(map[ClusterItem]float64)
+		d[x] = m
+	}
+	m[y] = dist
+}
+
+/////////////
+
+// BuildDistanceMap computes the distance between every pair of items using
+// workers concurrent calls to f, and returns the resulting DistanceMap. f must
+// be safe to call concurrently. Values of workers < 1 use runtime.NumCPU().
+func BuildDistanceMap(items []ClusterItem, f func(a, b ClusterItem) float64, workers int) DistanceMap {
+	return BuildDistanceMapWithin(items, f, workers, math.Inf(1))
+}
+
+// BuildDistanceMapWithin is like BuildDistanceMap, but only keeps the pairs
+// with a distance <= max, which greatly reduces the memory used for sparse
+// similarity data. Note that missing pairs have distance 1.0 in a
+// DistanceMapClusterSet, so max should be < 1.0 for distances in [0, 1].
+func BuildDistanceMapWithin(items []ClusterItem, f func(a, b ClusterItem) float64, workers int, max float64) DistanceMap {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	// every row is written by exactly one worker
+	res := make(DistanceMap, len(items))
+	rows := make([]map[ClusterItem]float64, len(items))
+	for i, x := range items {
+		rows[i] = make(map[ClusterItem]float64)
+		res[x] = rows[i]
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				for _, y := range items[i+1:] {
+					if d := f(items[i], y); d <= max {
+						rows[i][y] = d
+					}
+				}
+			}
+		}()
+	}
+	for i := range items {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return res
+}
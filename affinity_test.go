@@ -0,0 +1,81 @@
+package clustering
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestAffinityBuilder(t *testing.T) {
+	items := []ClusterItem{0, 1, 3, 7, 8}
+	var mu sync.Mutex
+	attempts := make(map[[2]ClusterItem]int)
+	b := &AffinityBuilder{
+		Distance: func(ctx context.Context, a, b ClusterItem) (float64, error) {
+			// fail the first attempt of every pair involving item 3
+			mu.Lock()
+			attempts[[2]ClusterItem{a, b}]++
+			n := attempts[[2]ClusterItem{a, b}]
+			mu.Unlock()
+			if (a == 3 || b == 3) && n == 1 {
+				return 0, errors.New("transient")
+			}
+			d := a.(int) - b.(int)
+			if d < 0 {
+				d = -d
+			}
+			return float64(d), nil
+		},
+		Concurrency: 3,
+		Retries:     2,
+		Resume:      DistanceMap{0: {1: 1}},
+	}
+	var checkpoints, lastDone int
+	b.Checkpoint = func(a, b ClusterItem, dist float64) error {
+		checkpoints++
+		return nil
+	}
+	b.Progress = func(done, total int) {
+		lastDone = done
+	}
+
+	d, err := b.Build(context.Background(), items)
+	if err != nil {
+		t.Fatalf("Build failed: %s", err)
+	}
+	if checkpoints != 9 || lastDone != 10 {
+		t.Errorf("expected 9 checkpoints and 10 pairs done, got %d and %d", checkpoints, lastDone)
+	}
+	if d[3][8] != 5 || d[0][1] != 1 {
+		t.Errorf("unexpected distance map %v", d)
+	}
+
+	b.Retries = 0
+	b.Resume = nil
+	attempts = make(map[[2]ClusterItem]int)
+	if _, err = b.Build(context.Background(), items); err == nil {
+		t.Errorf("expected Build to fail without retries")
+	}
+}
+
+func TestBuildDistanceMap(t *testing.T) {
+	items := []ClusterItem{0, 1, 3, 7, 8}
+	dist := func(a, b ClusterItem) float64 {
+		return math.Abs(float64(a.(int) - b.(int)))
+	}
+	full := BuildDistanceMap(items, dist, 3)
+	if full[1][7] != 6 || full[0][8] != 8 || len(full[0]) != 4 {
+		t.Errorf("unexpected full distance map %v", full)
+	}
+
+	near := BuildDistanceMapWithin(items, dist, 0, 2)
+	n := 0
+	for _, row := range near {
+		n += len(row)
+	}
+	if n != 3 || near[7][8] != 1 {
+		t.Errorf("expected 3 pairs within 2, got %v", near)
+	}
+}
@@ -0,0 +1,235 @@
+// Package arrowset provides a clustering.ClusterSet over the rows of Apache
+// Arrow record batches, so that feature data exported from Spark, DuckDB or
+// Pandas can be clustered without intermediate conversion.
+package arrowset
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/pbnjay/clustering"
+	"github.com/pbnjay/clustering/metrics"
+)
+
+// ClusterSet is a ClusterSet over the rows of one or more record batches with
+// the same schema, where distances are computed from a set of numeric feature
+// columns using a Metric. Float64 columns are used in place without copying,
+// other numeric columns are converted once when the set is created.
+//
+// The items enumerated by EachItem are int row indexes, counting across all
+// batches in order. ClusterSet implements clustering.VectorClusterSet.
+type ClusterSet struct {
+	records []arrow.Record
+
+	// cols[b][f] holds feature f of every row in batch b
+	cols    [][][]float64
+	offsets []int
+	metric  clustering.Metric
+
+	// scratch vectors for Distance, which is not safe for concurrent use
+	a, b []float64
+
+	clusters [][]clustering.ClusterItem
+}
+
+// New creates a ClusterSet with one initial cluster for each row of the
+// records, using the named feature columns, or every numeric column if
+// columns is nil. Feature columns must not contain nulls. If metric is nil,
+// metrics.Euclidean is used. The records are retained until Release is
+// called.
+func New(records []arrow.Record, columns []string, metric clustering.Metric) (*ClusterSet, error) {
+	if metric == nil {
+		metric = metrics.Euclidean
+	}
+	s := &ClusterSet{metric: metric}
+	if len(records) == 0 {
+		return s, nil
+	}
+
+	fields, err := featureColumns(records[0].Schema(), columns)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for _, rec := range records {
+		if !rec.Schema().Equal(records[0].Schema()) {
+			return nil, fmt.Errorf("arrowset: record batches have different schemas")
+		}
+		var cols [][]float64
+		for _, f := range fields {
+			vals, err := floats(rec.Column(f))
+			if err != nil {
+				return nil, fmt.Errorf("arrowset: column %q: %v", rec.ColumnName(f), err)
+			}
+			cols = append(cols, vals)
+		}
+		s.cols = append(s.cols, cols)
+		s.offsets = append(s.offsets, n)
+		n += int(rec.NumRows())
+	}
+
+	for _, rec := range records {
+		rec.Retain()
+	}
+	s.records = records
+	s.a = make([]float64, len(fields))
+	s.b = make([]float64, len(fields))
+	s.clusters = make([][]clustering.ClusterItem, n)
+	for i := range s.clusters {
+		s.clusters[i] = []clustering.ClusterItem{i}
+	}
+	return s, nil
+}
+
+// Release releases the record batches. The ClusterSet must not be used
+// afterwards.
+func (s *ClusterSet) Release() {
+	for _, rec := range s.records {
+		rec.Release()
+	}
+	s.records = nil
+}
+
+// Count returns the number of clusters in the set.
+func (s *ClusterSet) Count() int {
+	return len(s.clusters)
+}
+
+// EachCluster enumerates every cluster id "after" start.
+func (s *ClusterSet) EachCluster(start int, cb func(cluster int)) {
+	for i := start + 1; i < len(s.clusters); i++ {
+		cb(i)
+	}
+}
+
+// EachItem enumerates every item in the cluster.
+func (s *ClusterSet) EachItem(cluster int, cb func(clustering.ClusterItem)) {
+	for _, x := range s.clusters[cluster] {
+		cb(x)
+	}
+}
+
+// Merge the two clusters together.
+func (s *ClusterSet) Merge(i, j int) (keep, swappedIn int) {
+	if j < i {
+		j, i = i, j
+	}
+
+	// move the to-be-merged cluster to the end of the array
+	x := len(s.clusters) - 1
+	if j < x {
+		s.clusters[x], s.clusters[j] = s.clusters[j], s.clusters[x]
+		j = x
+	}
+	s.clusters[i] = append(s.clusters[i], s.clusters[j]...)
+	s.clusters = s.clusters[:j]
+	return i, x
+}
+
+// Distance computes the distance between the feature vectors of two rows.
+func (s *ClusterSet) Distance(c1, c2 int, item1, item2 clustering.ClusterItem) float64 {
+	s.row(item1.(int), s.a)
+	s.row(item2.(int), s.b)
+	return s.metric(s.a, s.b)
+}
+
+// Coordinates returns a copy of the feature vector of a row.
+func (s *ClusterSet) Coordinates(item clustering.ClusterItem) []float64 {
+	res := make([]float64, len(s.a))
+	s.row(item.(int), res)
+	return res
+}
+
+// Centroid returns the mean feature vector of the rows in the cluster.
+func (s *ClusterSet) Centroid(cluster int) []float64 {
+	res := make([]float64, len(s.a))
+	for _, x := range s.clusters[cluster] {
+		s.row(x.(int), s.a)
+		for f, v := range s.a {
+			res[f] += v
+		}
+	}
+	for f := range res {
+		res[f] /= float64(len(s.clusters[cluster]))
+	}
+	return res
+}
+
+// row gathers the features of row i into dst.
+func (s *ClusterSet) row(i int, dst []float64) {
+	b := sort.SearchInts(s.offsets, i+1) - 1
+	r := i - s.offsets[b]
+	for f, col := range s.cols[b] {
+		dst[f] = col[r]
+	}
+}
+
+/////////////
+
+// featureColumns returns the indexes of the named columns, or of every
+// numeric column if names is nil.
+func featureColumns(schema *arrow.Schema, names []string) ([]int, error) {
+	if names == nil {
+		var res []int
+		for f, field := range schema.Fields() {
+			if numeric(field.Type) {
+				res = append(res, f)
+			}
+		}
+		return res, nil
+	}
+
+	var res []int
+	for _, name := range names {
+		idx := schema.FieldIndices(name)
+		if len(idx) != 1 {
+			return nil, fmt.Errorf("arrowset: expected one column named %q, found %d", name, len(idx))
+		}
+		if !numeric(schema.Field(idx[0]).Type) {
+			return nil, fmt.Errorf("arrowset: column %q has non-numeric type %s", name, schema.Field(idx[0]).Type)
+		}
+		res = append(res, idx[0])
+	}
+	return res, nil
+}
+
+func numeric(t arrow.DataType) bool {
+	switch t.ID() {
+	case arrow.FLOAT64, arrow.FLOAT32, arrow.INT64, arrow.INT32, arrow.INT16, arrow.INT8:
+		return true
+	}
+	return false
+}
+
+// floats returns the values of a numeric column, without copying if it is
+// already a float64 column.
+func floats(col arrow.Array) ([]float64, error) {
+	if col.NullN() > 0 {
+		return nil, fmt.Errorf("contains %d nulls", col.NullN())
+	}
+	switch a := col.(type) {
+	case *array.Float64:
+		return a.Float64Values(), nil
+	case *array.Float32:
+		return convert(a.Float32Values()), nil
+	case *array.Int64:
+		return convert(a.Int64Values()), nil
+	case *array.Int32:
+		return convert(a.Int32Values()), nil
+	case *array.Int16:
+		return convert(a.Int16Values()), nil
+	case *array.Int8:
+		return convert(a.Int8Values()), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", col.DataType())
+}
+
+func convert[T float32 | int64 | int32 | int16 | int8](vals []T) []float64 {
+	res := make([]float64, len(vals))
+	for i, v := range vals {
+		res[i] = float64(v)
+	}
+	return res
+}
@@ -0,0 +1,59 @@
+package arrowset
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/pbnjay/clustering"
+)
+
+func TestClusterSet(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "name", Type: arrow.BinaryTypes.String},
+		{Name: "x", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "y", Type: arrow.PrimitiveTypes.Int32},
+	}, nil)
+	b := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+	defer b.Release()
+
+	// two batches, with two groups of points split across them
+	var records []arrow.Record
+	for _, batch := range [][][2]float64{
+		{{0, 0}, {0, 1}, {10, 10}},
+		{{1, 0}, {10, 11}, {11, 10}},
+	} {
+		for _, p := range batch {
+			b.Field(0).(*array.StringBuilder).Append("p")
+			b.Field(1).(*array.Float64Builder).Append(p[0])
+			b.Field(2).(*array.Int32Builder).Append(int32(p[1]))
+		}
+		rec := b.NewRecord()
+		defer rec.Release()
+		records = append(records, rec)
+	}
+
+	s, err := New(records, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Release()
+	if s.Count() != 6 || s.Distance(0, 3, 0, 3) != 1 {
+		t.Errorf("unexpected rows or distances across batches")
+	}
+
+	clustering.Cluster(s, clustering.Threshold(3), clustering.CompleteLinkage())
+	if s.Count() != 2 {
+		t.Fatalf("expected 2 clusters, got %d", s.Count())
+	}
+	s.EachCluster(-1, func(cluster int) {
+		if c := s.Centroid(cluster); c[0] != 1.0/3.0 && c[0] != 31.0/3.0 {
+			t.Errorf("unexpected centroid %v", c)
+		}
+	})
+
+	if _, err := New(records, []string{"name"}, nil); err == nil {
+		t.Errorf("expected an error for a non-numeric column")
+	}
+}
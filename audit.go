@@ -0,0 +1,106 @@
+package clustering
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MergeLogEntry records a single decision made during clustering.
+type MergeLogEntry struct {
+	// Step is the index of the decision, in the order it was made.
+	Step int `json:"step"`
+
+	// A and B are the dendrogram node ids (see Merge) of the two clusters that
+	// were considered for merging.
+	A int `json:"a"`
+	B int `json:"b"`
+
+	// Node is the id of the node created by the merge, or -1 if the merge
+	// was not performed.
+	Node int `json:"node"`
+
+	// Score is the linkage score of the merge.
+	Score float64 `json:"score"`
+
+	// Size is the total number of items in both clusters.
+	Size int `json:"size"`
+
+	// Decision is "merged" if the merge was performed, or the StopReason
+	// that prevented it.
+	Decision string `json:"decision"`
+}
+
+// MergeLog returns the complete ordered log of merge decisions made so far.
+// Every performed merge is included, followed by the final rejected merge if
+// clustering was stopped by the Checker or a limit. Together with the leaves
+// of the Dendrogram, this allows the exact reasons that any two items were
+// grouped together to be reconstructed.
+func (h *HClustering) MergeLog() []MergeLogEntry {
+	if h.history == nil {
+		h.history = newHistory(h.ClusterSet)
+	}
+	leaves, merges, ids := h.history.tree()
+	nl := len(leaves)
+	res := make([]MergeLogEntry, 0, len(merges)+1)
+	for k, m := range merges {
+		res = append(res, MergeLogEntry{
+			Step: k, A: m.A, B: m.B, Node: nl + k,
+			Score: m.Height, Size: m.Size, Decision: "merged",
+		})
+	}
+	if h.scored && (h.stop == CheckerStopped || h.stop == LimitExceeded) {
+		i, j := h.finalPair[0], h.finalPair[1]
+		if i < len(h.history.nodes) && j < len(h.history.nodes) {
+			a, b := h.history.nodes[i], h.history.nodes[j]
+			if ids != nil {
+				a, b = ids[a], ids[b]
+			}
+			res = append(res, MergeLogEntry{
+				Step: len(merges), A: a, B: b, Node: -1,
+				Score: h.finalScore, Size: h.history.sizes[i] + h.history.sizes[j],
+				Decision: h.stop.String(),
+			})
+		}
+	}
+	return res
+}
+
+// WriteMergeLogCSV writes the merge log of h to w as CSV, with the header
+// "step,node,a,b,score,size,decision,items". The leaves of the dendrogram are
+// written first, with a decision of "leaf" and their items separated by
+// semicolons. Items are formatted with fmt.Sprint.
+func WriteMergeLogCSV(w io.Writer, h *HClustering) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"step", "node", "a", "b", "score", "size", "decision", "items"})
+	for i, leaf := range h.Dendrogram().Leaves {
+		items := make([]string, len(leaf))
+		for k, x := range leaf {
+			items[k] = fmt.Sprint(x)
+		}
+		cw.Write([]string{"", strconv.Itoa(i), "", "", "", strconv.Itoa(len(leaf)),
+			"leaf", strings.Join(items, ";")})
+	}
+	for _, e := range h.MergeLog() {
+		cw.Write([]string{
+			strconv.Itoa(e.Step), strconv.Itoa(e.Node), strconv.Itoa(e.A), strconv.Itoa(e.B),
+			strconv.FormatFloat(e.Score, 'g', -1, 64), strconv.Itoa(e.Size), e.Decision, "",
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMergeLogJSON writes the merge log of h to w as a single JSON object of
+// the form {"run":...,"leaves":[[item,...],...],"merges":[...]}. Items are
+// encoded with encoding/json.
+func WriteMergeLogJSON(w io.Writer, h *HClustering) error {
+	return json.NewEncoder(w).Encode(struct {
+		Run    RunInfo         `json:"run"`
+		Leaves [][]ClusterItem `json:"leaves"`
+		Merges []MergeLogEntry `json:"merges"`
+	}{h.RunInfo(), h.Dendrogram().Leaves, h.MergeLog()})
+}
@@ -0,0 +1,310 @@
+// Package bboltkv provides a clustering.ClusterSet whose distances and cluster
+// membership are persisted in a bbolt database, so that extremely long-running
+// clustering jobs survive process restarts without holding the whole distance
+// matrix in memory.
+package bboltkv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/pbnjay/clustering"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	distBucket    = []byte("distances")
+	clusterBucket = []byte("clusters")
+	metaBucket    = []byte("meta")
+	countKey      = []byte("n")
+)
+
+// batchSize is the number of distances written per transaction by Create.
+const batchSize = 100000
+
+// ClusterSet is a ClusterSet stored in a bbolt database. Distances are read
+// from the database as needed, and every Merge is committed before it returns,
+// so clustering can be resumed with Open after the process restarts. Only the
+// cluster membership is held in memory.
+//
+// The items enumerated by EachItem are int indexes. Since the ClusterSet
+// interface cannot return errors, the first database error is recorded and
+// reported by Err; distances that cannot be read are returned as +Inf.
+type ClusterSet struct {
+	db  *bolt.DB
+	n   int
+	err error
+
+	clusters [][]clustering.ClusterItem
+
+	// pair and dists hold the distances between the items of the last pair
+	// of clusters read, which are all read in a single transaction.
+	pair  [2]int
+	dists map[[2]int]float64
+}
+
+// Create creates a new database at path containing the distance between every
+// pair of n items computed by dist, with one initial cluster for each item.
+func Create(path string, n int, dist func(i, j int) float64) (*ClusterSet, error) {
+	db, err := bolt.Open(path, 0644, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{distBucket, clusterBucket, metaBucket} {
+			if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < n; i++ {
+			if err := tx.Bucket(clusterBucket).Put(key(i), encodeItems([]clustering.ClusterItem{i})); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(metaBucket).Put(countKey, key(n))
+	})
+	if err == nil {
+		err = fill(db, n, dist)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return load(db)
+}
+
+// Open opens an existing database at path created by Create, restoring the
+// clusters as of the last completed Merge.
+func Open(path string) (*ClusterSet, error) {
+	db, err := bolt.Open(path, 0644, nil)
+	if err != nil {
+		return nil, err
+	}
+	s, err := load(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// fill writes the distance between every pair of items, in batches.
+func fill(db *bolt.DB, n int, dist func(i, j int) float64) error {
+	i, j, x := 0, 1, 0
+	for j < n {
+		err := db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(distBucket)
+			for end := x + batchSize; x < end && j < n; x++ {
+				var v [8]byte
+				binary.BigEndian.PutUint64(v[:], math.Float64bits(dist(i, j)))
+				if err := b.Put(key(x), v[:]); err != nil {
+					return err
+				}
+				if j++; j == n {
+					i++
+					j = i + 1
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load reads the item count and cluster membership from the database.
+func load(db *bolt.DB) (*ClusterSet, error) {
+	s := &ClusterSet{db: db}
+	err := db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if meta == nil || tx.Bucket(distBucket) == nil || tx.Bucket(clusterBucket) == nil {
+			return fmt.Errorf("bboltkv: %s is not a cluster database", db.Path())
+		}
+		v := meta.Get(countKey)
+		if len(v) != 8 {
+			return fmt.Errorf("bboltkv: item count is missing from %s", db.Path())
+		}
+		s.n = int(binary.BigEndian.Uint64(v))
+		return tx.Bucket(clusterBucket).ForEach(func(k, v []byte) error {
+			c := int(binary.BigEndian.Uint64(k))
+			if c != len(s.clusters) {
+				return fmt.Errorf("bboltkv: cluster %d is missing from %s", len(s.clusters), db.Path())
+			}
+			s.clusters = append(s.clusters, decodeItems(v))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database. The ClusterSet must not be used afterwards.
+func (s *ClusterSet) Close() error {
+	return s.db.Close()
+}
+
+// Err returns the first database error encountered, if any.
+func (s *ClusterSet) Err() error {
+	return s.err
+}
+
+// Count returns the number of clusters in the set.
+func (s *ClusterSet) Count() int {
+	return len(s.clusters)
+}
+
+// EachCluster enumerates every cluster id "after" start.
+func (s *ClusterSet) EachCluster(start int, cb func(cluster int)) {
+	for i := start + 1; i < len(s.clusters); i++ {
+		cb(i)
+	}
+}
+
+// EachItem enumerates every item in the cluster.
+func (s *ClusterSet) EachItem(cluster int, cb func(clustering.ClusterItem)) {
+	for _, x := range s.clusters[cluster] {
+		cb(x)
+	}
+}
+
+// Distance returns the distance between the two items. The distances between
+// every item of c1 and c2 are read from the database in a single transaction,
+// and kept until distances for another pair of clusters are needed.
+func (s *ClusterSet) Distance(c1, c2 int, item1, item2 clustering.ClusterItem) float64 {
+	i, j := item1.(int), item2.(int)
+	s.readPair(c1, c2)
+	if d, ok := s.dists[[2]int{i, j}]; ok {
+		return d
+	}
+	res := math.Inf(1)
+	s.view(func(b *bolt.Bucket) {
+		res = s.get(b, i, j)
+	})
+	return res
+}
+
+// EachItemDistance returns the distances from item1 to every item in c2,
+// reading them as Distance does.
+func (s *ClusterSet) EachItemDistance(c1, c2 int, item1 clustering.ClusterItem, cb func(clustering.ClusterItem, float64)) {
+	for _, x := range s.clusters[c2] {
+		cb(x, s.Distance(c1, c2, item1, x))
+	}
+}
+
+// readPair reads the distances between every item of c1 and c2, unless they
+// are already held.
+func (s *ClusterSet) readPair(c1, c2 int) {
+	if c2 < c1 {
+		c1, c2 = c2, c1
+	}
+	if s.dists != nil && s.pair == [2]int{c1, c2} {
+		return
+	}
+	s.pair = [2]int{c1, c2}
+	s.dists = make(map[[2]int]float64, len(s.clusters[c1])*len(s.clusters[c2]))
+	s.view(func(b *bolt.Bucket) {
+		for _, x := range s.clusters[c1] {
+			for _, y := range s.clusters[c2] {
+				d := s.get(b, x.(int), y.(int))
+				s.dists[[2]int{x.(int), y.(int)}] = d
+				s.dists[[2]int{y.(int), x.(int)}] = d
+			}
+		}
+	})
+}
+
+// Merge the two clusters together, committing the new membership to the
+// database. If the commit fails the clusters are merged in memory only, and
+// the error is reported by Err.
+func (s *ClusterSet) Merge(i, j int) (keep, swappedIn int) {
+	if j < i {
+		j, i = i, j
+	}
+	s.dists = nil
+
+	// move the to-be-merged cluster to the end of the array
+	x := len(s.clusters) - 1
+	moved := j
+	if j < x {
+		s.clusters[x], s.clusters[j] = s.clusters[j], s.clusters[x]
+		j = x
+	}
+	s.clusters[i] = append(s.clusters[i], s.clusters[j]...)
+	s.clusters = s.clusters[:j]
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(clusterBucket)
+		if err := b.Put(key(i), encodeItems(s.clusters[i])); err != nil {
+			return err
+		}
+		if moved < x {
+			if err := b.Put(key(moved), encodeItems(s.clusters[moved])); err != nil {
+				return err
+			}
+		}
+		return b.Delete(key(x))
+	})
+	s.fail(err)
+	return i, x
+}
+
+func (s *ClusterSet) view(fn func(b *bolt.Bucket)) {
+	s.fail(s.db.View(func(tx *bolt.Tx) error {
+		fn(tx.Bucket(distBucket))
+		return nil
+	}))
+}
+
+func (s *ClusterSet) get(b *bolt.Bucket, i, j int) float64 {
+	if i == j {
+		return 0.0
+	}
+	if j < i {
+		i, j = j, i
+	}
+	v := b.Get(key(s.n*i - i*(i+1)/2 + (j - i - 1)))
+	if len(v) != 8 {
+		s.fail(fmt.Errorf("bboltkv: missing distance for items %d and %d", i, j))
+		return math.Inf(1)
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(v))
+}
+
+func (s *ClusterSet) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+/////////////
+
+// key encodes x as a big-endian uint64, so that keys sort numerically.
+func key(x int) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], uint64(x))
+	return k[:]
+}
+
+func encodeItems(items []clustering.ClusterItem) []byte {
+	res := make([]byte, 4*len(items))
+	for k, x := range items {
+		binary.BigEndian.PutUint32(res[4*k:], uint32(x.(int)))
+	}
+	return res
+}
+
+func decodeItems(v []byte) []clustering.ClusterItem {
+	res := make([]clustering.ClusterItem, len(v)/4)
+	for k := range res {
+		res[k] = int(binary.BigEndian.Uint32(v[4*k:]))
+	}
+	return res
+}
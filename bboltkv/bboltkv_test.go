@@ -0,0 +1,102 @@
+package bboltkv
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/pbnjay/clustering"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestResume(t *testing.T) {
+	// items on a line at positions 0, 1, 3, 7 and 8
+	pos := []float64{0, 1, 3, 7, 8}
+	path := filepath.Join(t.TempDir(), "clusters.db")
+	s, err := Create(path, len(pos), func(i, j int) float64 {
+		return math.Abs(pos[i] - pos[j])
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Distance(0, 3, 3, 1) != 6 || s.Distance(0, 1, 0, 4) != 8 {
+		t.Errorf("unexpected stored distances")
+	}
+
+	// merge a few clusters, then restart
+	clustering.Cluster(s, clustering.MaxClusters(3), clustering.SingleLinkage())
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.Count() != 3 {
+		t.Fatalf("expected 3 clusters after reopening, got %d", s.Count())
+	}
+
+	clustering.Cluster(s, clustering.Threshold(3), clustering.SingleLinkage())
+	if s.Count() != 2 || s.Err() != nil {
+		t.Errorf("expected 2 clusters after resuming, got %d (%v)", s.Count(), s.Err())
+	}
+	s.EachCluster(-1, func(cluster int) {
+		n := 0
+		s.EachItem(cluster, func(x clustering.ClusterItem) { n++ })
+		if n != 2 && n != 3 {
+			t.Errorf("unexpected cluster size %d", n)
+		}
+	})
+}
+
+func TestBatchedReads(t *testing.T) {
+	s, err := Create(filepath.Join(t.TempDir(), "clusters.db"), 6, func(i, j int) float64 {
+		return float64(i + j)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Merge(0, 1)
+	s.Merge(0, 2)
+	s.Merge(1, 2)
+
+	// clusters {0, 1, 2} and {5, 4}: every distance is read in one transaction
+	before := s.db.Stats().TxN
+	var sum float64
+	s.EachItem(0, func(x clustering.ClusterItem) {
+		s.EachItemDistance(0, 1, x, func(y clustering.ClusterItem, d float64) {
+			if d != float64(x.(int)+y.(int)) {
+				t.Errorf("expected distance %d between %v and %v, got %g", x.(int)+y.(int), x, y, d)
+			}
+			sum += d
+		})
+	})
+	if n := s.db.Stats().TxN - before; n != 1 {
+		t.Errorf("expected 1 read transaction, got %d", n)
+	}
+	if sum != 2*(0+1+2)+3*(5+4) || s.Err() != nil {
+		t.Errorf("unexpected distance sum %g (%v)", sum, s.Err())
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clusters.db")
+	s, err := Create(path, 3, func(i, j int) float64 { return 1 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Delete(countKey)
+	})
+	s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Errorf("expected an error opening a database without an item count")
+	}
+}
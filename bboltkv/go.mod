@@ -0,0 +1,12 @@
+module github.com/pbnjay/clustering/bboltkv
+
+go 1.25.0
+
+require (
+	github.com/pbnjay/clustering v0.0.0-20261017041120-5460e7894a19
+	go.etcd.io/bbolt v1.4.3
+)
+
+require golang.org/x/sys v0.29.0 // indirect
+
+replace github.com/pbnjay/clustering => ../
@@ -0,0 +1,216 @@
+package clustering
+
+import "math"
+
+// CacheStorage selects how cached linkage scores are stored in memory (see
+// HClustering.CacheDistances).
+type CacheStorage int
+
+const (
+	// CacheFloat64 stores every score exactly, using 8 bytes per pair.
+	CacheFloat64 CacheStorage = iota
+
+	// CacheUint16 quantizes every score to 16-bit fixed point within the
+	// range given by HClustering.CacheRange, using 2 bytes per pair. Finite
+	// scores outside the range are clamped to it, while +Inf is kept exact so
+	// that unmergeable pairs stay unmergeable. The resolution is 1/65533rd of
+	// the range, which rarely changes the merge order of large runs.
+	CacheUint16
+
+	// CacheFloat32 stores every score in single precision, using 4 bytes per
+	// pair. This halves the memory used by the cache when double precision
+	// scores are unnecessary.
+	CacheFloat32
+)
+
+// bytes returns the number of bytes used to store each score.
+func (c CacheStorage) bytes() int {
+	switch c {
+	case CacheUint16:
+		return 2
+	case CacheFloat32:
+		return 4
+	}
+	return 8
+}
+
+/////////////
+
+// quantMissing marks a missing score in uint16 storage, and quantInf a score
+// of +Inf. Every smaller value is a fixed point score within the range.
+const (
+	quantMissing = math.MaxUint16
+	quantInf     = quantMissing - 1
+)
+
+// scoreCache stores the linkage score for every pair of clusters in a
+// condensed lower-triangular layout, so that removing the last cluster is a
+// simple truncation. Missing scores are stored as NaN (or quantMissing).
+// Exactly one of vals, f32 or q is used for storage.
+type scoreCache struct {
+	vals []float64
+	f32  []float32
+
+	// quantized storage, used instead of vals when lo < hi
+	q      []uint16
+	lo, hi float64
+}
+
+func newScoreCache(n int) *scoreCache {
+	s := &scoreCache{vals: make([]float64, n*(n-1)/2)}
+	s.reset()
+	return s
+}
+
+// newQuantizedCache creates a scoreCache that stores scores in the range
+// [lo, hi] as 16-bit fixed point values.
+func newQuantizedCache(n int, lo, hi float64) *scoreCache {
+	s := &scoreCache{q: make([]uint16, n*(n-1)/2), lo: lo, hi: hi}
+	s.reset()
+	return s
+}
+
+// newFloat32Cache creates a scoreCache that stores scores in single precision.
+func newFloat32Cache(n int) *scoreCache {
+	s := &scoreCache{f32: make([]float32, n*(n-1)/2)}
+	s.reset()
+	return s
+}
+
+func (s *scoreCache) size() int {
+	switch {
+	case s.q != nil:
+		return len(s.q)
+	case s.f32 != nil:
+		return len(s.f32)
+	}
+	return len(s.vals)
+}
+
+func (s *scoreCache) load(x int) float64 {
+	switch {
+	case s.f32 != nil:
+		return float64(s.f32[x])
+	case s.q == nil:
+		return s.vals[x]
+	}
+	switch s.q[x] {
+	case quantMissing:
+		return math.NaN()
+	case quantInf:
+		return math.Inf(1)
+	}
+	return s.lo + float64(s.q[x])*(s.hi-s.lo)/(quantInf-1)
+}
+
+func (s *scoreCache) store(x int, v float64) {
+	switch {
+	case s.f32 != nil:
+		s.f32[x] = float32(v)
+		return
+	case s.q == nil:
+		s.vals[x] = v
+		return
+	}
+	switch {
+	case math.IsNaN(v):
+		s.q[x] = quantMissing
+		return
+	case math.IsInf(v, 1):
+		s.q[x] = quantInf
+		return
+	}
+	v = math.Max(s.lo, math.Min(s.hi, v))
+	s.q[x] = uint16(math.Round((v - s.lo) / (s.hi - s.lo) * (quantInf - 1)))
+}
+
+func triIndex(i, j int) int {
+	if i > j {
+		i, j = j, i
+	}
+	return j*(j-1)/2 + i
+}
+
+func (s *scoreCache) get(i, j int) (float64, bool) {
+	if i == j {
+		return 0.0, false
+	}
+	x := triIndex(i, j)
+	if x >= s.size() {
+		return 0.0, false
+	}
+	v := s.load(x)
+	return v, !math.IsNaN(v)
+}
+
+func (s *scoreCache) set(i, j int, v float64) {
+	if i == j {
+		return
+	}
+	x := triIndex(i, j)
+	if x < s.size() {
+		s.store(x, v)
+	}
+}
+
+func (s *scoreCache) clear(i, j int) {
+	s.set(i, j, math.NaN())
+}
+
+// move copies all the scores for cluster from into cluster to, for each of
+// the n clusters.
+func (s *scoreCache) move(from, to, n int) {
+	for k := 0; k < n; k++ {
+		if k == from || k == to {
+			continue
+		}
+		v, ok := s.get(from, k)
+		if !ok {
+			v = math.NaN()
+		}
+		s.set(to, k, v)
+	}
+}
+
+// truncate drops all the scores for clusters >= n.
+func (s *scoreCache) truncate(n int) {
+	if m := n * (n - 1) / 2; m < s.size() {
+		switch {
+		case s.q != nil:
+			s.q = s.q[:m]
+		case s.f32 != nil:
+			s.f32 = s.f32[:m]
+		default:
+			s.vals = s.vals[:m]
+		}
+	}
+}
+
+// reset marks every score as missing.
+func (s *scoreCache) reset() {
+	for x := 0; x < s.size(); x++ {
+		s.store(x, math.NaN())
+	}
+}
+
+// sample returns up to n cluster pairs with cached scores, evenly spaced
+// throughout the cache.
+func (s *scoreCache) sample(n int) [][2]int {
+	if n <= 0 || s.size() == 0 {
+		return nil
+	}
+	stride := s.size() / n
+	if stride < 1 {
+		stride = 1
+	}
+	var res [][2]int
+	x := 0
+	for j := 1; x < s.size() && len(res) < n; j++ {
+		for i := 0; i < j && len(res) < n; i, x = i+1, x+1 {
+			if x%stride == 0 && !math.IsNaN(s.load(x)) {
+				res = append(res, [2]int{i, j})
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,115 @@
+package clustering
+
+import "sort"
+
+// CentralItems returns the items of the cluster ordered by centrality, i.e. by
+// their distance to the cluster's medoid (the item with the smallest total
+// distance to every other item). The medoid is always first, so the most
+// representative members are listed first. This requires computing the
+// distance between every pair of items in the cluster, so c must implement
+// ItemDistanceSet, otherwise the items are returned in their original order.
+func CentralItems(c ClusterSet, cluster int) []ClusterItem {
+	var items []ClusterItem
+	c.EachItem(cluster, func(x ClusterItem) {
+		items = append(items, x)
+	})
+	if len(items) < 3 {
+		return items
+	}
+
+	n := len(items)
+	dists := make([]float64, n*n)
+	sums := make([]float64, n)
+	for a := range items {
+		for b := a + 1; b < n; b++ {
+			d, ok := itemDistanceOf(c, items[a], items[b])
+			if !ok {
+				return items
+			}
+			dists[a*n+b], dists[b*n+a] = d, d
+			sums[a] += d
+			sums[b] += d
+		}
+	}
+	medoid := 0
+	for a := range sums {
+		if sums[a] < sums[medoid] {
+			medoid = a
+		}
+	}
+
+	order := make([]int, n)
+	for a := range order {
+		order[a] = a
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return dists[medoid*n+order[a]] < dists[medoid*n+order[b]]
+	})
+	res := make([]ClusterItem, n)
+	for a, x := range order {
+		res[a] = items[x]
+	}
+	return res
+}
+
+// CentralityOrder is a ClusterSet wrapper whose EachItem enumerates the items
+// of each cluster in order of centrality (see CentralItems), so that exports
+// and accessors such as ClusterIterator.Items list the most representative
+// members first. Orderings are computed on demand and cached until the
+// cluster is merged, so the wrapper is best applied once clustering is
+// complete.
+type CentralityOrder struct {
+	ClusterSet
+
+	orders map[int][]ClusterItem
+}
+
+// OrderByCentrality wraps c so that items are enumerated in order of
+// centrality.
+func OrderByCentrality(c ClusterSet) *CentralityOrder {
+	return &CentralityOrder{
+		ClusterSet: c,
+		orders:     make(map[int][]ClusterItem),
+	}
+}
+
+// EachItem enumerates every item from the cluster, most central first.
+func (o *CentralityOrder) EachItem(cluster int, cb func(ClusterItem)) {
+	items, ok := o.orders[cluster]
+	if !ok {
+		items = CentralItems(o.ClusterSet, cluster)
+		o.orders[cluster] = items
+	}
+	for _, x := range items {
+		cb(x)
+	}
+}
+
+// ClusterSize implements ClusterSizer, without computing the ordering.
+func (o *CentralityOrder) ClusterSize(cluster int) int {
+	return clusterSize(o.ClusterSet, cluster)
+}
+
+// Merge the two clusters together, discarding their cached orderings.
+func (o *CentralityOrder) Merge(i, j int) (kept, swappedIn int) {
+	kept, swappedIn = o.ClusterSet.Merge(i, j)
+	delete(o.orders, i)
+	delete(o.orders, j)
+	delete(o.orders, swappedIn)
+	return kept, swappedIn
+}
+
+// ItemDistance implements ItemDistanceSet when the underlying ClusterSet does.
+func (o *CentralityOrder) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return itemDistanceOf(o.ClusterSet, item1, item2)
+}
+
+// Coordinates implements VectorClusterSet when the underlying ClusterSet does.
+func (o *CentralityOrder) Coordinates(item ClusterItem) []float64 {
+	return coordinatesOf(o.ClusterSet, item)
+}
+
+// Centroid implements VectorClusterSet when the underlying ClusterSet does.
+func (o *CentralityOrder) Centroid(cluster int) []float64 {
+	return centroidOf(o.ClusterSet, cluster)
+}
@@ -0,0 +1,39 @@
+package clustering
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCentralItems(t *testing.T) {
+	p := NewPointsClusterSet([][]float64{{0}, {9}, {4}, {5}, {6}}, nil)
+	Cluster(p, MaxClusters(1), SingleLinkage())
+
+	if got := fmt.Sprint(CentralItems(p, 0)); got != "[3 2 4 1 0]" {
+		t.Errorf("unexpected centrality order %s", got)
+	}
+
+	var items []ClusterItem
+	it := ClustersBySize(OrderByCentrality(p))
+	for it.Next() {
+		items = it.Items()
+	}
+	if len(items) != 5 || items[4] != 0 {
+		t.Errorf("expected furthest item last, got %v", items)
+	}
+
+	// distances within the cluster never come from Distance
+	s := &strictClusterSet{p, t}
+	if got := fmt.Sprint(CentralItems(s, 0)); got != "[3 2 4 1 0]" {
+		t.Errorf("unexpected centrality order %s", got)
+	}
+	if got := fmt.Sprint(CentralItems(struct{ ClusterSet }{s}, 0)); got != "[0 1 2 3 4]" {
+		t.Errorf("expected the original order without item distances, got %s", got)
+	}
+
+	// sizes are reported without ordering the items
+	o := OrderByCentrality(p)
+	if o.ClusterSize(0) != 5 || len(o.orders) != 0 {
+		t.Errorf("expected the size of 5 items without an ordering, got %d", o.ClusterSize(0))
+	}
+}
@@ -1,6 +1,12 @@
 package clustering
 
-import "log"
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
 
 // Checker implements the decision criteria used to stop clustering.
 // Note that this interface may also be used to collect the hierarchical
@@ -13,6 +19,49 @@ type Checker interface {
 	Check(clusters ClusterSet, i, j int, nextScore float64) bool
 }
 
+// PendingMerge describes the next merge that clustering is about to perform.
+type PendingMerge struct {
+	// I and J are the ids of the clusters to be merged.
+	I, J int
+
+	// SizeI and SizeJ are the number of items in clusters I and J.
+	SizeI, SizeJ int
+
+	// Score is the linkage score of the merge.
+	Score float64
+
+	// Merges is the number of merges applied so far, as recorded in the
+	// history of the HClustering.
+	Merges int
+}
+
+// MergeChecker is an optional extension of Checker that receives the details
+// of the pending merge, including the sizes of both clusters. When the Checker
+// used by HClustering implements MergeChecker, CheckMerge is called instead of
+// Check. The items of each cluster may be enumerated from clusters if needed.
+// The provided combinators (e.g. AndChecker) implement MergeChecker, and pass
+// the pending merge on to the checkers they wrap.
+type MergeChecker interface {
+	Checker
+
+	// CheckMerge decides wether or not to continue merging cluster nodes.
+	// Returns true to continue clustering, false to stop.
+	CheckMerge(clusters ClusterSet, m PendingMerge) bool
+}
+
+// CandidateChecker is an optional extension of Checker for criteria derived
+// from the distribution of linkage scores. When the Checker used by
+// HClustering implements CandidateChecker, or wraps one (e.g. via AndChecker),
+// CheckCandidates is called once with the linkage scores of every candidate
+// pair of clusters scored for the first merge, before Check is called.
+type CandidateChecker interface {
+	Checker
+
+	// CheckCandidates receives the linkage scores of every candidate pair of
+	// clusters, in no particular order.
+	CheckCandidates(scores []float64)
+}
+
 // MaxClusters returns a Checker that limits total number of output clusters.
 func MaxClusters(t int) Checker {
 	return limitClustersCount{t}
@@ -28,6 +77,132 @@ func TreeLog(c Checker) Checker {
 	return clusterTreeLog{c}
 }
 
+// Inconsistent returns a Checker that stops when the next merge score is more
+// than k standard deviations above the mean of the last window merge heights,
+// similar to SciPy's inconsistency criterion. This allows an automatic cutoff
+// without a hand-tuned threshold. If the recent heights have no variance, the
+// merge is always considered consistent.
+func Inconsistent(k float64, window int) Checker {
+	return &inconsistentCheck{k: k, window: window}
+}
+
+// Elbow returns a Checker that tracks the sequence of merge heights and stops
+// at a sharp acceleration (second difference) in merge heights, the standard
+// "elbow" used to pick a cut level without specifying k or a threshold.
+//
+// Because merges are decided online, the largest acceleration of the full
+// sequence cannot be known in advance. Instead, clustering stops when the next
+// acceleration exceeds factor times the largest acceleration observed so far
+// (after at least 3 merges, and once some acceleration has been seen). A
+// factor of 2-3 works well for most data.
+func Elbow(factor float64) Checker {
+	return &elbowCheck{factor: factor}
+}
+
+// Kneedle returns a Checker that applies the online variant of the Kneedle
+// algorithm (Satopaa et al, 2011) to the curve of merge heights, and stops
+// clustering at the detected knee point. The sensitivity parameter S controls
+// how aggressive knee detection is: smaller values detect knees sooner, larger
+// values are more conservative. S=1 is recommended for offline data, but
+// values of 2-3 are more robust for merge heights which arrive online.
+func Kneedle(sensitivity float64) Checker {
+	return &kneedleCheck{sensitivity: sensitivity}
+}
+
+// AndChecker returns a Checker that continues merging only while every one of
+// the checkers continues, i.e. clustering stops as soon as any of them stops.
+// For example, to stop at a threshold of 0.4 or when 10 clusters remain:
+//
+//	AndChecker(Threshold(0.4), MaxClusters(10))
+//
+// Every checker is always consulted, so that stateful checkers observe every
+// merge.
+func AndChecker(chks ...Checker) Checker {
+	return &andChecker{chks: chks}
+}
+
+// OrChecker returns a Checker that continues merging while any one of the
+// checkers continues, i.e. clustering stops only once all of them stop. Every
+// checker is always consulted.
+func OrChecker(chks ...Checker) Checker {
+	return &orChecker{chks: chks}
+}
+
+// NotChecker returns a Checker that inverts the decision of c.
+func NotChecker(c Checker) Checker {
+	return notChecker{c}
+}
+
+// ContextChecker returns a Checker that stops clustering once ctx is cancelled
+// or its deadline passes, so that long clustering runs can be aborted cleanly.
+// Otherwise the decision is delegated to inner.
+func ContextChecker(ctx context.Context, inner Checker) Checker {
+	return &contextCheck{ctx: ctx, chk: inner}
+}
+
+// TimeBudget returns a Checker that stops clustering once d has elapsed since
+// the run started, regardless of scores, leaving the partition reached so far.
+// Otherwise the decision is delegated to inner. This is important for
+// interactive and online use. The clock starts at the first check of every run
+// (see PendingMerge.Merges), so the Checker may be created ahead of time and
+// reused. If it is only consulted through Check, e.g. by a custom wrapper, the
+// clock starts at the first check and is never reset.
+func TimeBudget(d time.Duration, inner Checker) Checker {
+	return &timeBudgetCheck{budget: d, chk: inner}
+}
+
+// MaxMerges returns a Checker that limits the total number of merges that are
+// performed, independent of the final number of clusters. This is useful for
+// step-wise clustering UIs that advance a fixed number of merges at a time.
+// The merges applied are taken from the history of the HClustering (see
+// PendingMerge). If it is only consulted through Check, e.g. by a custom
+// wrapper, merges are counted from the number of clusters at the first check
+// instead, so a new Checker should be used for each run.
+func MaxMerges(n int) Checker {
+	return &maxMergesCheck{max: n}
+}
+
+// RelativeJump returns a Checker that stops when the next merge score exceeds
+// the previous merge score by more than the given ratio (e.g. 2.0 stops when
+// the score more than doubles). This is more dataset-agnostic than an absolute
+// Threshold. Jumps from a previous score of zero are ignored.
+func RelativeJump(ratio float64) Checker {
+	return &relativeJumpCheck{ratio: ratio, prev: -1.0}
+}
+
+// PercentileThreshold returns a Checker that derives its threshold from the
+// data. It receives the linkage scores of every candidate pair of clusters
+// scored for the first merge (see CandidateChecker), and stops merging above
+// the p-th percentile (0-100) of that distribution, avoiding manual threshold
+// tuning per dataset. As the scores are computed by the linkage method, they
+// reflect any pre-merged clusters and use the distance cache, Memo and Limits
+// of the run. If no scores are received, e.g. when the Checker is hidden in a
+// custom wrapper, it calibrates itself from the average item distance between
+// every pair of clusters at the first check instead.
+func PercentileThreshold(p float64) Checker {
+	return &percentileCheck{p: p, cutoff: math.NaN()}
+}
+
+// AdaptiveThreshold returns a Checker that maintains a running mean and
+// standard deviation of all applied merge scores (using Welford's online
+// algorithm), and stops when the next score is more than k standard deviations
+// above the mean. Unlike Inconsistent, every applied merge is considered and
+// only O(1) memory is used. Merges accepted by this Checker but then blocked
+// (e.g. by Limits or an enclosing AndChecker) are not counted. At least
+// minMerges merges are always accepted before the criterion applies, so that
+// the statistics are meaningful. If the applied scores have no variance, the
+// merge is always accepted.
+func AdaptiveThreshold(k float64, minMerges int) Checker {
+	return &adaptiveCheck{k: k, minMerges: minMerges}
+}
+
+// MaxMergeSize returns a Checker that stops clustering before two clusters
+// that each already contain at least n items would be merged. Smaller clusters
+// may still be absorbed into large ones until then.
+func MaxMergeSize(n int) Checker {
+	return maxMergeSize{n}
+}
+
 /////////////
 
 type simpleThreshold struct {
@@ -38,6 +213,10 @@ func (t simpleThreshold) Check(clusters ClusterSet, i, j int, nextScore float64)
 	return nextScore <= t.val
 }
 
+func (t simpleThreshold) Describe() Description {
+	return Description{Name: "threshold", Params: map[string]interface{}{"threshold": t.val}}
+}
+
 /////////////
 
 type clusterTreeLog struct {
@@ -45,7 +224,14 @@ type clusterTreeLog struct {
 }
 
 func (c clusterTreeLog) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
-	t := c.chk.Check(clusters, i, j, nextScore)
+	return c.log(clusters, i, j, nextScore, c.chk.Check(clusters, i, j, nextScore))
+}
+
+func (c clusterTreeLog) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	return c.log(clusters, m.I, m.J, m.Score, checkMerge(c.chk, clusters, m))
+}
+
+func (c clusterTreeLog) log(clusters ClusterSet, i, j int, nextScore float64, t bool) bool {
 	if t {
 		log.Printf("  merge (%d,%d) ~~ %f %v", i, j, nextScore, clusters)
 	} else {
@@ -54,6 +240,18 @@ func (c clusterTreeLog) Check(clusters ClusterSet, i, j int, nextScore float64)
 	return t
 }
 
+func (c clusterTreeLog) inner() []Checker {
+	return []Checker{c.chk}
+}
+
+func (c clusterTreeLog) Describe() Description {
+	return Description{Name: "tree-log", Inner: []Description{Describe(c.chk)}}
+}
+
+func (c clusterTreeLog) stopCause() []Checker {
+	return []Checker{c.chk}
+}
+
 //////////////
 
 type limitClustersCount struct {
@@ -63,3 +261,501 @@ type limitClustersCount struct {
 func (t limitClustersCount) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
 	return clusters.Count() > t.val
 }
+
+func (t limitClustersCount) Describe() Description {
+	return Description{Name: "max-clusters", Params: map[string]interface{}{"max": t.val}}
+}
+
+//////////////
+
+type inconsistentCheck struct {
+	k      float64
+	window int
+
+	heights []float64
+}
+
+func (c *inconsistentCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	if n := len(c.heights); n >= 2 {
+		mean := 0.0
+		for _, x := range c.heights {
+			mean += x
+		}
+		mean /= float64(n)
+		sd := 0.0
+		for _, x := range c.heights {
+			sd += (x - mean) * (x - mean)
+		}
+		sd = math.Sqrt(sd / float64(n-1))
+
+		if sd > 0.0 && (nextScore-mean)/sd > c.k {
+			return false
+		}
+	}
+
+	c.heights = append(c.heights, nextScore)
+	if c.window > 0 && len(c.heights) > c.window {
+		c.heights = c.heights[1:]
+	}
+	return true
+}
+
+func (c *inconsistentCheck) Describe() Description {
+	return Description{Name: "inconsistent", Params: map[string]interface{}{
+		"k": c.k, "window": c.window}}
+}
+
+//////////////
+
+type elbowCheck struct {
+	factor float64
+
+	heights  []float64
+	maxAccel float64
+}
+
+func (c *elbowCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	if n := len(c.heights); n >= 2 {
+		accel := nextScore - 2*c.heights[n-1] + c.heights[n-2]
+		if n >= 3 && c.maxAccel > 0.0 && accel > c.factor*c.maxAccel {
+			return false
+		}
+		if accel > c.maxAccel {
+			c.maxAccel = accel
+		}
+	}
+	c.heights = append(c.heights, nextScore)
+	return true
+}
+
+func (c *elbowCheck) Describe() Description {
+	return Description{Name: "elbow", Params: map[string]interface{}{"factor": c.factor}}
+}
+
+//////////////
+
+type kneedleCheck struct {
+	sensitivity float64
+
+	heights []float64
+}
+
+func (c *kneedleCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	pts := append(c.heights, nextScore)
+	if c.isKnee(pts) {
+		return false
+	}
+	c.heights = pts
+	return true
+}
+
+// isKnee returns true if the last point of the curve falls below the Kneedle
+// threshold of the most recent local maximum of the difference curve.
+func (c *kneedleCheck) isKnee(ys []float64) bool {
+	m := len(ys)
+	if m < 3 {
+		return false
+	}
+	ymin, ymax := ys[0], ys[0]
+	for _, y := range ys {
+		ymin = math.Min(ymin, y)
+		ymax = math.Max(ymax, y)
+	}
+	if ymax <= ymin {
+		return false
+	}
+
+	// difference curve of the normalized (increasing, convex) curve
+	step := 1.0 / float64(m-1)
+	diff := make([]float64, m)
+	for k, y := range ys {
+		diff[k] = float64(k)*step - (y-ymin)/(ymax-ymin)
+	}
+
+	for k := m - 2; k > 0; k-- {
+		if diff[k] >= diff[k-1] && diff[k] > diff[k+1] {
+			threshold := diff[k] - c.sensitivity*step
+			return diff[m-1] < threshold
+		}
+	}
+	return false
+}
+
+func (c *kneedleCheck) Describe() Description {
+	return Description{Name: "kneedle", Params: map[string]interface{}{"sensitivity": c.sensitivity}}
+}
+
+//////////////
+
+type andChecker struct {
+	chks    []Checker
+	stopped []Checker
+}
+
+func (c *andChecker) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	return c.check(func(chk Checker) bool {
+		return chk.Check(clusters, i, j, nextScore)
+	})
+}
+
+func (c *andChecker) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	return c.check(func(chk Checker) bool {
+		return checkMerge(chk, clusters, m)
+	})
+}
+
+func (c *andChecker) check(check func(Checker) bool) bool {
+	c.stopped = c.stopped[:0]
+	for _, chk := range c.chks {
+		if !check(chk) {
+			c.stopped = append(c.stopped, chk)
+		}
+	}
+	return len(c.stopped) == 0
+}
+
+func (c *andChecker) inner() []Checker {
+	return c.chks
+}
+
+func (c *andChecker) Describe() Description {
+	return Description{Name: "and", Inner: describeAll(c.chks)}
+}
+
+func (c *andChecker) stopCause() []Checker {
+	return c.stopped
+}
+
+//////////////
+
+type orChecker struct {
+	chks []Checker
+}
+
+func (c *orChecker) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	return c.check(func(chk Checker) bool {
+		return chk.Check(clusters, i, j, nextScore)
+	})
+}
+
+func (c *orChecker) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	return c.check(func(chk Checker) bool {
+		return checkMerge(chk, clusters, m)
+	})
+}
+
+func (c *orChecker) check(check func(Checker) bool) bool {
+	res := false
+	for _, chk := range c.chks {
+		if check(chk) {
+			res = true
+		}
+	}
+	return res
+}
+
+func (c *orChecker) inner() []Checker {
+	return c.chks
+}
+
+func (c *orChecker) Describe() Description {
+	return Description{Name: "or", Inner: describeAll(c.chks)}
+}
+
+func (c *orChecker) stopCause() []Checker {
+	return c.chks
+}
+
+func describeAll(chks []Checker) []Description {
+	res := make([]Description, len(chks))
+	for i, chk := range chks {
+		res[i] = Describe(chk)
+	}
+	return res
+}
+
+//////////////
+
+type notChecker struct {
+	chk Checker
+}
+
+func (c notChecker) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	return !c.chk.Check(clusters, i, j, nextScore)
+}
+
+func (c notChecker) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	return !checkMerge(c.chk, clusters, m)
+}
+
+func (c notChecker) inner() []Checker {
+	return []Checker{c.chk}
+}
+
+func (c notChecker) Describe() Description {
+	return Description{Name: "not", Inner: []Description{Describe(c.chk)}}
+}
+
+//////////////
+
+type contextCheck struct {
+	ctx context.Context
+	chk Checker
+
+	expired bool
+}
+
+func (c *contextCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	c.expired = c.ctx.Err() != nil
+	return !c.expired && c.chk.Check(clusters, i, j, nextScore)
+}
+
+func (c *contextCheck) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	c.expired = c.ctx.Err() != nil
+	return !c.expired && checkMerge(c.chk, clusters, m)
+}
+
+func (c *contextCheck) inner() []Checker {
+	return []Checker{c.chk}
+}
+
+func (c *contextCheck) Describe() Description {
+	return Description{Name: "context", Inner: []Description{Describe(c.chk)}}
+}
+
+func (c *contextCheck) stopCause() []Checker {
+	if c.expired {
+		return nil
+	}
+	return []Checker{c.chk}
+}
+
+//////////////
+
+type timeBudgetCheck struct {
+	budget time.Duration
+	chk    Checker
+
+	start   time.Time
+	expired bool
+}
+
+func (c *timeBudgetCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	if c.start.IsZero() {
+		c.start = time.Now()
+	}
+	c.expired = time.Since(c.start) > c.budget
+	return !c.expired && c.chk.Check(clusters, i, j, nextScore)
+}
+
+func (c *timeBudgetCheck) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	if m.Merges == 0 || c.start.IsZero() {
+		// a new run
+		c.start = time.Now()
+	}
+	c.expired = time.Since(c.start) > c.budget
+	return !c.expired && checkMerge(c.chk, clusters, m)
+}
+
+func (c *timeBudgetCheck) stopCause() []Checker {
+	if c.expired {
+		return nil
+	}
+	return []Checker{c.chk}
+}
+
+func (c *timeBudgetCheck) inner() []Checker {
+	return []Checker{c.chk}
+}
+
+func (c *timeBudgetCheck) Describe() Description {
+	return Description{Name: "time-budget", Params: map[string]interface{}{
+		"budget": c.budget.String()}, Inner: []Description{Describe(c.chk)}}
+}
+
+//////////////
+
+type maxMergesCheck struct {
+	max   int
+	start int
+}
+
+// Check counts the merges performed from the number of clusters remaining, so
+// that checks whose merge was rejected (e.g. by an enclosing AndChecker) are
+// not counted.
+func (c *maxMergesCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	if c.start == 0 {
+		c.start = clusters.Count()
+	}
+	return c.start-clusters.Count() < c.max
+}
+
+func (c *maxMergesCheck) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	return m.Merges < c.max
+}
+
+func (c *maxMergesCheck) Describe() Description {
+	return Description{Name: "max-merges", Params: map[string]interface{}{"max": c.max}}
+}
+
+//////////////
+
+type relativeJumpCheck struct {
+	ratio float64
+	prev  float64
+}
+
+func (c *relativeJumpCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	if c.prev > 0.0 && nextScore > c.ratio*c.prev {
+		return false
+	}
+	c.prev = nextScore
+	return true
+}
+
+func (c *relativeJumpCheck) Describe() Description {
+	return Description{Name: "relative-jump", Params: map[string]interface{}{"ratio": c.ratio}}
+}
+
+//////////////
+
+type percentileCheck struct {
+	p      float64
+	cutoff float64
+
+	// received is set when the candidate scores of the current run were
+	// received, so that the Checker does not calibrate itself.
+	received bool
+}
+
+func (c *percentileCheck) CheckCandidates(scores []float64) {
+	sorted := append([]float64(nil), scores...)
+	sort.Float64s(sorted)
+	c.cutoff = percentileOf(sorted, c.p)
+	c.received = true
+}
+
+// Check calibrates the cutoff at the first check if no scores were received.
+func (c *percentileCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	if math.IsNaN(c.cutoff) && !c.received {
+		c.calibrate(clusters)
+	}
+	return math.IsNaN(c.cutoff) || nextScore <= c.cutoff
+}
+
+// CheckMerge calibrates the cutoff at the first merge of every run, unless the
+// candidate scores were received.
+func (c *percentileCheck) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	if m.Merges == 0 && !c.received {
+		c.calibrate(clusters)
+	}
+	c.received = false
+	return math.IsNaN(c.cutoff) || m.Score <= c.cutoff
+}
+
+// calibrate derives the cutoff from the average distance between the items of
+// every pair of clusters, which is the average linkage score of each pair.
+func (c *percentileCheck) calibrate(clusters ClusterSet) {
+	var scores []float64
+	clusters.EachCluster(-1, func(c1 int) {
+		clusters.EachCluster(c1, func(c2 int) {
+			sum, n := 0.0, 0
+			clusters.EachItem(c1, func(item1 ClusterItem) {
+				clusters.EachItem(c2, func(item2 ClusterItem) {
+					sum += clusters.Distance(c1, c2, item1, item2)
+					n++
+				})
+			})
+			if n > 0 {
+				scores = append(scores, sum/float64(n))
+			}
+		})
+	})
+	c.CheckCandidates(scores)
+	c.received = false
+}
+
+func (c *percentileCheck) Describe() Description {
+	return Description{Name: "percentile-threshold", Params: map[string]interface{}{"percentile": c.p}}
+}
+
+//////////////
+
+type maxMergeSize struct {
+	n int
+}
+
+func (c maxMergeSize) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	return c.CheckMerge(clusters, PendingMerge{
+		I: i, J: j, Score: nextScore,
+		SizeI: clusterSize(clusters, i),
+		SizeJ: clusterSize(clusters, j),
+	})
+}
+
+func (c maxMergeSize) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	return m.SizeI < c.n || m.SizeJ < c.n
+}
+
+func (c maxMergeSize) Describe() Description {
+	return Description{Name: "max-merge-size", Params: map[string]interface{}{"size": c.n}}
+}
+
+//////////////
+
+type adaptiveCheck struct {
+	k         float64
+	minMerges int
+
+	n    int
+	mean float64
+	m2   float64
+
+	// the last accepted score, which is added to the statistics once its
+	// merge is known to have been applied (i.e. it was not blocked by Limits
+	// or an enclosing checker).
+	pending    float64
+	pendingAt  int
+	hasPending bool
+}
+
+// Check detects applied merges from the number of clusters remaining.
+func (c *adaptiveCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	n := clusters.Count()
+	c.settle(n < c.pendingAt)
+	return c.check(nextScore, n)
+}
+
+func (c *adaptiveCheck) CheckMerge(clusters ClusterSet, m PendingMerge) bool {
+	// merges are counted up instead of clusters down, so negate them to
+	// compare the same way as Check.
+	c.settle(-m.Merges < c.pendingAt)
+	return c.check(m.Score, -m.Merges)
+}
+
+// settle adds the pending score to the statistics if its merge was applied.
+func (c *adaptiveCheck) settle(applied bool) {
+	if c.hasPending && applied {
+		c.n++
+		delta := c.pending - c.mean
+		c.mean += delta / float64(c.n)
+		c.m2 += delta * (c.pending - c.mean)
+	}
+	c.hasPending = false
+}
+
+func (c *adaptiveCheck) check(nextScore float64, at int) bool {
+	if c.n >= 2 && c.n >= c.minMerges {
+		sd := math.Sqrt(c.m2 / float64(c.n-1))
+		if sd > 0.0 && nextScore > c.mean+c.k*sd {
+			return false
+		}
+	}
+	c.pending, c.pendingAt, c.hasPending = nextScore, at, true
+	return true
+}
+
+func (c *adaptiveCheck) Describe() Description {
+	return Description{Name: "adaptive-threshold", Params: map[string]interface{}{
+		"k": c.k, "min-merges": c.minMerges}}
+}
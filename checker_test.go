@@ -0,0 +1,324 @@
+package clustering
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// twoGroups returns a DistanceMap with two tight, well separated groups of
+// items: a0..a4 near position 0, and b0..b4 near position 10.
+func twoGroups() DistanceMap {
+	pos := map[string]float64{}
+	for i := 0; i < 5; i++ {
+		pos[fmt.Sprint("a", i)] = float64(i) * 0.125
+		pos[fmt.Sprint("b", i)] = 10.0 + float64(i)*0.25
+	}
+	d := make(DistanceMap)
+	for a, pa := range pos {
+		d[a] = make(map[ClusterItem]float64)
+		for b, pb := range pos {
+			if a < b {
+				x := pa - pb
+				if x < 0 {
+					x = -x
+				}
+				d[a][b] = x
+			}
+		}
+	}
+	return d
+}
+
+func TestSilhouetteChecker(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, Silhouette(0.0), AverageLinkage())
+	if d.Count() != 2 {
+		t.Errorf("silhouette checker should stop at 2 clusters, got %d", d.Count())
+	}
+
+	// distances within pre-merged clusters come from ItemDistance, or are
+	// left out without it, but never from Distance
+	for _, items := range []bool{true, false} {
+		d = NewDistanceMapClusterSet(twoGroups())
+		mergeItemGroups(d, [][]ClusterItem{{"a0", "a1", "a2"}, {"b3", "b4"}})
+		var cs ClusterSet = &strictClusterSet{d, t}
+		if !items {
+			cs = struct{ ClusterSet }{cs}
+		}
+		Cluster(cs, Silhouette(0.0), AverageLinkage())
+		if d.Count() != 2 {
+			t.Errorf("silhouette checker should stop at 2 clusters, got %d", d.Count())
+		}
+	}
+}
+
+func TestInconsistentChecker(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, Inconsistent(3.0, 0), SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("inconsistency checker should stop at 2 clusters, got %d", d.Count())
+	}
+}
+
+func TestElbowChecker(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, Elbow(2.0), SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("elbow checker should stop at 2 clusters, got %d", d.Count())
+	}
+}
+
+func TestKneedleChecker(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, Kneedle(3.0), SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("kneedle checker should stop at 2 clusters, got %d", d.Count())
+	}
+}
+
+// checkerFunc adapts a function to the Checker interface.
+type checkerFunc func(clusters ClusterSet, i, j int, nextScore float64) bool
+
+func (f checkerFunc) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	return f(clusters, i, j, nextScore)
+}
+
+func TestContextChecker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	checks := 0
+	inner := checkerFunc(func(clusters ClusterSet, i, j int, nextScore float64) bool {
+		checks++
+		if checks == 3 {
+			cancel()
+		}
+		return true
+	})
+
+	d := NewDistanceMapClusterSet(twoGroups())
+	r := ClusterWithReport(d, ContextChecker(ctx, inner), SingleLinkage())
+	// the third merge is accepted before the cancellation is observed
+	if r.StopReason != CheckerStopped || r.Merges != 3 || d.Count() != 7 {
+		t.Errorf("expected clustering to stop after 3 merges, got %+v", r)
+	}
+	if r.StoppedBy == nil || r.StoppedBy.Name != "context" {
+		t.Errorf("expected the context checker to stop clustering, got %+v", r.StoppedBy)
+	}
+
+	// an uncancelled context delegates to the inner checker
+	d = NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, ContextChecker(context.Background(), MaxClusters(4)), SingleLinkage())
+	if d.Count() != 4 {
+		t.Errorf("expected the inner checker to stop at 4 clusters, got %d", d.Count())
+	}
+
+	// configurations are bound to the given context
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	chk, err := ParseCheckerContext(ctx, []byte(`{"name": "context", "inner": [{"name": "threshold", "params": {"threshold": 100}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, chk, SingleLinkage())
+	if d.Count() != 10 {
+		t.Errorf("expected a cancelled context to stop immediately, got %d clusters", d.Count())
+	}
+}
+
+func TestTimeBudget(t *testing.T) {
+	// the budget is measured from the start of each run, not from creation
+	chk, err := ParseChecker([]byte(`{"name": "time-budget", "params": {"budget": "50ms"},
+		"inner": [{"name": "threshold", "params": {"threshold": 100}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	for run := 0; run < 2; run++ {
+		d := NewDistanceMapClusterSet(twoGroups())
+		r := ClusterWithReport(d, chk, SingleLinkage())
+		if r.Merges != 9 {
+			t.Errorf("run %d: expected a complete run within the budget, got %+v", run, r)
+		}
+		time.Sleep(60 * time.Millisecond)
+	}
+
+	// a run that takes longer than the budget is stopped
+	slow := checkerFunc(func(clusters ClusterSet, i, j int, nextScore float64) bool {
+		time.Sleep(20 * time.Millisecond)
+		return true
+	})
+	d := NewDistanceMapClusterSet(twoGroups())
+	r := ClusterWithReport(d, TimeBudget(30*time.Millisecond, slow), SingleLinkage())
+	if r.Merges == 0 || r.Merges >= 9 || r.StoppedBy == nil || r.StoppedBy.Name != "time-budget" {
+		t.Errorf("expected the budget to stop the run early, got %+v", r)
+	}
+
+	// within the budget the decision is delegated, even inside AndChecker
+	d = NewDistanceMapClusterSet(twoGroups())
+	r = ClusterWithReport(d, AndChecker(TimeBudget(time.Hour, Threshold(100)), MaxClusters(4)), SingleLinkage())
+	if d.Count() != 4 || r.StoppedBy == nil || r.StoppedBy.Name != "max-clusters" {
+		t.Errorf("expected max-clusters to stop at 4 clusters, got %d and %+v", d.Count(), r.StoppedBy)
+	}
+}
+
+func TestCheckerCombinators(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, AndChecker(Threshold(100), MaxClusters(4)), SingleLinkage())
+	if d.Count() != 4 {
+		t.Errorf("AndChecker should stop at 4 clusters, got %d", d.Count())
+	}
+
+	d = NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, OrChecker(Threshold(1), MaxClusters(4)), SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("OrChecker should stop at 2 clusters, got %d", d.Count())
+	}
+
+	d = NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, NotChecker(MaxClusters(1)), SingleLinkage())
+	if d.Count() != 10 {
+		t.Errorf("NotChecker should stop immediately, got %d clusters", d.Count())
+	}
+}
+
+func TestRelativeJumpChecker(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, RelativeJump(3.0), SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("relative jump checker should stop at 2 clusters, got %d", d.Count())
+	}
+}
+
+func TestStopReport(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	r := ClusterWithReport(d, AndChecker(Threshold(100), MaxClusters(4)), SingleLinkage())
+	if r.StopReason != CheckerStopped || r.StoppedBy == nil || r.StoppedBy.Name != "max-clusters" {
+		t.Errorf("expected max-clusters to stop clustering, got %+v", r)
+	}
+	if r.Merges != 6 || r.FinalScore <= 0 {
+		t.Errorf("expected 6 merges and a final score, got %+v", r)
+	}
+}
+
+func TestMaxMergeSize(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, MaxMergeSize(3), SingleLinkage())
+	if sizes := clusterSizes(d); sizes[5] != 2 {
+		t.Errorf("expected two clusters of 5 items, got sizes %v", sizes)
+	}
+}
+
+func TestAdaptiveThreshold(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, AdaptiveThreshold(3, 2), SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", d.Count())
+	}
+
+	// the statistics follow the merges as they are applied, but not a merge
+	// blocked by Limits
+	chk := AdaptiveThreshold(3, 2).(*adaptiveCheck)
+	h := &HClustering{
+		ClusterSet:  NewDistanceMapClusterSet(twoGroups()),
+		Checker:     chk,
+		LinkageType: SingleLinkage(),
+		Limits:      Limits{MaxMerges: 3},
+	}
+	for k := 0; h.MergeNext(); k++ {
+		merges := h.Dendrogram().Merges
+		if chk.n != k {
+			t.Fatalf("expected %d merges in the statistics, got %d", k, chk.n)
+		}
+		sum := 0.0
+		for _, m := range merges[:k] {
+			sum += m.Height
+		}
+		if k > 0 && math.Abs(chk.mean-sum/float64(k)) > 1e-12 {
+			t.Errorf("expected mean %f after %d merges, got %f", sum/float64(k), k, chk.mean)
+		}
+	}
+	if _, ok := h.Err().(*LimitError); !ok || chk.n != 3 {
+		t.Errorf("expected the blocked merge to be left out, got %d merges and %v", chk.n, h.Err())
+	}
+}
+
+func TestMaxMerges(t *testing.T) {
+	limit := 0.2
+	h := &HClustering{
+		ClusterSet: NewDistanceMapClusterSet(twoGroups()),
+		Checker: AndChecker(MaxMerges(6), checkerFunc(func(clusters ClusterSet, i, j int, nextScore float64) bool {
+			return nextScore <= limit
+		})),
+		LinkageType: SingleLinkage(),
+	}
+	h.Run()
+	if h.ClusterSet.Count() != 6 {
+		t.Fatalf("expected the threshold to stop at 6 clusters, got %d", h.ClusterSet.Count())
+	}
+
+	// the rejected merge is not counted when clustering resumes
+	limit = 100
+	h.Run()
+	if h.ClusterSet.Count() != 4 {
+		t.Errorf("expected 6 merges in total, got %d clusters", h.ClusterSet.Count())
+	}
+
+	// removing an item that empties its cluster is not a merge
+	h = &HClustering{
+		ClusterSet:  NewDistanceMapClusterSet(twoGroups()),
+		Checker:     MaxMerges(3),
+		LinkageType: SingleLinkage(),
+	}
+	h.MergeNext()
+	if !h.RemoveItem("b4") {
+		t.Fatalf("expected b4 to be removed")
+	}
+	h.Run()
+	if h.Result().Merges != 3 || h.ClusterSet.Count() != 6 {
+		t.Errorf("expected 3 merges leaving 6 clusters, got %d and %d", h.Result().Merges, h.ClusterSet.Count())
+	}
+}
+
+// candidateRecorder records the candidate scores it receives.
+type candidateRecorder struct {
+	scores []float64
+}
+
+func (c *candidateRecorder) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	return true
+}
+
+func (c *candidateRecorder) CheckCandidates(scores []float64) {
+	c.scores = append(c.scores, scores...)
+}
+
+func TestPercentileThreshold(t *testing.T) {
+	d := NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, PercentileThreshold(40), SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", d.Count())
+	}
+
+	// candidates are the linkage scores of clusters, not of items
+	d = NewDistanceMapClusterSet(twoGroups())
+	PremergeWithin(d, 0.3)
+	rec := &candidateRecorder{}
+	Cluster(d, AndChecker(rec, Threshold(5)), CompleteLinkage())
+	if len(rec.scores) != 1 || rec.scores[0] != 11 {
+		t.Errorf("expected the single complete linkage score 11, got %v", rec.scores)
+	}
+
+	// hidden from CandidateChecker discovery, the checker calibrates itself
+	chk := PercentileThreshold(40)
+	hidden := checkerFunc(func(clusters ClusterSet, i, j int, nextScore float64) bool {
+		return chk.Check(clusters, i, j, nextScore)
+	})
+	d = NewDistanceMapClusterSet(twoGroups())
+	Cluster(d, hidden, SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("expected a self-calibrated checker to stop at 2 clusters, got %d", d.Count())
+	}
+}
@@ -0,0 +1,49 @@
+package clustering
+
+// CloneableClusterSet is an optional interface for ClusterSets that can be
+// copied, so that several clusterings (e.g. different thresholds or linkages)
+// can be run against the same source data.
+type CloneableClusterSet interface {
+	ClusterSet
+
+	// Clone returns an independent copy of the current clusters. Merging
+	// clusters in the copy must not affect the original, but the underlying
+	// distance data may be shared.
+	Clone() ClusterSet
+}
+
+// ClusterCopy clusters a copy of the input set using the specified linkage
+// type until the provided threshold is hit, leaving the input unchanged.
+func ClusterCopy(c CloneableClusterSet, chk Checker, lt LinkageType) ClusterSet {
+	cp := c.Clone()
+	Cluster(cp, chk, lt)
+	return cp
+}
+
+// clone returns a copy of the cluster list.
+func (d *clusterList) clone() clusterList {
+	res := clusterList{clusters: make([][]ClusterItem, len(d.clusters))}
+	for i, items := range d.clusters {
+		res.clusters[i] = append([]ClusterItem(nil), items...)
+	}
+	return res
+}
+
+// Clone returns a copy of the clusters that shares the distance map.
+func (d *distMapClusterSet) Clone() ClusterSet {
+	return &distMapClusterSet{
+		clusterList: d.clusterList.clone(),
+		data:        d.data,
+		data32:      d.data32,
+		policy:      d.policy,
+	}
+}
+
+// Clone returns a copy of the clusters that shares the distance map and
+// weights.
+func (d *weightedDistMapClusterSet) Clone() ClusterSet {
+	return &weightedDistMapClusterSet{
+		distMapClusterSet: d.distMapClusterSet.Clone().(*distMapClusterSet),
+		weights:           d.weights,
+	}
+}
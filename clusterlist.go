@@ -0,0 +1,69 @@
+package clustering
+
+// clusterList implements the cluster enumeration and merging parts of a
+// ClusterSet using a simple slice of item lists. Clusters are merged by
+// appending items, and the last cluster is swapped into the removed position.
+type clusterList struct {
+	clusters [][]ClusterItem
+}
+
+func (d *clusterList) EachCluster(start int, cb func(cluster int)) {
+	if start+1 >= len(d.clusters) {
+		return
+	}
+
+	for i := start + 1; i < len(d.clusters); i++ {
+		cb(i)
+	}
+}
+
+func (d *clusterList) EachItem(cluster int, cb func(ClusterItem)) {
+	for _, x := range d.clusters[cluster] {
+		cb(x)
+	}
+}
+
+func (d *clusterList) ClusterSize(cluster int) int {
+	return len(d.clusters[cluster])
+}
+
+func (d *clusterList) Count() int {
+	return len(d.clusters)
+}
+
+func (d *clusterList) Merge(i, j int) (keep, swappedIn int) {
+	if j < i {
+		j, i = i, j
+	}
+
+	// move the to-be-merged cluster to the end of the array
+	x := len(d.clusters) - 1
+	if j < x {
+		d.clusters[x], d.clusters[j] = d.clusters[j], d.clusters[x]
+		j = x
+	}
+	d.clusters[i] = append(d.clusters[i], d.clusters[j]...)
+	d.clusters = d.clusters[:j]
+	return i, x
+}
+
+// removeItem removes the item from its cluster, removing the cluster if it
+// is empty by moving the last cluster into its position.
+func (d *clusterList) removeItem(item ClusterItem) (cluster int, empty bool) {
+	for c, items := range d.clusters {
+		for k, x := range items {
+			if x != item {
+				continue
+			}
+			d.clusters[c] = append(items[:k:k], items[k+1:]...)
+			if len(d.clusters[c]) > 0 {
+				return c, false
+			}
+			last := len(d.clusters) - 1
+			d.clusters[c] = d.clusters[last]
+			d.clusters = d.clusters[:last]
+			return c, true
+		}
+	}
+	return -1, false
+}
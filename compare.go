@@ -0,0 +1,143 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Divergence describes the first structural difference found between a
+// Dendrogram and a reference linkage matrix.
+type Divergence struct {
+	// Step is the index of the merge (in this package's merge order) where the
+	// divergence was found.
+	Step int
+
+	// Reason is a short description of the divergence.
+	Reason string
+
+	// Items are the members of the cluster created by this package at Step.
+	Items []ClusterItem
+
+	// Height is the merge height computed by this package at Step.
+	Height float64
+
+	// ReferenceItems and ReferenceHeight describe the cluster created by the
+	// reference linkage matrix at the same step, for context.
+	ReferenceItems  []ClusterItem
+	ReferenceHeight float64
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("step %d: %s\n  got:  %v @ %g\n  want: %v @ %g",
+		d.Step, d.Reason, d.Items, d.Height, d.ReferenceItems, d.ReferenceHeight)
+}
+
+// CompareLinkageMatrix compares the merge sequence of d against a reference
+// linkage matrix z produced by SciPy (scipy.cluster.hierarchy.linkage) or R
+// (converted to the SciPy layout). Each row of z is [node1, node2, height,
+// size], and labels[k] is the item corresponding to observation k of the
+// reference data. Every leaf of d must contain exactly one item.
+//
+// Clusters are compared by membership, so merges of equal height may occur
+// in any order. Heights must match within tol. Returns nil if the two
+// hierarchies are equivalent, otherwise the first divergence in d's merge
+// order.
+func CompareLinkageMatrix(d *Dendrogram, z [][]float64, labels []ClusterItem, tol float64) (*Divergence, error) {
+	n := len(labels)
+	obs := make(map[ClusterItem]int, n)
+	for i, x := range labels {
+		obs[x] = i
+	}
+
+	// membership of every reference node, as sorted observation indices
+	ref := make([][]int, n+len(z))
+	for i := 0; i < n; i++ {
+		ref[i] = []int{i}
+	}
+	refSteps := make(map[string]int, len(z))
+	for r, row := range z {
+		if len(row) < 3 {
+			return nil, fmt.Errorf("clustering: linkage matrix row %d has %d columns", r, len(row))
+		}
+		a, b := int(row[0]), int(row[1])
+		if a < 0 || b < 0 || a >= n+r || b >= n+r {
+			return nil, fmt.Errorf("clustering: linkage matrix row %d refers to invalid node", r)
+		}
+		ref[n+r] = mergeSorted(ref[a], ref[b])
+		refSteps[obsKey(ref[n+r])] = r
+	}
+
+	// membership of every node in d
+	nl := len(d.Leaves)
+	ours := make([][]int, nl+len(d.Merges))
+	for i, leaf := range d.Leaves {
+		if len(leaf) != 1 {
+			return nil, fmt.Errorf("clustering: leaf %d does not contain exactly one item", i)
+		}
+		k, ok := obs[leaf[0]]
+		if !ok {
+			return nil, fmt.Errorf("clustering: item %v is not in the reference labels", leaf[0])
+		}
+		ours[i] = []int{k}
+	}
+
+	toItems := func(idx []int) []ClusterItem {
+		res := make([]ClusterItem, len(idx))
+		for i, k := range idx {
+			res[i] = labels[k]
+		}
+		return res
+	}
+	divergence := func(step int, reason string) *Divergence {
+		dv := &Divergence{Step: step, Reason: reason, ReferenceHeight: math.NaN(), Height: math.NaN()}
+		if step < len(d.Merges) {
+			dv.Items = toItems(ours[nl+step])
+			dv.Height = d.Merges[step].Height
+		}
+		if step < len(z) {
+			dv.ReferenceItems = toItems(ref[n+step])
+			dv.ReferenceHeight = z[step][2]
+		}
+		return dv
+	}
+
+	for s, m := range d.Merges {
+		ours[nl+s] = mergeSorted(ours[m.A], ours[m.B])
+		r, ok := refSteps[obsKey(ours[nl+s])]
+		if !ok {
+			return divergence(s, "cluster does not exist in reference"), nil
+		}
+		if math.Abs(z[r][2]-m.Height) > tol {
+			dv := divergence(s, fmt.Sprintf("height differs from reference step %d", r))
+			dv.ReferenceItems = toItems(ref[n+r])
+			dv.ReferenceHeight = z[r][2]
+			return dv, nil
+		}
+	}
+	if len(d.Merges) != len(z) {
+		return divergence(len(d.Merges), fmt.Sprintf("%d merges but reference has %d",
+			len(d.Merges), len(z))), nil
+	}
+	return nil, nil
+}
+
+func mergeSorted(a, b []int) []int {
+	res := make([]int, 0, len(a)+len(b))
+	res = append(append(res, a...), b...)
+	sort.Ints(res)
+	return res
+}
+
+func obsKey(idx []int) string {
+	var sb strings.Builder
+	for i, k := range idx {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.Itoa(k))
+	}
+	return sb.String()
+}
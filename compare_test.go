@@ -0,0 +1,48 @@
+package clustering
+
+import "testing"
+
+func TestCompareLinkageMatrix(t *testing.T) {
+	h := &HClustering{
+		ClusterSet:  NewDistanceMapClusterSet(testLine()),
+		Checker:     Threshold(100),
+		LinkageType: SingleLinkage(),
+	}
+	for h.MergeNext() {
+	}
+
+	d := h.Dendrogram()
+	if len(d.Leaves) != 4 || len(d.Merges) != 3 {
+		t.Errorf("expected 4 leaves and 3 merges, got %d and %d", len(d.Leaves), len(d.Merges))
+	}
+	if d.Merges[2].Size != 4 || len(d.Items(6)) != 4 {
+		t.Errorf("root node should contain 4 items")
+	}
+
+	labels := []ClusterItem{"a", "b", "c", "d"}
+	z := [][]float64{
+		{0, 1, 1, 2},
+		{2, 4, 2, 3},
+		{3, 5, 4, 4},
+	}
+	dv, err := CompareLinkageMatrix(d, z, labels, 1e-9)
+	if err != nil || dv != nil {
+		t.Errorf("expected identical hierarchies, got %v (err=%v)", dv, err)
+	}
+
+	z[1][2] = 2.5
+	dv, err = CompareLinkageMatrix(d, z, labels, 1e-9)
+	if err != nil || dv == nil || dv.Step != 1 {
+		t.Errorf("expected height divergence at step 1, got %v (err=%v)", dv, err)
+	}
+
+	z = [][]float64{
+		{0, 1, 1, 2},
+		{3, 4, 2, 3},
+		{2, 5, 4, 4},
+	}
+	dv, err = CompareLinkageMatrix(d, z, labels, 1e-9)
+	if err != nil || dv == nil || dv.Step != 1 || len(dv.ReferenceItems) != 3 {
+		t.Errorf("expected structural divergence at step 1, got %v (err=%v)", dv, err)
+	}
+}
@@ -0,0 +1,145 @@
+package clustering
+
+import (
+	"math"
+
+	"github.com/pbnjay/clustering/metrics"
+)
+
+// Cophenetic returns the cophenetic distance between every pair of items,
+// i.e. the height of the merge where they first share a cluster. Items are
+// returned in leaf order, and distances as a condensed matrix in the same
+// layout as NewMatrixClusterSet. Items within the same leaf have distance 0,
+// and items that were never merged have distance +Inf.
+func (d *Dendrogram) Cophenetic() (items []ClusterItem, condensed []float64) {
+	index := make(map[ClusterItem]int)
+	for _, leaf := range d.Leaves {
+		for _, x := range leaf {
+			index[x] = len(items)
+			items = append(items, x)
+		}
+	}
+	n := len(items)
+	condensed = make([]float64, n*(n-1)/2)
+	for x := range condensed {
+		condensed[x] = math.Inf(1)
+	}
+	for _, leaf := range d.Leaves {
+		for i, a := range leaf {
+			for _, b := range leaf[i+1:] {
+				condensed[condensedIndex(n, index[a], index[b])] = 0
+			}
+		}
+	}
+
+	nl := len(d.Leaves)
+	members := make([][]int, nl+len(d.Merges))
+	for i, leaf := range d.Leaves {
+		for _, x := range leaf {
+			members[i] = append(members[i], index[x])
+		}
+	}
+	for s, m := range d.Merges {
+		for _, a := range members[m.A] {
+			for _, b := range members[m.B] {
+				condensed[condensedIndex(n, a, b)] = m.Height
+			}
+		}
+		members[nl+s] = append(append([]int(nil), members[m.A]...), members[m.B]...)
+		members[m.A], members[m.B] = nil, nil
+	}
+	return items, condensed
+}
+
+// MergeHeight returns the cophenetic distance between two items, i.e. the
+// height of the merge where they first share a cluster, in time proportional
+// to the depth of the tree. Items within the same leaf have distance 0, items
+// that were never merged have distance +Inf, and NaN is returned if either
+// item is not in the dendrogram.
+//
+// An index of the tree is built on the first call, so Leaves and Merges must
+// not be modified afterwards.
+func (d *Dendrogram) MergeHeight(a, b ClusterItem) float64 {
+	idx := d.index.Load()
+	if idx == nil {
+		idx = newTreeIndex(d)
+		d.index.Store(idx)
+	}
+	na, ok := idx.leaf[a]
+	if !ok {
+		return math.NaN()
+	}
+	nb, ok := idx.leaf[b]
+	if !ok {
+		return math.NaN()
+	}
+	for idx.depth[na] > idx.depth[nb] {
+		na = idx.parent[na]
+	}
+	for idx.depth[nb] > idx.depth[na] {
+		nb = idx.parent[nb]
+	}
+	for na != nb {
+		if idx.parent[na] == -1 {
+			return math.Inf(1)
+		}
+		na, nb = idx.parent[na], idx.parent[nb]
+	}
+	return d.nodeHeight(na)
+}
+
+// treeIndex maps items to their leaves, and nodes to their parent and depth.
+type treeIndex struct {
+	leaf   map[ClusterItem]int
+	parent []int
+	depth  []int
+}
+
+func newTreeIndex(d *Dendrogram) *treeIndex {
+	nl := len(d.Leaves)
+	idx := &treeIndex{
+		leaf:   make(map[ClusterItem]int),
+		parent: make([]int, nl+len(d.Merges)),
+		depth:  make([]int, nl+len(d.Merges)),
+	}
+	for n, leaf := range d.Leaves {
+		for _, x := range leaf {
+			idx.leaf[x] = n
+		}
+	}
+	for n := range idx.parent {
+		idx.parent[n] = -1
+	}
+	for k, m := range d.Merges {
+		idx.parent[m.A] = nl + k
+		idx.parent[m.B] = nl + k
+	}
+	// parents always have larger ids than their children
+	for n := len(idx.parent) - 1; n >= 0; n-- {
+		if p := idx.parent[n]; p != -1 {
+			idx.depth[n] = idx.depth[p] + 1
+		}
+	}
+	return idx
+}
+
+// CopheneticCorrelation returns the Pearson correlation between the
+// cophenetic distances of the dendrogram and the original distances between
+// items computed by dist. This is the standard check of how faithfully the
+// linkage method preserved the original distances, where 1 is perfect. Pairs
+// of items that were never merged are ignored.
+func (d *Dendrogram) CopheneticCorrelation(dist func(a, b ClusterItem) float64) float64 {
+	items, coph := d.Cophenetic()
+	var x, y []float64
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			c := coph[condensedIndex(len(items), i, j)]
+			if math.IsInf(c, 1) {
+				continue
+			}
+			x = append(x, c)
+			y = append(y, dist(items[i], items[j]))
+		}
+	}
+	return 1.0 - metrics.Pearson(x, y)
+}
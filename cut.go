@@ -0,0 +1,58 @@
+package clustering
+
+// CutAtHeight returns the flat clusters formed by applying every merge with a
+// height <= h, as a map from each item to its cluster number. Clusters are
+// numbered from 0 in the order their first leaf appears. Cutting a recorded
+// tree is much faster than re-running Cluster for every threshold.
+func (d *Dendrogram) CutAtHeight(h float64) map[ClusterItem]int {
+	return d.cut(func(step int, m Merge) bool {
+		return m.Height <= h
+	})
+}
+
+// CutK returns the flat clusters formed by applying merges in order until k
+// clusters remain, as a map from each item to its cluster number. If the
+// dendrogram has fewer than k leaves, or clustering stopped with more than k
+// clusters, the closest possible partition is returned.
+func (d *Dendrogram) CutK(k int) map[ClusterItem]int {
+	return d.cut(func(step int, m Merge) bool {
+		return len(d.Leaves)-step > k
+	})
+}
+
+// cut applies the merges accepted by apply using a union-find over nodes.
+func (d *Dendrogram) cut(apply func(step int, m Merge) bool) map[ClusterItem]int {
+	nl := len(d.Leaves)
+	parent := make([]int, nl+len(d.Merges))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for s, m := range d.Merges {
+		if apply(s, m) {
+			parent[find(m.A)] = nl + s
+			parent[find(m.B)] = nl + s
+		}
+	}
+
+	res := make(map[ClusterItem]int)
+	ids := make(map[int]int)
+	for i, leaf := range d.Leaves {
+		r := find(i)
+		c, ok := ids[r]
+		if !ok {
+			c = len(ids)
+			ids[r] = c
+		}
+		for _, x := range leaf {
+			res[x] = c
+		}
+	}
+	return res
+}
@@ -0,0 +1,54 @@
+package clustering
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// d3Node is a node of the nested tree structure consumed by d3.hierarchy.
+type d3Node struct {
+	Name        string                 `json:"name,omitempty"`
+	Height      float64                `json:"height"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Children    []*d3Node              `json:"children,omitempty"`
+}
+
+// MarshalD3JSON returns the dendrogram as nested {name, height, children}
+// objects, as consumed by d3.hierarchy and d3.cluster, for interactive web
+// dendrograms. Leaves are named with their items, and leaves containing
+// several items have a child for each item. If clustering stopped before
+// reaching a single cluster, the remaining roots are joined at the height of
+// the tallest one. Node annotations are included as an "annotations" object,
+// and must be encodable by encoding/json.
+func (d *Dendrogram) MarshalD3JSON() ([]byte, error) {
+	roots := d.Roots()
+	if len(roots) == 1 {
+		return json.Marshal(d.d3Node(roots[0]))
+	}
+	top := &d3Node{}
+	for _, r := range roots {
+		n := d.d3Node(r)
+		top.Height = max(top.Height, n.Height)
+		top.Children = append(top.Children, n)
+	}
+	return json.Marshal(top)
+}
+
+func (d *Dendrogram) d3Node(node int) *d3Node {
+	n := &d3Node{Annotations: d.Annotations[node]}
+	if node < len(d.Leaves) {
+		items := d.Leaves[node]
+		if len(items) == 1 {
+			n.Name = fmt.Sprint(items[0])
+			return n
+		}
+		for _, x := range items {
+			n.Children = append(n.Children, &d3Node{Name: fmt.Sprint(x)})
+		}
+		return n
+	}
+	m := d.Merges[node-len(d.Leaves)]
+	n.Height = m.Height
+	n.Children = []*d3Node{d.d3Node(m.A), d.d3Node(m.B)}
+	return n
+}
@@ -0,0 +1,215 @@
+package clustering
+
+import "sync/atomic"
+
+// Merge records a single agglomeration step. Nodes are identified using the
+// same scheme as SciPy linkage matrices: ids less than the number of leaves
+// refer to the initial clusters, and merge k creates node id numLeaves+k.
+type Merge struct {
+	// A and B are the node ids of the two clusters that were merged.
+	A, B int
+
+	// Height is the linkage score at which the clusters were merged.
+	Height float64
+
+	// Size is the total number of items in the new cluster.
+	Size int
+}
+
+// Dendrogram is the complete merge history of a clustering run.
+type Dendrogram struct {
+	// Leaves contains the items of each initial cluster, indexed by node id.
+	// Unless the ClusterSet was pre-merged, every leaf contains one item.
+	Leaves [][]ClusterItem
+
+	// Merges contains every merge performed, in order.
+	Merges []Merge
+
+	// Annotations holds arbitrary metadata attached to nodes, keyed by node
+	// id and then by name. Annotations are included in the Newick and D3
+	// exports. See Annotate.
+	Annotations map[int]map[string]interface{}
+
+	// index is built by MergeHeight.
+	index atomic.Pointer[treeIndex]
+}
+
+// ClusterWithTree clusters the input set (in-place) exactly like Cluster, and
+// returns the Dendrogram recording every merge, so that the full hierarchy is
+// available instead of only the final flat partition. Using a Checker that
+// never stops (e.g. Threshold(math.Inf(1))) records the complete tree.
+func ClusterWithTree(c ClusterSet, chk Checker, lt LinkageType) *Dendrogram {
+	h := HClustering{
+		ClusterSet:  c,
+		Checker:     chk,
+		LinkageType: lt,
+	}
+	h.Run()
+	return h.Dendrogram()
+}
+
+// Roots returns the node ids that were never merged, i.e. the clusters at the
+// end of the run, in increasing order. A complete tree has a single root.
+func (d *Dendrogram) Roots() []int {
+	merged := make([]bool, len(d.Leaves)+len(d.Merges))
+	for _, m := range d.Merges {
+		merged[m.A] = true
+		merged[m.B] = true
+	}
+	var res []int
+	for n, ok := range merged {
+		if !ok {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// Items returns every item contained in the node.
+func (d *Dendrogram) Items(node int) []ClusterItem {
+	var res []ClusterItem
+	stack := []int{node}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n < len(d.Leaves) {
+			res = append(res, d.Leaves[n]...)
+			continue
+		}
+		m := d.Merges[n-len(d.Leaves)]
+		stack = append(stack, m.B, m.A)
+	}
+	return res
+}
+
+// Annotate attaches a named value to a node, such as a label, a color or the
+// dominant category of the subtree, replacing any previous value of the same
+// name.
+func (d *Dendrogram) Annotate(node int, key string, value interface{}) {
+	if d.Annotations == nil {
+		d.Annotations = make(map[int]map[string]interface{})
+	}
+	if d.Annotations[node] == nil {
+		d.Annotations[node] = make(map[string]interface{})
+	}
+	d.Annotations[node][key] = value
+}
+
+// Annotation returns the named value attached to a node, if any.
+func (d *Dendrogram) Annotation(node int, key string) (interface{}, bool) {
+	v, ok := d.Annotations[node][key]
+	return v, ok
+}
+
+/////////////
+
+// history tracks the dendrogram node id and size of every current cluster as
+// clustering proceeds.
+type history struct {
+	nodes []int
+	sizes []int
+
+	leaves [][]ClusterItem
+	merges []Merge
+
+	// removed contains the items removed by HClustering.RemoveItem
+	removed map[ClusterItem]struct{}
+}
+
+func newHistory(c ClusterSet) *history {
+	h := &history{}
+	c.EachCluster(-1, func(cluster int) {
+		var items []ClusterItem
+		c.EachItem(cluster, func(x ClusterItem) {
+			items = append(items, x)
+		})
+		for len(h.nodes) <= cluster {
+			h.nodes = append(h.nodes, -1)
+			h.sizes = append(h.sizes, 0)
+		}
+		h.nodes[cluster] = len(h.leaves)
+		h.sizes[cluster] = len(items)
+		h.leaves = append(h.leaves, items)
+	})
+	return h
+}
+
+// height returns the height at which the cluster was formed, or 0 if it is
+// one of the initial clusters.
+func (h *history) height(cluster int) float64 {
+	n := h.nodes[cluster] - len(h.leaves)
+	if n < 0 {
+		return 0.0
+	}
+	return h.merges[n].Height
+}
+
+func (h *history) merge(kept, removed, swappedIn int, height float64) {
+	size := h.sizes[kept] + h.sizes[removed]
+	h.merges = append(h.merges, Merge{
+		A:      h.nodes[kept],
+		B:      h.nodes[removed],
+		Height: height,
+		Size:   size,
+	})
+	h.nodes[kept] = len(h.leaves) + len(h.merges) - 1
+	h.sizes[kept] = size
+
+	last := len(h.nodes) - 1
+	if swappedIn != removed {
+		h.nodes[removed] = h.nodes[swappedIn]
+		h.sizes[removed] = h.sizes[swappedIn]
+	}
+	h.nodes = h.nodes[:last]
+	h.sizes = h.sizes[:last]
+}
+
+// remove drops an emptied cluster, moving the last cluster into its position.
+func (h *history) remove(cluster int) {
+	last := len(h.nodes) - 1
+	h.nodes[cluster] = h.nodes[last]
+	h.sizes[cluster] = h.sizes[last]
+	h.nodes = h.nodes[:last]
+	h.sizes = h.sizes[:last]
+}
+
+// tree returns the recorded leaves and merges. Removed items are left out,
+// along with the leaves and merges that no longer join any items, so the
+// remaining nodes are renumbered and their sizes recomputed. ids maps the
+// recorded node ids to the returned ones, or is nil if no items were removed.
+func (h *history) tree() (leaves [][]ClusterItem, merges []Merge, ids []int) {
+	if len(h.removed) == 0 {
+		return h.leaves, h.merges, nil
+	}
+	nl := len(h.leaves)
+	ids = make([]int, nl+len(h.merges))
+	var sizes []int
+	for n, leaf := range h.leaves {
+		var items []ClusterItem
+		for _, x := range leaf {
+			if _, ok := h.removed[x]; !ok {
+				items = append(items, x)
+			}
+		}
+		ids[n] = -1
+		if len(items) > 0 {
+			ids[n] = len(leaves)
+			leaves = append(leaves, items)
+			sizes = append(sizes, len(items))
+		}
+	}
+	for k, m := range h.merges {
+		a, b := ids[m.A], ids[m.B]
+		switch {
+		case a == -1:
+			ids[nl+k] = b
+		case b == -1:
+			ids[nl+k] = a
+		default:
+			ids[nl+k] = len(leaves) + len(merges)
+			merges = append(merges, Merge{A: a, B: b, Height: m.Height, Size: sizes[a] + sizes[b]})
+			sizes = append(sizes, sizes[a]+sizes[b])
+		}
+	}
+	return leaves, merges, ids
+}
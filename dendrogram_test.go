@@ -0,0 +1,248 @@
+package clustering
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testTree returns the complete single-linkage tree of the items at
+// testLinePositions.
+func testTree() *Dendrogram {
+	return ClusterWithTree(NewMatrixClusterSet([]string{"a", "b", "c", "d"}, testLineMatrix()), Threshold(math.Inf(1)), SingleLinkage())
+}
+
+func TestClusterWithTree(t *testing.T) {
+	d := testTree()
+	if len(d.Leaves) != 4 || len(d.Merges) != 3 {
+		t.Fatalf("expected 4 leaves and 3 merges, got %+v", d)
+	}
+	want := []Merge{{0, 1, 1, 2}, {2, 4, 2, 3}, {3, 5, 4, 4}}
+	for i, m := range d.Merges {
+		a, b := m.A, m.B
+		if a > b {
+			a, b = b, a
+		}
+		if a != want[i].A || b != want[i].B || m.Height != want[i].Height || m.Size != want[i].Size {
+			t.Errorf("merge %d: expected %+v, got %+v", i, want[i], m)
+		}
+	}
+	if fmt.Sprint(d.Roots()) != "[6]" {
+		t.Errorf("expected a single root 6, got %v", d.Roots())
+	}
+
+	partial := ClusterWithTree(NewDistanceMapClusterSet(testDistanceMap(6)), MaxClusters(2), AverageLinkage())
+	if len(partial.Roots()) != 2 {
+		t.Errorf("expected 2 roots, got %v", partial.Roots())
+	}
+}
+
+func TestWriteNewick(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testTree().WriteNewick(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "(((0:1,1:1):1,2:2):2,3:4);\n" {
+		t.Errorf("unexpected newick tree %q", buf.String())
+	}
+
+	d := &Dendrogram{
+		Leaves: [][]ClusterItem{{"it's"}, {"b", "c"}, {"d"}},
+		Merges: []Merge{{A: 0, B: 1, Height: 0.5, Size: 3}},
+	}
+	buf.Reset()
+	d.WriteNewick(&buf)
+	if buf.String() != "(d:0.5,('it''s':0.5,(b:0,c:0):0.5):0);\n" {
+		t.Errorf("unexpected partial newick tree %q", buf.String())
+	}
+}
+
+func TestReadLinkageMatrix(t *testing.T) {
+	// scipy.cluster.hierarchy.linkage([[0], [1], [3], [7]], 'single')
+	z := `# saved by numpy.savetxt
+0.000000000000000000e+00 1.000000000000000000e+00 1.000000000000000000e+00 2.000000000000000000e+00
+2.000000000000000000e+00 4.000000000000000000e+00 2.000000000000000000e+00 3.000000000000000000e+00
+3.0,5.0,4.0,4.0
+`
+	d, err := ReadLinkageMatrix(strings.NewReader(z), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Leaves) != 4 || len(d.Merges) != 3 || d.Leaves[2][0] != 2 {
+		t.Fatalf("unexpected imported tree %+v", d)
+	}
+	var buf bytes.Buffer
+	d.WriteNewick(&buf)
+	if buf.String() != "(3:4,(2:2,(0:1,1:1):1):2);\n" {
+		t.Errorf("unexpected imported tree %q", buf.String())
+	}
+
+	if _, err := ReadLinkageMatrix(strings.NewReader("0 1 1 2\n0 2 2 3\n"), nil); err == nil {
+		t.Errorf("expected an error when a node is merged twice")
+	}
+	if _, err := ReadLinkageMatrix(strings.NewReader("0 1 1 3\n"), nil); err == nil {
+		t.Errorf("expected an error for an inconsistent size")
+	}
+}
+
+func TestCut(t *testing.T) {
+	d := testTree()
+	cases := []struct {
+		cut  map[ClusterItem]int
+		want string
+	}{
+		{d.CutAtHeight(0.5), "map[0:0 1:1 2:2 3:3]"},
+		{d.CutAtHeight(1), "map[0:0 1:0 2:1 3:2]"},
+		{d.CutAtHeight(3), "map[0:0 1:0 2:0 3:1]"},
+		{d.CutAtHeight(100), "map[0:0 1:0 2:0 3:0]"},
+		{d.CutK(2), "map[0:0 1:0 2:0 3:1]"},
+		{d.CutK(3), "map[0:0 1:0 2:1 3:2]"},
+		{d.CutK(10), "map[0:0 1:1 2:2 3:3]"},
+	}
+	for i, c := range cases {
+		if got := fmt.Sprint(c.cut); got != c.want {
+			t.Errorf("cut %d: expected %s, got %s", i, c.want, got)
+		}
+	}
+}
+
+func TestCophenetic(t *testing.T) {
+	items, coph := testTree().Cophenetic()
+	if fmt.Sprint(items) != "[0 1 2 3]" || fmt.Sprint(coph) != "[1 2 4 2 4 4]" {
+		t.Errorf("unexpected cophenetic matrix %v for %v", coph, items)
+	}
+
+	r := testTree().CopheneticCorrelation(func(a, b ClusterItem) float64 {
+		return math.Abs(testLinePositions[a.(int)] - testLinePositions[b.(int)])
+	})
+	if math.Abs(r-0.898519) > 1e-6 {
+		t.Errorf("expected cophenetic correlation 0.898519, got %g", r)
+	}
+
+	partial := ClusterWithTree(NewDistanceMapClusterSet(testDistanceMap(6)), MaxClusters(2), AverageLinkage())
+	_, coph = partial.Cophenetic()
+	inf := 0
+	for _, c := range coph {
+		if math.IsInf(c, 1) {
+			inf++
+		}
+	}
+	if inf == 0 || inf == len(coph) {
+		t.Errorf("expected some unmerged pairs, got %v", coph)
+	}
+}
+
+func TestMarshalD3JSON(t *testing.T) {
+	data, err := testTree().MarshalD3JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"height":4,"children":[{"height":2,"children":[{"height":1,"children":[` +
+		`{"name":"0","height":0},{"name":"1","height":0}]},{"name":"2","height":0}]},{"name":"3","height":0}]}`
+	if string(data) != want {
+		t.Errorf("unexpected d3 json %s", data)
+	}
+
+	d := &Dendrogram{Leaves: [][]ClusterItem{{"a"}, {"b", "c"}}}
+	data, _ = d.MarshalD3JSON()
+	if string(data) != `{"height":0,"children":[{"name":"a","height":0},{"height":0,"children":[{"name":"b","height":0},{"name":"c","height":0}]}]}` {
+		t.Errorf("unexpected d3 json for a partial tree %s", data)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	d := testTree()
+	d.Annotate(5, "category", "low")
+	d.Annotate(5, "score", 0.5)
+	d.Annotate(3, "label", "far away")
+	if v, ok := d.Annotation(5, "score"); !ok || v != 0.5 {
+		t.Errorf("expected annotation score=0.5, got %v", v)
+	}
+	if _, ok := d.Annotation(4, "score"); ok {
+		t.Error("unexpected annotation on node 4")
+	}
+
+	var buf bytes.Buffer
+	d.WriteNewick(&buf)
+	want := "(((0:1,1:1):1,2:2)[&category=low,score=0.5]:2,3[&label=\"far away\"]:4);\n"
+	if buf.String() != want {
+		t.Errorf("expected newick %q, got %q", want, buf.String())
+	}
+
+	data, _ := d.MarshalD3JSON()
+	if !strings.Contains(string(data), `{"height":2,"annotations":{"category":"low","score":0.5},"children"`) ||
+		!strings.Contains(string(data), `{"name":"3","height":0,"annotations":{"label":"far away"}}`) {
+		t.Errorf("annotations missing from d3 json %s", data)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	d := testTree()
+	d.Annotate(5, "category", "low")
+
+	p := d.Prune(3)
+	if fmt.Sprint(p.Leaves) != "[[2] [3] [0 1]]" {
+		t.Errorf("unexpected pruned leaves %v", p.Leaves)
+	}
+	var buf bytes.Buffer
+	p.WriteNewick(&buf)
+	want := "(((0:0,1:0)[&noise=true]:2,2[&noise=true]:2)[&category=low]:2,3[&noise=true]:4);\n"
+	if buf.String() != want {
+		t.Errorf("expected newick %q, got %q", want, buf.String())
+	}
+
+	// nothing is large enough, so the whole tree becomes a single leaf
+	p = d.Prune(5)
+	if len(p.Leaves) != 1 || len(p.Leaves[0]) != 4 || len(p.Merges) != 0 {
+		t.Errorf("expected a single collapsed leaf, got %+v", p)
+	}
+	if v, _ := p.Annotation(0, "noise"); v != true {
+		t.Error("expected collapsed leaf to be marked as noise")
+	}
+
+	if p = d.Prune(1); fmt.Sprint(p.Merges) != fmt.Sprint(d.Merges) || len(p.Annotations) != 1 {
+		t.Errorf("pruning with minSize 1 should not change the tree, got %+v", p)
+	}
+}
+
+func TestMergeHeight(t *testing.T) {
+	d := testTree()
+	items, coph := d.Cophenetic()
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if h := d.MergeHeight(items[i], items[j]); h != coph[condensedIndex(len(items), i, j)] {
+				t.Errorf("unexpected merge height %g for %v and %v", h, items[i], items[j])
+			}
+		}
+	}
+	if h := d.MergeHeight(2, 2); h != 0 {
+		t.Errorf("expected merge height 0 for the same item, got %g", h)
+	}
+	if h := d.MergeHeight(2, 9); !math.IsNaN(h) {
+		t.Errorf("expected NaN for an unknown item, got %g", h)
+	}
+
+	d = &Dendrogram{Leaves: [][]ClusterItem{{"a", "b"}, {"c"}}}
+	if h := d.MergeHeight("a", "b"); h != 0 {
+		t.Errorf("expected merge height 0 within a leaf, got %g", h)
+	}
+	if h := d.MergeHeight("a", "c"); !math.IsInf(h, 1) {
+		t.Errorf("expected +Inf for items never merged, got %g", h)
+	}
+}
+
+func TestHeightSeries(t *testing.T) {
+	d := testTree()
+	if s := fmt.Sprint(d.HeightSeries()); s != "[{1 3} {2 2} {4 1}]" {
+		t.Errorf("unexpected height series %s", s)
+	}
+	var buf bytes.Buffer
+	if err := WriteHeightSeriesCSV(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+	if want := "step,height,clusters\n0,1,3\n1,2,2\n2,4,1\n"; buf.String() != want {
+		t.Errorf("expected csv %q, got %q", want, buf.String())
+	}
+}
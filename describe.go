@@ -0,0 +1,54 @@
+package clustering
+
+import "fmt"
+
+// Description is a structured description of a LinkageType or Checker, so
+// that saved results can record exactly how they were produced.
+type Description struct {
+	// Name identifies the method, e.g. "complete" or "threshold".
+	Name string `json:"name"`
+
+	// Params contains the parameters the method was configured with.
+	Params map[string]interface{} `json:"params,omitempty"`
+
+	// Inner describes any wrapped methods (e.g. for TreeLog).
+	Inner []Description `json:"inner,omitempty"`
+}
+
+// Describer is an optional interface implemented by LinkageTypes and Checkers
+// that can describe their own configuration. All the methods provided by this
+// package implement it.
+type Describer interface {
+	// Describe returns a structured description of the configuration.
+	Describe() Description
+}
+
+// Describe returns the description of x if it implements Describer, otherwise
+// a Description containing only the type name of x.
+func Describe(x interface{}) Description {
+	if d, ok := x.(Describer); ok {
+		return d.Describe()
+	}
+	return Description{Name: fmt.Sprintf("%T", x)}
+}
+
+// RunInfo records how a clustering run was configured.
+type RunInfo struct {
+	Linkage Description `json:"linkage"`
+	Checker Description `json:"checker"`
+}
+
+// RunInfoWriter is an optional interface for ResultWriters that can record
+// the RunInfo alongside the results. If implemented, WriteRunInfo is called
+// before any items are written.
+type RunInfoWriter interface {
+	WriteRunInfo(info RunInfo) error
+}
+
+// RunInfo describes the configuration of this clustering run.
+func (h *HClustering) RunInfo() RunInfo {
+	return RunInfo{
+		Linkage: Describe(h.LinkageType),
+		Checker: Describe(h.Checker),
+	}
+}
@@ -0,0 +1,134 @@
+package clustering
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// ClusterDiff describes a cluster that appears in only one of two dendrograms,
+// or appears in both but at different heights.
+type ClusterDiff struct {
+	// InFirst and InSecond report which dendrograms contain the cluster.
+	InFirst, InSecond bool
+
+	// Items are the members of the cluster.
+	Items []ClusterItem
+
+	// Height and OtherHeight are the heights at which the cluster formed in
+	// the first and second dendrograms (NaN if it does not exist).
+	Height, OtherHeight float64
+}
+
+func (c ClusterDiff) String() string {
+	switch {
+	case !c.InSecond:
+		return fmt.Sprintf("- %v @ %g", c.Items, c.Height)
+	case !c.InFirst:
+		return fmt.Sprintf("+ %v @ %g", c.Items, c.OtherHeight)
+	default:
+		return fmt.Sprintf("~ %v @ %g -> %g", c.Items, c.Height, c.OtherHeight)
+	}
+}
+
+// DiffDendrograms compares two dendrograms over the same items, and reports
+// every cluster present in one but not the other, as well as clusters present
+// in both whose heights differ by more than tol. Results are sorted by height.
+// This is useful for validating refactors of distance functions.
+func DiffDendrograms(a, b *Dendrogram, tol float64) []ClusterDiff {
+	ids := make(map[ClusterItem]int)
+	var items []ClusterItem
+	type node struct {
+		members []int
+		height  float64
+	}
+	nodes := func(d *Dendrogram) map[string]node {
+		members := make([][]int, len(d.Leaves)+len(d.Merges))
+		for i, leaf := range d.Leaves {
+			for _, x := range leaf {
+				id, ok := ids[x]
+				if !ok {
+					id = len(items)
+					ids[x] = id
+					items = append(items, x)
+				}
+				members[i] = append(members[i], id)
+			}
+			sort.Ints(members[i])
+		}
+		res := make(map[string]node, len(d.Merges))
+		for k, m := range d.Merges {
+			n := len(d.Leaves) + k
+			members[n] = mergeSorted(members[m.A], members[m.B])
+			res[obsKey(members[n])] = node{members[n], m.Height}
+		}
+		return res
+	}
+	first, second := nodes(a), nodes(b)
+
+	toItems := func(n node) []ClusterItem {
+		res := make([]ClusterItem, len(n.members))
+		for i, id := range n.members {
+			res[i] = items[id]
+		}
+		return res
+	}
+
+	var diffs []ClusterDiff
+	var keys []string
+	for key, n := range first {
+		n2, ok := second[key]
+		if !ok {
+			diffs = append(diffs, ClusterDiff{InFirst: true, Items: toItems(n),
+				Height: n.height, OtherHeight: math.NaN()})
+			keys = append(keys, key)
+		} else if math.Abs(n.height-n2.height) > tol {
+			diffs = append(diffs, ClusterDiff{InFirst: true, InSecond: true,
+				Items: toItems(n), Height: n.height, OtherHeight: n2.height})
+			keys = append(keys, key)
+		}
+	}
+	for key, n := range second {
+		if _, ok := first[key]; !ok {
+			diffs = append(diffs, ClusterDiff{InSecond: true, Items: toItems(n),
+				Height: math.NaN(), OtherHeight: n.height})
+			keys = append(keys, key)
+		}
+	}
+
+	height := func(c ClusterDiff) float64 {
+		if c.InFirst {
+			return c.Height
+		}
+		return c.OtherHeight
+	}
+	order := make([]int, len(diffs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if ha, hb := height(diffs[a]), height(diffs[b]); ha != hb {
+			return ha < hb
+		}
+		return keys[a] < keys[b]
+	})
+	res := make([]ClusterDiff, len(diffs))
+	for i, k := range order {
+		res[i] = diffs[k]
+	}
+	return res
+}
+
+// WriteDiff formats the differences for human review, one cluster per line.
+// Lines starting with "-" are only in the first dendrogram, "+" only in the
+// second, and "~" are in both at different heights.
+func WriteDiff(w io.Writer, diffs []ClusterDiff) error {
+	for _, d := range diffs {
+		if _, err := fmt.Fprintln(w, d.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
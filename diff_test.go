@@ -0,0 +1,35 @@
+package clustering
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffDendrograms(t *testing.T) {
+	// a chain of merges adding one item at a time
+	tree := &Dendrogram{
+		Leaves: [][]ClusterItem{{0}, {1}, {2}, {3}},
+		Merges: []Merge{{0, 1, 1, 2}, {2, 4, 2, 3}, {3, 5, 4, 4}},
+	}
+	// item 2 moves out of the second merge, and item 3 moves in
+	other := &Dendrogram{
+		Leaves: [][]ClusterItem{{0}, {1}, {2}, {3}},
+		Merges: []Merge{{0, 1, 1.5, 2}, {4, 3, 2, 3}, {5, 2, 4, 4}},
+	}
+	diffs := DiffDendrograms(tree, other, 0.1)
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, diffs); err != nil {
+		t.Fatal(err)
+	}
+	want := "~ [0 1] @ 1 -> 1.5\n- [0 1 2] @ 2\n+ [0 1 3] @ 2\n"
+	if buf.String() != want {
+		t.Errorf("expected diff %q, got %q", want, buf.String())
+	}
+
+	if diffs := DiffDendrograms(tree, other, 1); len(diffs) != 2 {
+		t.Errorf("expected height changes within tolerance to be ignored, got %v", diffs)
+	}
+	if diffs := DiffDendrograms(tree, tree, 0); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+}
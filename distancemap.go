@@ -1,16 +1,44 @@
 package clustering
 
+import "math"
+
 // DistanceMap is a map of maps from cluster items (pairs) to the distance
 // measures between them. A distance map does not have to be symmetric, but it
 // is highly recommended to have all pairs defined.
 type DistanceMap map[ClusterItem]map[ClusterItem]float64
 
+// DistanceMap32 is a DistanceMap using single precision distances, which
+// halves the memory used by the distances of large maps.
+type DistanceMap32 map[ClusterItem]map[ClusterItem]float32
+
 type distMapClusterSet struct {
-	data map[ClusterItem]map[ClusterItem]float64
+	clusterList
 
-	clusters [][]ClusterItem
+	data   map[ClusterItem]map[ClusterItem]float64
+	data32 map[ClusterItem]map[ClusterItem]float32
+	policy AsymmetryPolicy
 }
 
+// AsymmetryPolicy selects the distance used for a pair of items when a
+// DistanceMap contains both directions (a,b) and (b,a) with different values,
+// e.g. for directed similarity data.
+type AsymmetryPolicy int
+
+const (
+	// AsymmetryFirst uses the (a,b) distance where a is the first item in the
+	// lookup, which depends on the order that clusters are compared.
+	AsymmetryFirst AsymmetryPolicy = iota
+
+	// AsymmetryMin uses the smaller of the two distances.
+	AsymmetryMin
+
+	// AsymmetryMax uses the larger of the two distances.
+	AsymmetryMax
+
+	// AsymmetryMean uses the mean of the two distances.
+	AsymmetryMean
+)
+
 // NewDistanceMapClusterSet initializes a new ClusterSet from a distance map by
 // creating a singleton cluster for every unique item in the maps.
 func NewDistanceMapClusterSet(data DistanceMap) ClusterSet {
@@ -20,67 +48,124 @@ func NewDistanceMapClusterSet(data DistanceMap) ClusterSet {
 
 	allItems := make(map[ClusterItem]struct{})
 	for k1, subs := range data {
-		if _, done := allItems[k1]; !done {
-			allItems[k1] = struct{}{}
-			d.clusters = append(d.clusters, []ClusterItem{k1})
-		}
+		d.addItem(allItems, k1)
 		for k2 := range subs {
-			if _, done := allItems[k2]; !done {
-				allItems[k2] = struct{}{}
-				d.clusters = append(d.clusters, []ClusterItem{k2})
-			}
+			d.addItem(allItems, k2)
 		}
 	}
 
 	return d
 }
 
-func (d *distMapClusterSet) EachCluster(start int, cb func(cluster int)) {
-	if start+1 >= len(d.clusters) {
-		return
+// NewAsymmetricDistanceMapClusterSet is like NewDistanceMapClusterSet, but
+// uses policy to resolve pairs of items with a distance in both directions.
+func NewAsymmetricDistanceMapClusterSet(data DistanceMap, policy AsymmetryPolicy) ClusterSet {
+	d := NewDistanceMapClusterSet(data).(*distMapClusterSet)
+	d.policy = policy
+	return d
+}
+
+// NewDistanceMap32ClusterSet initializes a new ClusterSet from a single
+// precision distance map by creating a singleton cluster for every unique
+// item in the maps.
+func NewDistanceMap32ClusterSet(data DistanceMap32) ClusterSet {
+	d := &distMapClusterSet{
+		data32: data,
 	}
 
-	for i := start + 1; i < len(d.clusters); i++ {
-		cb(i)
+	allItems := make(map[ClusterItem]struct{})
+	for k1, subs := range data {
+		d.addItem(allItems, k1)
+		for k2 := range subs {
+			d.addItem(allItems, k2)
+		}
 	}
+
+	return d
 }
 
-func (d *distMapClusterSet) EachItem(cluster int, cb func(ClusterItem)) {
-	for _, x := range d.clusters[cluster] {
-		cb(x)
+// addItem creates a singleton cluster for x if it has not been seen before.
+func (d *distMapClusterSet) addItem(seen map[ClusterItem]struct{}, x ClusterItem) {
+	if _, done := seen[x]; !done {
+		seen[x] = struct{}{}
+		d.clusters = append(d.clusters, []ClusterItem{x})
 	}
 }
 
 func (d *distMapClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
-	if x, ok := d.data[item1]; ok {
-		if y, ok := x[item2]; ok {
-			return y
+	ab, okab := d.lookup(item1, item2)
+	if d.policy == AsymmetryFirst && okab {
+		return ab
+	}
+	ba, okba := d.lookup(item2, item1)
+	switch {
+	case !okab && !okba:
+		return 1.0
+	case !okba:
+		return ab
+	case !okab:
+		return ba
+	}
+	switch d.policy {
+	case AsymmetryMin:
+		return math.Min(ab, ba)
+	case AsymmetryMax:
+		return math.Max(ab, ba)
+	}
+	return (ab + ba) / 2.0
+}
+
+// ItemDistance implements ItemDistanceSet, as the distance does not depend on
+// the clusters of the items.
+func (d *distMapClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return d.Distance(-1, -1, item1, item2), true
+}
+
+// lookup returns the distance stored for item1 to item2, if any.
+func (d *distMapClusterSet) lookup(item1, item2 ClusterItem) (float64, bool) {
+	if d.data32 != nil {
+		if x, ok := d.data32[item1]; ok {
+			if y, ok := x[item2]; ok {
+				return float64(y), true
+			}
 		}
+		return 0.0, false
 	}
-	if x, ok := d.data[item2]; ok {
-		if y, ok := x[item1]; ok {
-			return y
+	if x, ok := d.data[item1]; ok {
+		if y, ok := x[item2]; ok {
+			return y, true
 		}
 	}
-	return 1.0
+	return 0.0, false
 }
 
-func (d *distMapClusterSet) Count() int {
-	return len(d.clusters)
+// RemoveItem removes the item from its cluster. Its distances remain in the
+// distance map, but are no longer used.
+func (d *distMapClusterSet) RemoveItem(item ClusterItem) (cluster int, empty bool) {
+	return d.removeItem(item)
 }
 
-func (d *distMapClusterSet) Merge(i, j int) (keep, swappedIn int) {
-	if j < i {
-		j, i = i, j
+/////////////
+
+type weightedDistMapClusterSet struct {
+	*distMapClusterSet
+
+	weights map[ClusterItem]float64
+}
+
+// NewWeightedDistanceMapClusterSet is like NewDistanceMapClusterSet, but every
+// item carries a weight (or multiplicity) from weights, which defaults to 1.
+// The returned ClusterSet implements WeightedClusterSet.
+func NewWeightedDistanceMapClusterSet(data DistanceMap, weights map[ClusterItem]float64) ClusterSet {
+	return &weightedDistMapClusterSet{
+		distMapClusterSet: NewDistanceMapClusterSet(data).(*distMapClusterSet),
+		weights:           weights,
 	}
+}
 
-	// move the to-be-merged cluster to the end of the array
-	x := len(d.clusters) - 1
-	if j < x {
-		d.clusters[x], d.clusters[j] = d.clusters[j], d.clusters[x]
-		j = x
+func (d *weightedDistMapClusterSet) Weight(item ClusterItem) float64 {
+	if w, ok := d.weights[item]; ok {
+		return w
 	}
-	d.clusters[i] = append(d.clusters[i], d.clusters[j]...)
-	d.clusters = d.clusters[:j]
-	return i, x
+	return 1.0
 }
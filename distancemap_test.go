@@ -158,3 +158,76 @@ func TestDistanceMapClustering3(t *testing.T) {
 		t.Errorf("after clustering, 5-node DistanceMapClusterSet should be 2,3")
 	}
 }
+
+func TestDistanceFuncClusterSet(t *testing.T) {
+	d := NewDistanceFuncClusterSet([]ClusterItem{0.0, 0.1, 0.3, 5.0, 5.2}, func(a, b ClusterItem) float64 {
+		x := a.(float64) - b.(float64)
+		if x < 0 {
+			return -x
+		}
+		return x
+	})
+	if d.Count() != 5 {
+		t.Errorf("expected 5 starting clusters, got %d", d.Count())
+	}
+	Cluster(d, Threshold(1.0), CompleteLinkage())
+	if d.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", d.Count())
+	}
+}
+
+func TestWeightedDistanceMap(t *testing.T) {
+	// items on a line at positions 0, 1 and 3, with b weighted 3x
+	data := DistanceMap{
+		"a": {"b": 1, "c": 3},
+		"b": {"c": 2},
+	}
+	h := &HClustering{
+		ClusterSet:     NewWeightedDistanceMapClusterSet(data, map[ClusterItem]float64{"b": 3}),
+		Checker:        MaxClusters(2),
+		LinkageType:    AverageLinkage(),
+		CacheDistances: true,
+	}
+	h.Run()
+
+	// {a,b} vs {c} is (3*2 + 3) / 4 with weights, or (2 + 3) / 2 without
+	m := h.DistanceMatrix()
+	if m[0][1] != 2.25 {
+		t.Errorf("expected weighted average linkage of 2.25, got %g", m[0][1])
+	}
+}
+
+func TestClusterCopy(t *testing.T) {
+	src := NewDistanceMapClusterSet(testDistanceMap(12)).(CloneableClusterSet)
+
+	loose := ClusterCopy(src, MaxClusters(2), AverageLinkage())
+	tight := ClusterCopy(src, MaxClusters(6), AverageLinkage())
+	if src.Count() != 12 {
+		t.Errorf("source set should be unchanged, got %d clusters", src.Count())
+	}
+	if loose.Count() != 2 || tight.Count() != 6 {
+		t.Errorf("expected copies with 2 and 6 clusters, got %d and %d", loose.Count(), tight.Count())
+	}
+}
+
+func TestAsymmetryPolicy(t *testing.T) {
+	data := DistanceMap{
+		"a": {"b": 1, "c": 3},
+		"b": {"a": 4},
+	}
+	expect := map[AsymmetryPolicy]float64{
+		AsymmetryFirst: 4,
+		AsymmetryMin:   1,
+		AsymmetryMax:   4,
+		AsymmetryMean:  2.5,
+	}
+	for policy, want := range expect {
+		d := NewAsymmetricDistanceMapClusterSet(data, policy)
+		if got := d.Distance(0, 1, "b", "a"); got != want {
+			t.Errorf("policy %d: expected %g, got %g", policy, want, got)
+		}
+		if got := d.Distance(0, 1, "c", "a"); got != 3 {
+			t.Errorf("policy %d: one-sided distance should be used, got %g", policy, got)
+		}
+	}
+}
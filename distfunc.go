@@ -0,0 +1,32 @@
+package clustering
+
+type distFuncClusterSet struct {
+	clusterList
+
+	dist func(a, b ClusterItem) float64
+}
+
+// NewDistanceFuncClusterSet initializes a new ClusterSet with a singleton
+// cluster for every item, where dist computes the distance between two items.
+// Distances are computed on demand, so dist should be cheap or memoized (see
+// HClustering.Memo and HClustering.CacheDistances).
+func NewDistanceFuncClusterSet(items []ClusterItem, dist func(a, b ClusterItem) float64) ClusterSet {
+	d := &distFuncClusterSet{
+		dist: dist,
+	}
+	d.clusters = make([][]ClusterItem, len(items))
+	for i, x := range items {
+		d.clusters[i] = []ClusterItem{x}
+	}
+	return d
+}
+
+func (d *distFuncClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	return d.dist(item1, item2)
+}
+
+// ItemDistance implements ItemDistanceSet, as the distance does not depend on
+// the clusters of the items.
+func (d *distFuncClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return d.Distance(-1, -1, item1, item2), true
+}
@@ -29,8 +29,9 @@
 //    1 b
 //    1 c
 //
-// Other useful linkage types that should be implemented one day:
-//   Centroid  -- select clusters where the "centers" are close together.
-//   Ward      -- select clusters that reduce the variance of distances.
+// ClusterSets of feature vectors that implement VectorClusterSet (such as
+// NewPointsClusterSet) also support CentroidLinkage, which selects clusters
+// whose "centers" are close together, and WardLinkage, which selects clusters
+// that least increase the within-cluster variance.
 //
 package clustering
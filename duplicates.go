@@ -0,0 +1,219 @@
+package clustering
+
+// FindDuplicates returns every group of two or more items that are exact
+// duplicates of each other (i.e. their pairwise distance is zero). Items that
+// are already in the same cluster are not compared. This requires computing
+// the distance between every pair of items.
+func FindDuplicates(c ClusterSet) [][]ClusterItem {
+	return findWithin(c, 0.0)
+}
+
+// PremergeWithin merges every pair of items within eps distance of each other
+// into the same starting cluster before clustering, using a union-find so that
+// chains of close items end up together. Using an eps of 0 merges only exact
+// duplicates. This is both a large speedup when many items are trivially
+// linked, and avoids degenerate ties between them. Returns the groups of items
+// that were merged together.
+//
+// Unlike CollapseDuplicates, every item remains visible to the linkage method.
+// Note that the resulting dendrogram starts from the merged clusters.
+func PremergeWithin(c ClusterSet, eps float64) [][]ClusterItem {
+	groups := findWithin(c, eps)
+	mergeItemGroups(c, groups)
+	return groups
+}
+
+// DuplicateSet is a ClusterSet wrapper that collapses groups of exact
+// duplicate items into a single representative item. Clustering a DuplicateSet
+// only computes distances between representatives, which both speeds up runs
+// and avoids the degenerate ties caused by many zero distances. Call Expand
+// after clustering to enumerate every original item again.
+type DuplicateSet struct {
+	ClusterSet
+
+	groups   [][]ClusterItem
+	weights  map[ClusterItem]int
+	hidden   map[ClusterItem]struct{}
+	expanded bool
+}
+
+// CollapseDuplicates detects groups of exact duplicate items in c, merges each
+// group into a single cluster, and returns a wrapper that only exposes one
+// representative item from each group until Expand is called.
+func CollapseDuplicates(c ClusterSet) *DuplicateSet {
+	return CollapseWithin(c, 0.0)
+}
+
+// CollapseWithin is like CollapseDuplicates, but collapses every group of
+// items within eps distance of each other (using a union-find, so that chains
+// of close items are collapsed together) into its first item. The distances of
+// the representative item stand in for the whole group, so eps should be small
+// relative to the distances between clusters. For datasets with many near
+// duplicates this drastically shrinks the number of items to cluster.
+func CollapseWithin(c ClusterSet, eps float64) *DuplicateSet {
+	d := &DuplicateSet{
+		ClusterSet: c,
+		groups:     findWithin(c, eps),
+		weights:    make(map[ClusterItem]int),
+		hidden:     make(map[ClusterItem]struct{}),
+	}
+	for _, g := range d.groups {
+		d.weights[g[0]] = len(g)
+		for _, x := range g[1:] {
+			d.hidden[x] = struct{}{}
+		}
+	}
+	mergeItemGroups(c, d.groups)
+	return d
+}
+
+// EachItem enumerates the representative items of the cluster, or every item
+// if Expand has been called.
+func (d *DuplicateSet) EachItem(cluster int, cb func(ClusterItem)) {
+	if d.expanded {
+		d.ClusterSet.EachItem(cluster, cb)
+		return
+	}
+	d.ClusterSet.EachItem(cluster, func(x ClusterItem) {
+		if _, ok := d.hidden[x]; !ok {
+			cb(x)
+		}
+	})
+}
+
+// Duplicates returns the groups of duplicate items that were collapsed. The
+// first item in each group is its representative.
+func (d *DuplicateSet) Duplicates() [][]ClusterItem {
+	return d.groups
+}
+
+// Weight returns the number of original items represented by item. This
+// implements WeightedClusterSet, so that weighted linkages such as average
+// linkage produce the same scores as clustering every duplicate.
+func (d *DuplicateSet) Weight(item ClusterItem) float64 {
+	if w, ok := d.weights[item]; ok {
+		return float64(w)
+	}
+	return 1.0
+}
+
+// ItemDistance implements ItemDistanceSet when the underlying ClusterSet does.
+func (d *DuplicateSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return itemDistanceOf(d.ClusterSet, item1, item2)
+}
+
+// Coordinates implements VectorClusterSet when the underlying ClusterSet does.
+func (d *DuplicateSet) Coordinates(item ClusterItem) []float64 {
+	return coordinatesOf(d.ClusterSet, item)
+}
+
+// Centroid implements VectorClusterSet when the underlying ClusterSet does.
+// The duplicates of every representative are included, so this is the same
+// as the mean of the representatives weighted by Weight.
+func (d *DuplicateSet) Centroid(cluster int) []float64 {
+	return centroidOf(d.ClusterSet, cluster)
+}
+
+// Expand makes EachItem enumerate every original item, including duplicates.
+// It should be called once clustering is complete.
+func (d *DuplicateSet) Expand() {
+	d.expanded = true
+}
+
+/////////////
+
+// findWithin returns the connected components of items that are within eps
+// distance of each other, using a union-find over every pair of items.
+func findWithin(c ClusterSet, eps float64) [][]ClusterItem {
+	var items []ClusterItem
+	var clusters []int
+	c.EachCluster(-1, func(cluster int) {
+		c.EachItem(cluster, func(x ClusterItem) {
+			items = append(items, x)
+			clusters = append(clusters, cluster)
+		})
+	})
+
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if clusters[i] == clusters[j] {
+				continue
+			}
+			if c.Distance(clusters[i], clusters[j], items[i], items[j]) <= eps {
+				ri, rj := find(i), find(j)
+				if ri != rj {
+					parent[rj] = ri
+				}
+			}
+		}
+	}
+
+	var groups [][]ClusterItem
+	groupIndex := make(map[int]int)
+	for i := range items {
+		r := find(i)
+		if r == i {
+			continue
+		}
+		g, ok := groupIndex[r]
+		if !ok {
+			g = len(groups)
+			groupIndex[r] = g
+			groups = append(groups, []ClusterItem{items[r]})
+		}
+		groups[g] = append(groups[g], items[i])
+	}
+	return groups
+}
+
+// mergeItemGroups merges the clusters containing the items of each group, so
+// that every group ends up within a single cluster.
+func mergeItemGroups(c ClusterSet, groups [][]ClusterItem) {
+	where := make(map[ClusterItem]int)
+	c.EachCluster(-1, func(cluster int) {
+		c.EachItem(cluster, func(x ClusterItem) {
+			where[x] = cluster
+		})
+	})
+	relabel := func(cluster int) {
+		c.EachItem(cluster, func(x ClusterItem) {
+			where[x] = cluster
+		})
+	}
+
+	for _, g := range groups {
+		target, ok := where[g[0]]
+		if !ok {
+			continue
+		}
+		for _, x := range g[1:] {
+			cx, ok := where[x]
+			if !ok || cx == target {
+				continue
+			}
+			kept, _ := c.Merge(target, cx)
+			removed := cx
+			if kept == cx {
+				removed = target
+			}
+			relabel(kept)
+			if removed < c.Count() {
+				// another cluster was swapped into the removed position
+				relabel(removed)
+			}
+			target = kept
+		}
+	}
+}
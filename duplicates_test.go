@@ -0,0 +1,77 @@
+package clustering
+
+import "testing"
+
+func TestCollapseDuplicates(t *testing.T) {
+	d := CollapseDuplicates(NewDistanceMapClusterSet(DistanceMap{
+		"a": {"b": 0.0, "c": 0.0, "d": 1.0, "e": 0.4},
+		"b": {"c": 0.0, "d": 0.9, "e": 0.4},
+		"c": {"d": 0.9, "e": 0.2},
+		"d": {"e": 0.1},
+	}))
+
+	if len(d.Duplicates()) != 1 || len(d.Duplicates()[0]) != 3 {
+		t.Errorf("expected one group of 3 duplicates, got %v", d.Duplicates())
+	}
+	if d.Count() != 3 {
+		t.Errorf("collapsed set should start with 3 clusters, got %d", d.Count())
+	}
+	if d.Weight(d.Duplicates()[0][0]) != 3 {
+		t.Errorf("representative should have a weight of 3")
+	}
+
+	n := 0
+	d.EachCluster(-1, func(cluster int) {
+		d.EachItem(cluster, func(x ClusterItem) { n++ })
+	})
+	if n != 3 {
+		t.Errorf("collapsed set should enumerate 3 items, got %d", n)
+	}
+
+	Cluster(d, Threshold(0.4), CompleteLinkage())
+	d.Expand()
+
+	n = 0
+	d.EachCluster(-1, func(cluster int) {
+		d.EachItem(cluster, func(x ClusterItem) { n++ })
+	})
+	if d.Count() != 2 || n != 5 {
+		t.Errorf("expanded set should have 2 clusters with 5 items, got %d with %d", d.Count(), n)
+	}
+}
+
+func TestCollapseWithin(t *testing.T) {
+	// items on a line at positions 0, 0.01, 0.02, 5 and 5.01
+	d := CollapseWithin(NewDistanceMapClusterSet(DistanceMap{
+		"a": {"b": 0.01, "c": 0.02, "d": 5, "e": 5.01},
+		"b": {"c": 0.01, "d": 4.99, "e": 5},
+		"c": {"d": 4.98, "e": 4.99},
+		"d": {"e": 0.01},
+	}), 0.01)
+	if d.Count() != 2 || len(d.Duplicates()) != 2 {
+		t.Fatalf("expected 2 collapsed groups, got %v", d.Duplicates())
+	}
+
+	Cluster(d, Threshold(1), AverageLinkage())
+	d.Expand()
+	if d.Count() != 2 || clusterSizes(d)[3] != 1 || clusterSizes(d)[2] != 1 {
+		t.Errorf("expected clusters of 3 and 2 items, got %v", clusterSizes(d))
+	}
+}
+
+func TestPremergeWithin(t *testing.T) {
+	// items on a line at positions 0, 0.01, 0.02, 1 and 2
+	d := NewDistanceMapClusterSet(DistanceMap{
+		"a": {"b": 0.01, "c": 0.02, "d": 1, "e": 2},
+		"b": {"c": 0.01, "d": 0.99, "e": 1.99},
+		"c": {"d": 0.98, "e": 1.98},
+		"d": {"e": 1},
+	})
+	groups := PremergeWithin(d, 0.015)
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Errorf("expected one group of 3 items, got %v", groups)
+	}
+	if d.Count() != 3 {
+		t.Errorf("expected 3 starting clusters, got %d", d.Count())
+	}
+}
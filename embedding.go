@@ -0,0 +1,137 @@
+package clustering
+
+import (
+	"math/rand"
+
+	"github.com/pbnjay/clustering/metrics"
+)
+
+// EmbeddingOptions configures ClusterEmbeddings.
+type EmbeddingOptions struct {
+	// K is the number of nearest neighbors to link for each vector.
+	K int
+
+	// Recall is the target recall (0-1) of the approximate k-NN graph,
+	// estimated against exact neighbors for a random sample of vectors. Values
+	// of 1 (or 0, the default) compute the exact k-NN graph by brute force.
+	Recall float64
+
+	// Mutual keeps only the edges where both vectors are among each other's
+	// K nearest neighbors, which removes most of the spurious links to hubs
+	// that are common in high-dimensional embedding spaces.
+	Mutual bool
+
+	// Seed initializes the random number generator used by the approximate
+	// k-NN search, so that results are reproducible.
+	Seed int64
+}
+
+// ClusterEmbeddings clusters embedding vectors (e.g. sentence embeddings) using
+// the cosine distance, end-to-end. It first builds a k-NN graph over the
+// vectors (approximately, using NN-descent, when opt.Recall < 1), optionally
+// sparsifies it to mutual neighbors, and then clusters the graph. Only linked
+// vectors are ever considered for merging, and linkages are computed over the
+// edges between clusters only, e.g. average linkage scores the mean distance
+// of the neighbor links between two clusters. Clusters without any link
+// between them are never merged.
+//
+// The returned ClusterSet enumerates items as int indexes into vectors.
+func ClusterEmbeddings(vectors [][]float64, opt EmbeddingOptions, chk Checker, lt LinkageType) ClusterSet {
+	k := opt.K
+	if k >= len(vectors) {
+		k = len(vectors) - 1
+	}
+	if k < 0 {
+		k = 0
+	}
+	var nn [][]Neighbor
+	if opt.Recall <= 0 || opt.Recall >= 1 {
+		nn = exactNeighbors(vectors, k, metrics.Cosine)
+	} else {
+		nn = nnDescent(vectors, k, metrics.Cosine, opt.Recall, rand.New(rand.NewSource(opt.Seed)))
+	}
+
+	g := newGraphClusterSet(len(vectors))
+	for i, list := range nn {
+		for _, x := range list {
+			if opt.Mutual && !hasNeighbor(nn[x.Index], i) {
+				continue
+			}
+			g.link(i, x.Index, x.Distance)
+		}
+	}
+	Cluster(g, chk, lt)
+	return g
+}
+
+/////////////
+
+// nnDescent approximates the k nearest neighbors of each vector using the
+// NN-descent algorithm (Dong et al, 2011): neighbors of neighbors are likely
+// to be neighbors. Iterations continue until the recall estimated on a random
+// sample of vectors reaches the target, or no more updates are made.
+func nnDescent(vectors [][]float64, k int, metric Metric, recall float64, rng *rand.Rand) [][]Neighbor {
+	n := len(vectors)
+	nn := make([][]Neighbor, n)
+	for i := range nn {
+		for len(nn[i]) < k {
+			j := rng.Intn(n)
+			if j != i {
+				nn[i], _ = insertNeighbor(nn[i], k, Neighbor{j, metric(vectors[i], vectors[j])})
+			}
+		}
+	}
+
+	sample := rng.Perm(n)
+	if len(sample) > 32 {
+		sample = sample[:32]
+	}
+	exact := make([][]Neighbor, len(sample))
+	for s, i := range sample {
+		exact[s] = exactNeighborsOf(vectors, i, k, metric)
+	}
+
+	for {
+		// candidates are both forward and reverse neighbors
+		cands := make([][]int, n)
+		for i, list := range nn {
+			for _, x := range list {
+				cands[i] = append(cands[i], x.Index)
+				cands[x.Index] = append(cands[x.Index], i)
+			}
+		}
+
+		updates := 0
+		for _, c := range cands {
+			for a := 0; a < len(c); a++ {
+				for b := a + 1; b < len(c); b++ {
+					u, v := c[a], c[b]
+					if u == v {
+						continue
+					}
+					d := metric(vectors[u], vectors[v])
+					var ok bool
+					if nn[u], ok = insertNeighbor(nn[u], k, Neighbor{v, d}); ok {
+						updates++
+					}
+					if nn[v], ok = insertNeighbor(nn[v], k, Neighbor{u, d}); ok {
+						updates++
+					}
+				}
+			}
+		}
+
+		found, total := 0, 0
+		for s, i := range sample {
+			for _, x := range exact[s] {
+				total++
+				if hasNeighbor(nn[i], x.Index) {
+					found++
+				}
+			}
+		}
+		if updates == 0 || total == 0 || float64(found)/float64(total) >= recall {
+			return nn
+		}
+	}
+}
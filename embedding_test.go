@@ -0,0 +1,36 @@
+package clustering
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClusterEmbeddings(t *testing.T) {
+	var vectors [][]float64
+	for i := 0; i < 40; i++ {
+		a := float64(i%20) * 0.01
+		if i < 20 {
+			vectors = append(vectors, []float64{math.Cos(a), math.Sin(a), 0})
+		} else {
+			vectors = append(vectors, []float64{0, math.Sin(a), math.Cos(a)})
+		}
+	}
+
+	for _, recall := range []float64{1.0, 0.9} {
+		opt := EmbeddingOptions{K: 5, Recall: recall, Mutual: true, Seed: 1}
+		c := ClusterEmbeddings(vectors, opt, Threshold(0.5), SingleLinkage())
+		if c.Count() != 2 {
+			t.Errorf("recall %g: expected 2 clusters, got %d", recall, c.Count())
+		}
+		c.EachCluster(-1, func(cluster int) {
+			first := -1
+			c.EachItem(cluster, func(x ClusterItem) {
+				if first == -1 {
+					first = x.(int) / 20
+				} else if x.(int)/20 != first {
+					t.Errorf("recall %g: cluster %d mixes groups", recall, cluster)
+				}
+			})
+		})
+	}
+}
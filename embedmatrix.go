@@ -0,0 +1,194 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// EmbeddingMetric selects the distance used by an EmbeddingClusterSet.
+type EmbeddingMetric int
+
+const (
+	// EmbeddingCosine is the cosine distance 1 - a·b/(|a||b|). Zero vectors
+	// have distance 1 to every other vector.
+	EmbeddingCosine EmbeddingMetric = iota
+
+	// EmbeddingEuclidean is the Euclidean distance |a-b|.
+	EmbeddingEuclidean
+)
+
+// embedBlock is the number of rows in each block of the Gram matrix, chosen so
+// that a pair of blocks of typical embeddings fits in cache.
+const embedBlock = 64
+
+// GramFunc computes the m×n matrix c of dot products between the rows of the
+// m×d matrix a and the rows of the n×d matrix b, i.e. c = a·bᵀ, where every
+// matrix is stored in row-major order. It is called concurrently for
+// different blocks of rows, so it must not share state between calls.
+type GramFunc func(m, n, d int, a, b []float32, c []float64)
+
+// EmbeddingClusterSet is a ClusterSet specialized for dense embedding
+// matrices, e.g. from ML models. All pairwise distances are computed up front
+// from the Gram matrix of dot products, as matrix multiplications of one block
+// of rows by another across all CPUs, instead of once per pair callback. This
+// is dramatically faster for the initial all-pairs phase than a
+// PointsClusterSet, at the cost of storing n*(n-1)/2 single precision
+// distances.
+//
+// The items enumerated by EachItem are int row indexes, use Vector to
+// retrieve the original embedding for an item.
+type EmbeddingClusterSet struct {
+	clusterList
+
+	n, d      int
+	data      []float32
+	condensed []float32
+}
+
+// NewEmbeddingClusterSet creates a ClusterSet with one initial cluster for
+// each row of the n×d row-major matrix data. The blocks of the Gram matrix are
+// multiplied in pure Go, accumulating in double precision. It panics if
+// len(data) is not a multiple of d.
+func NewEmbeddingClusterSet(data []float32, d int, metric EmbeddingMetric) *EmbeddingClusterSet {
+	return NewEmbeddingClusterSetGram(data, d, metric, gram32)
+}
+
+// NewEmbeddingClusterSetGram is like NewEmbeddingClusterSet, but multiplies
+// the blocks of the Gram matrix with gram, e.g. to use an optimized BLAS
+// implementation such as the one provided by the gonum subpackage.
+func NewEmbeddingClusterSetGram(data []float32, d int, metric EmbeddingMetric, gram GramFunc) *EmbeddingClusterSet {
+	if d <= 0 || len(data)%d != 0 {
+		panic(fmt.Sprintf("clustering: embedding matrix has %d values, not a multiple of %d", len(data), d))
+	}
+	n := len(data) / d
+	e := &EmbeddingClusterSet{
+		n:         n,
+		d:         d,
+		data:      data,
+		condensed: make([]float32, n*(n-1)/2),
+	}
+	e.clusters = make([][]ClusterItem, n)
+	for i := range e.clusters {
+		e.clusters[i] = []ClusterItem{i}
+	}
+	e.compute(metric, gram)
+	return e
+}
+
+// Vector returns the embedding of an item.
+func (e *EmbeddingClusterSet) Vector(item ClusterItem) []float32 {
+	i := item.(int)
+	return e.data[i*e.d : (i+1)*e.d]
+}
+
+// Distance returns the precomputed distance between the two items.
+func (e *EmbeddingClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	i, j := item1.(int), item2.(int)
+	if i == j {
+		return 0.0
+	}
+	return float64(e.condensed[condensedIndex(e.n, i, j)])
+}
+
+// ItemDistance implements ItemDistanceSet, as the distance does not depend on
+// the clusters of the items.
+func (e *EmbeddingClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return e.Distance(-1, -1, item1, item2), true
+}
+
+// compute fills the condensed matrix, distributing blocks of rows over
+// workers. Each worker multiplies its block by every later block.
+func (e *EmbeddingClusterSet) compute(metric EmbeddingMetric, gram GramFunc) {
+	norms := make([]float64, e.n)
+	for i := range norms {
+		norms[i] = dot32(e.Vector(i), e.Vector(i))
+	}
+
+	blocks := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := make([]float64, embedBlock*embedBlock)
+			for b := range blocks {
+				e.computeBlock(b, metric, gram, norms, c)
+			}
+		}()
+	}
+	for b := 0; b < e.n; b += embedBlock {
+		blocks <- b
+	}
+	close(blocks)
+	wg.Wait()
+}
+
+// computeBlock computes the distances from rows [start, start+embedBlock) to
+// every later row, using c to hold each block of the Gram matrix. Only this
+// block's entries of the condensed matrix are written, so blocks can be
+// computed concurrently.
+func (e *EmbeddingClusterSet) computeBlock(start int, metric EmbeddingMetric, gram GramFunc, norms, c []float64) {
+	end := start + embedBlock
+	if end > e.n {
+		end = e.n
+	}
+	for jb := start; jb < e.n; jb += embedBlock {
+		jend := jb + embedBlock
+		if jend > e.n {
+			jend = e.n
+		}
+		m, n := end-start, jend-jb
+		gram(m, n, e.d, e.data[start*e.d:end*e.d], e.data[jb*e.d:jend*e.d], c[:m*n])
+
+		for i := start; i < end; i++ {
+			j0 := jb
+			if j0 <= i {
+				j0 = i + 1
+			}
+			for j := j0; j < jend; j++ {
+				ab := c[(i-start)*n+j-jb]
+				var d float64
+				switch metric {
+				case EmbeddingEuclidean:
+					d = math.Sqrt(math.Max(0, norms[i]+norms[j]-2*ab))
+				default:
+					if norms[i] == 0 || norms[j] == 0 {
+						d = 1.0
+					} else {
+						d = 1.0 - ab/math.Sqrt(norms[i]*norms[j])
+					}
+				}
+				e.condensed[condensedIndex(e.n, i, j)] = float32(d)
+			}
+		}
+	}
+}
+
+// gram32 is the pure Go GramFunc, accumulating in double precision.
+func gram32(m, n, d int, a, b []float32, c []float64) {
+	for i := 0; i < m; i++ {
+		ai := a[i*d : (i+1)*d]
+		for j := 0; j < n; j++ {
+			c[i*n+j] = dot32(ai, b[j*d:(j+1)*d])
+		}
+	}
+}
+
+// dot32 returns the dot product of a and b in double precision, unrolled to
+// allow independent accumulators.
+func dot32(a, b []float32) float64 {
+	var s0, s1, s2, s3 float64
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += float64(a[i]) * float64(b[i])
+		s1 += float64(a[i+1]) * float64(b[i+1])
+		s2 += float64(a[i+2]) * float64(b[i+2])
+		s3 += float64(a[i+3]) * float64(b[i+3])
+	}
+	for ; i < len(a); i++ {
+		s0 += float64(a[i]) * float64(b[i])
+	}
+	return s0 + s1 + s2 + s3
+}
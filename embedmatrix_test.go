@@ -0,0 +1,41 @@
+package clustering
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pbnjay/clustering/metrics"
+)
+
+func TestEmbeddingClusterSet(t *testing.T) {
+	// enough rows to span several blocks
+	n, d := 150, 5
+	data := make([]float32, n*d)
+	points := make([][]float64, n)
+	x := uint32(7)
+	for i := range points {
+		points[i] = make([]float64, d)
+		for k := range points[i] {
+			x = x*1103515245 + 12345
+			data[i*d+k] = float32(x%1000) / 100
+			points[i][k] = float64(data[i*d+k])
+		}
+	}
+
+	euc := NewEmbeddingClusterSet(data, d, EmbeddingEuclidean)
+	cos := NewEmbeddingClusterSet(data, d, EmbeddingCosine)
+	for _, p := range [][2]int{{0, 1}, {3, 149}, {70, 64}, {127, 128}} {
+		i, j := p[0], p[1]
+		if got, want := euc.Distance(i, j, i, j), metrics.Euclidean(points[i], points[j]); math.Abs(got-want) > 1e-4 {
+			t.Errorf("euclidean distance %d-%d: got %f, expected %f", i, j, got, want)
+		}
+		if got, want := cos.Distance(i, j, j, i), metrics.Cosine(points[i], points[j]); math.Abs(got-want) > 1e-5 {
+			t.Errorf("cosine distance %d-%d: got %f, expected %f", i, j, got, want)
+		}
+	}
+
+	Cluster(euc, MaxClusters(3), AverageLinkage())
+	if euc.Count() != 3 {
+		t.Errorf("expected 3 clusters, got %d", euc.Count())
+	}
+}
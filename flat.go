@@ -0,0 +1,51 @@
+package clustering
+
+import "math"
+
+// FlatCluster is one cluster of a flat partition, annotated with how tight and
+// how distinct it is.
+type FlatCluster struct {
+	// Cluster is the cluster id in the ClusterSet.
+	Cluster int
+
+	// Items are the members of the cluster.
+	Items []ClusterItem
+
+	// Height is the height at which the cluster was formed (0 for clusters
+	// that were never merged). Lower heights indicate tighter clusters.
+	Height float64
+
+	// NextHeight is the linkage score of the closest other cluster, i.e. the
+	// height at which this cluster would next be merged (+Inf if there are no
+	// other clusters). Higher values indicate more distinct clusters.
+	NextHeight float64
+}
+
+// FlatClusters returns the current clusters annotated with the height at which
+// each formed and the height at which each would next merge. It is typically
+// called once clustering has stopped, to report the flat partition.
+func (h *HClustering) FlatClusters() []FlatCluster {
+	if h.history == nil {
+		h.history = newHistory(h.ClusterSet)
+	}
+	var res []FlatCluster
+	h.ClusterSet.EachCluster(-1, func(c1 int) {
+		fc := FlatCluster{
+			Cluster:    c1,
+			Height:     h.history.height(c1),
+			NextHeight: math.Inf(1),
+		}
+		h.ClusterSet.EachItem(c1, func(x ClusterItem) {
+			fc.Items = append(fc.Items, x)
+		})
+		res = append(res, fc)
+	})
+	for a := range res {
+		for b := a + 1; b < len(res); b++ {
+			d := h.dist(res[a].Cluster, res[b].Cluster)
+			res[a].NextHeight = math.Min(res[a].NextHeight, d)
+			res[b].NextHeight = math.Min(res[b].NextHeight, d)
+		}
+	}
+	return res
+}
@@ -0,0 +1,25 @@
+package clustering
+
+import "testing"
+
+func TestFlatClusters(t *testing.T) {
+	h := &HClustering{
+		ClusterSet:  NewDistanceMapClusterSet(testLine()),
+		Checker:     Threshold(3),
+		LinkageType: SingleLinkage(),
+	}
+	h.Run()
+
+	flat := h.FlatClusters()
+	if len(flat) != 2 {
+		t.Fatalf("expected 2 flat clusters, got %d", len(flat))
+	}
+	for _, fc := range flat {
+		if fc.NextHeight != 4 {
+			t.Errorf("expected next merge at 4, got %g", fc.NextHeight)
+		}
+		if (len(fc.Items) == 3 && fc.Height != 2) || (len(fc.Items) == 1 && fc.Height != 0) {
+			t.Errorf("unexpected height %g for %v", fc.Height, fc.Items)
+		}
+	}
+}
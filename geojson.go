@@ -0,0 +1,105 @@
+package clustering
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// WriteGeoJSON writes the clusters of c as newline-delimited GeoJSON, with one
+// FeatureCollection per cluster, so results drop straight into mapping tools.
+// Each collection contains a Point feature for every item (with "cluster" and
+// "item" properties), plus a Polygon feature for the convex hull of the
+// cluster if it has at least 3 distinct, non-collinear points. The coord
+// function returns the longitude and latitude of an item.
+func WriteGeoJSON(w io.Writer, c ClusterSet, coord func(item ClusterItem) (lon, lat float64)) error {
+	enc := json.NewEncoder(w)
+
+	var err error
+	c.EachCluster(-1, func(cluster int) {
+		if err != nil {
+			return
+		}
+
+		fc := geoFeatureCollection{
+			Type:       "FeatureCollection",
+			Properties: map[string]interface{}{"cluster": cluster},
+		}
+		var pts [][2]float64
+		c.EachItem(cluster, func(x ClusterItem) {
+			lon, lat := coord(x)
+			pts = append(pts, [2]float64{lon, lat})
+			fc.Features = append(fc.Features, geoFeature{
+				Type:       "Feature",
+				Geometry:   geoGeometry{Type: "Point", Coordinates: [2]float64{lon, lat}},
+				Properties: map[string]interface{}{"cluster": cluster, "item": x},
+			})
+		})
+
+		if hull := convexHull(pts); len(hull) >= 3 {
+			// polygon rings must be closed
+			ring := append(hull, hull[0])
+			fc.Features = append(fc.Features, geoFeature{
+				Type:       "Feature",
+				Geometry:   geoGeometry{Type: "Polygon", Coordinates: [][][2]float64{ring}},
+				Properties: map[string]interface{}{"cluster": cluster, "hull": true},
+			})
+		}
+		err = enc.Encode(fc)
+	})
+	return err
+}
+
+/////////////
+
+type geoFeatureCollection struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Features   []geoFeature           `json:"features"`
+}
+
+type geoFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoGeometry            `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// convexHull returns the convex hull of the points in counter-clockwise order
+// using Andrew's monotone chain algorithm. Collinear points are excluded.
+func convexHull(pts [][2]float64) [][2]float64 {
+	if len(pts) < 3 {
+		return nil
+	}
+	p := append([][2]float64{}, pts...)
+	sort.Slice(p, func(i, j int) bool {
+		if p[i][0] != p[j][0] {
+			return p[i][0] < p[j][0]
+		}
+		return p[i][1] < p[j][1]
+	})
+
+	cross := func(o, a, b [2]float64) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+
+	hull := make([][2]float64, 0, 2*len(p))
+	for _, x := range p {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], x) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, x)
+	}
+	lower := len(hull) + 1
+	for i := len(p) - 2; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p[i])
+	}
+	return hull[:len(hull)-1]
+}
@@ -0,0 +1,87 @@
+package clustering
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	cases := []struct {
+		name string
+		pts  [][2]float64
+		want string
+	}{
+		{"two points", [][2]float64{{0, 0}, {1, 1}}, "[]"},
+		{"collinear", [][2]float64{{0, 0}, {2, 2}, {1, 1}}, "[[0 0] [2 2]]"},
+		{"duplicates", [][2]float64{{1, 1}, {1, 1}, {1, 1}}, "[[1 1] [1 1]]"},
+		{"square", [][2]float64{{0, 0}, {1, 1}, {0, 1}, {1, 0}, {0.5, 0.5}, {0, 0}, {0.5, 0}},
+			"[[0 0] [1 0] [1 1] [0 1]]"},
+	}
+	for _, c := range cases {
+		if got := fmt.Sprint(convexHull(c.pts)); got != c.want {
+			t.Errorf("%s: expected hull %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	coords := map[string][2]float64{
+		"a": {0, 0}, "b": {1, 0}, "c": {0, 1},
+		"d": {10, 10}, "e": {11, 11}, "f": {12, 12},
+		"g": {20, 20},
+	}
+	dm := make(DistanceMap)
+	for a, pa := range coords {
+		dm[a] = make(map[ClusterItem]float64)
+		for b, pb := range coords {
+			if a < b {
+				dm[a][b] = (pa[0]-pb[0])*(pa[0]-pb[0]) + (pa[1]-pb[1])*(pa[1]-pb[1])
+			}
+		}
+	}
+	c := NewDistanceMapClusterSet(dm)
+	Cluster(c, Threshold(10), SingleLinkage())
+
+	var buf bytes.Buffer
+	err := WriteGeoJSON(&buf, c, func(x ClusterItem) (lon, lat float64) {
+		p := coords[x.(string)]
+		return p[0], p[1]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 feature collections, got %d", len(lines))
+	}
+
+	// only the triangle has a hull, the collinear cluster and singleton do not
+	hulls := 0
+	for _, line := range lines {
+		var fc struct {
+			Features []struct {
+				Geometry struct {
+					Type        string
+					Coordinates json.RawMessage
+				}
+			}
+		}
+		if err := json.Unmarshal([]byte(line), &fc); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range fc.Features {
+			if f.Geometry.Type == "Polygon" {
+				hulls++
+				if string(f.Geometry.Coordinates) != "[[[0,0],[1,0],[0,1],[0,0]]]" {
+					t.Errorf("unexpected hull %s", f.Geometry.Coordinates)
+				}
+			}
+		}
+	}
+	if hulls != 1 {
+		t.Errorf("expected 1 hull, got %d", hulls)
+	}
+}
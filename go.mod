@@ -0,0 +1,3 @@
+module github.com/pbnjay/clustering
+
+go 1.25.0
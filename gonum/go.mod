@@ -0,0 +1,10 @@
+module github.com/pbnjay/clustering/gonum
+
+go 1.25.0
+
+require (
+	github.com/pbnjay/clustering v0.0.0-20261017040156-9087e28e0159
+	gonum.org/v1/gonum v0.17.0
+)
+
+replace github.com/pbnjay/clustering => ../
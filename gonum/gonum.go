@@ -0,0 +1,98 @@
+// Package gonum integrates the clustering package with the gonum numeric
+// libraries, so that users in the gonum ecosystem can evaluate and cluster
+// data without converting back and forth between representations.
+//
+// Functions that take a data matrix expect the items of the ClusterSet to be
+// int row indexes into that matrix.
+package gonum
+
+import (
+	"github.com/pbnjay/clustering"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Centroids returns a k×d matrix containing the centroid of each of the k
+// clusters of cs, where the items of cs are row indexes into the n×d matrix x.
+func Centroids(x mat.Matrix, cs clustering.ClusterSet) *mat.Dense {
+	_, d := x.Dims()
+	res := mat.NewDense(max(cs.Count(), 1), d, nil)
+	cs.EachCluster(-1, func(cluster int) {
+		rows := clusterRows(cs, cluster)
+		col := make([]float64, len(rows))
+		for j := 0; j < d; j++ {
+			for k, r := range rows {
+				col[k] = x.At(r, j)
+			}
+			res.Set(cluster, j, stat.Mean(col, nil))
+		}
+	})
+	return res
+}
+
+// WithinSS returns the within-cluster sum of squared deviations from the
+// centroid for each cluster. This is the quantity minimized by Ward's method,
+// and is useful for diagnosing Ward linkage results.
+func WithinSS(x mat.Matrix, cs clustering.ClusterSet) []float64 {
+	_, d := x.Dims()
+	res := make([]float64, cs.Count())
+	cs.EachCluster(-1, func(cluster int) {
+		rows := clusterRows(cs, cluster)
+		col := make([]float64, len(rows))
+		for j := 0; j < d; j++ {
+			for k, r := range rows {
+				col[k] = x.At(r, j)
+			}
+			if len(col) > 1 {
+				res[cluster] += stat.Variance(col, nil) * float64(len(col)-1)
+			}
+		}
+	})
+	return res
+}
+
+// CalinskiHarabasz computes the Calinski-Harabasz (variance ratio) index of
+// the partition in cs, where the items of cs are row indexes into x. Higher
+// values indicate denser, better separated clusters. Returns 0 when there are
+// fewer than 2 clusters or no more clusters than items.
+func CalinskiHarabasz(x mat.Matrix, cs clustering.ClusterSet) float64 {
+	n, d := x.Dims()
+	k := cs.Count()
+	if k < 2 || n <= k {
+		return 0.0
+	}
+
+	overall := make([]float64, d)
+	col := make([]float64, n)
+	for j := 0; j < d; j++ {
+		mat.Col(col, j, x)
+		overall[j] = stat.Mean(col, nil)
+	}
+
+	centroids := Centroids(x, cs)
+	between := 0.0
+	cs.EachCluster(-1, func(cluster int) {
+		size := float64(len(clusterRows(cs, cluster)))
+		for j := 0; j < d; j++ {
+			diff := centroids.At(cluster, j) - overall[j]
+			between += size * diff * diff
+		}
+	})
+
+	within := 0.0
+	for _, ss := range WithinSS(x, cs) {
+		within += ss
+	}
+	if within == 0.0 {
+		return 0.0
+	}
+	return (between / float64(k-1)) / (within / float64(n-k))
+}
+
+func clusterRows(cs clustering.ClusterSet, cluster int) []int {
+	var rows []int
+	cs.EachItem(cluster, func(x clustering.ClusterItem) {
+		rows = append(rows, x.(int))
+	})
+	return rows
+}
@@ -0,0 +1,88 @@
+package gonum
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pbnjay/clustering"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestEvaluationMetrics(t *testing.T) {
+	x := mat.NewDense(4, 2, []float64{
+		0, 0,
+		0, 1,
+		10, 0,
+		10, 1,
+	})
+	cs := clustering.NewDistanceMapClusterSet(clustering.DistanceMap{
+		0: {1: 1, 2: 10, 3: 10},
+		1: {2: 10, 3: 10},
+		2: {3: 1},
+	})
+	clustering.Cluster(cs, clustering.MaxClusters(2), clustering.AverageLinkage())
+
+	c := Centroids(x, cs)
+	if r, _ := c.Dims(); r != 2 || c.At(0, 1) != 0.5 || c.At(1, 1) != 0.5 {
+		t.Errorf("unexpected centroids %v", mat.Formatted(c))
+	}
+	for _, ss := range WithinSS(x, cs) {
+		if math.Abs(ss-0.5) > 1e-12 {
+			t.Errorf("expected within-cluster SS of 0.5, got %f", ss)
+		}
+	}
+	// between = 4 * 25 = 100, within = 1 => (100/1) / (1/2)
+	if ch := CalinskiHarabasz(x, cs); math.Abs(ch-200) > 1e-9 {
+		t.Errorf("expected CH index of 200, got %f", ch)
+	}
+}
+
+func TestSymmetricClusterSet(t *testing.T) {
+	// a, b and c are close together, and d is far from all of them
+	m := mat.NewSymDense(4, []float64{
+		0, 1, 2, 9,
+		1, 0, 2, 8,
+		2, 2, 0, 7,
+		9, 8, 7, 0,
+	})
+	if _, err := NewSymmetricClusterSet(m, []string{"a"}); err == nil {
+		t.Errorf("expected error for mismatched labels")
+	}
+	cs, err := NewSymmetricClusterSet(m, []string{"a", "b", "c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clustering.Cluster(cs, clustering.Threshold(2), clustering.SingleLinkage())
+	if cs.Count() != 2 {
+		t.Fatalf("expected 2 clusters, got %d", cs.Count())
+	}
+	n := 0
+	cs.EachItem(0, func(x clustering.ClusterItem) { n++ })
+	if n != 3 || cs.Label(3) != "d" {
+		t.Errorf("expected a cluster of 3 items and labels, got %d", n)
+	}
+}
+
+func TestGram(t *testing.T) {
+	// enough rows to span several blocks
+	n, d := 150, 5
+	data := make([]float32, n*d)
+	x := uint32(7)
+	for i := range data {
+		x = x*1103515245 + 12345
+		data[i] = float32(x%1000) / 100
+	}
+
+	for _, metric := range []clustering.EmbeddingMetric{clustering.EmbeddingEuclidean, clustering.EmbeddingCosine} {
+		want := clustering.NewEmbeddingClusterSet(data, d, metric)
+		got := clustering.NewEmbeddingClusterSetGram(data, d, metric, Gram)
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if math.Abs(got.Distance(i, j, i, j)-want.Distance(i, j, i, j)) > 1e-3 {
+					t.Fatalf("metric %d, distance %d-%d: got %f, expected %f", metric, i, j,
+						got.Distance(i, j, i, j), want.Distance(i, j, i, j))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package gonum
+
+import (
+	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/blas/blas32"
+)
+
+// Gram is a clustering.GramFunc that multiplies the blocks of the Gram matrix
+// of an EmbeddingClusterSet with the single precision BLAS GEMM, so that an
+// optimized implementation installed with blas32.Use is used. Pass it to
+// clustering.NewEmbeddingClusterSetGram.
+func Gram(m, n, d int, a, b []float32, c []float64) {
+	res := make([]float32, m*n)
+	blas32.Gemm(blas.NoTrans, blas.Trans, 1,
+		blas32.General{Rows: m, Cols: d, Stride: d, Data: a},
+		blas32.General{Rows: n, Cols: d, Stride: d, Data: b},
+		0, blas32.General{Rows: m, Cols: n, Stride: n, Data: res})
+	for k, x := range res {
+		c[k] = float64(x)
+	}
+}
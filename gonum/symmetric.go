@@ -0,0 +1,85 @@
+package gonum
+
+import (
+	"fmt"
+
+	"github.com/pbnjay/clustering"
+	"gonum.org/v1/gonum/mat"
+)
+
+// SymmetricClusterSet is a ClusterSet backed by a symmetric distance matrix,
+// such as a *mat.SymDense. The matrix is used directly, without copying.
+//
+// The items enumerated by EachItem are int row indexes into the matrix, use
+// Label to retrieve the label of an item.
+type SymmetricClusterSet struct {
+	m      mat.Symmetric
+	labels []string
+
+	clusters [][]clustering.ClusterItem
+}
+
+// NewSymmetricClusterSet creates a ClusterSet with one initial cluster for each
+// row of the distance matrix m. If labels is not nil, it must contain exactly
+// one label per row.
+func NewSymmetricClusterSet(m mat.Symmetric, labels []string) (*SymmetricClusterSet, error) {
+	n := m.SymmetricDim()
+	if labels != nil && len(labels) != n {
+		return nil, fmt.Errorf("gonum: %d labels for %d rows", len(labels), n)
+	}
+	s := &SymmetricClusterSet{
+		m:        m,
+		labels:   labels,
+		clusters: make([][]clustering.ClusterItem, n),
+	}
+	for i := range s.clusters {
+		s.clusters[i] = []clustering.ClusterItem{i}
+	}
+	return s, nil
+}
+
+// Label returns the label of an item, or its row index formatted as a string
+// if no labels were provided.
+func (s *SymmetricClusterSet) Label(item clustering.ClusterItem) string {
+	if s.labels == nil {
+		return fmt.Sprint(item)
+	}
+	return s.labels[item.(int)]
+}
+
+// Count returns the number of clusters in the set.
+func (s *SymmetricClusterSet) Count() int {
+	return len(s.clusters)
+}
+
+// EachCluster enumerates every cluster id "after" start.
+func (s *SymmetricClusterSet) EachCluster(start int, cb func(cluster int)) {
+	for i := start + 1; i < len(s.clusters); i++ {
+		cb(i)
+	}
+}
+
+// EachItem enumerates every item from the cluster.
+func (s *SymmetricClusterSet) EachItem(cluster int, cb func(item clustering.ClusterItem)) {
+	for _, x := range s.clusters[cluster] {
+		cb(x)
+	}
+}
+
+// Distance returns the matrix entry for the two items.
+func (s *SymmetricClusterSet) Distance(c1, c2 int, item1, item2 clustering.ClusterItem) float64 {
+	return s.m.At(item1.(int), item2.(int))
+}
+
+// Merge the two clusters together. The lower cluster id is kept, and the last
+// cluster is swapped into the place of the merged cluster.
+func (s *SymmetricClusterSet) Merge(i, j int) (kept, swappedIn int) {
+	if j < i {
+		i, j = j, i
+	}
+	last := len(s.clusters) - 1
+	s.clusters[i] = append(s.clusters[i], s.clusters[j]...)
+	s.clusters[j] = s.clusters[last]
+	s.clusters = s.clusters[:last]
+	return i, last
+}
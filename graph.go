@@ -0,0 +1,104 @@
+package clustering
+
+import "math"
+
+// ConnectedClusterSet is an optional interface for ClusterSets that constrain
+// which clusters may be merged, e.g. by spatial or structural adjacency. The
+// engine never merges clusters that are not connected.
+type ConnectedClusterSet interface {
+	// Connected returns true if clusters c1 and c2 may be merged.
+	Connected(c1, c2 int) bool
+}
+
+// connectedOf returns true if clusters c1 and c2 may be merged, which is
+// always the case if cs does not implement ConnectedClusterSet.
+func connectedOf(cs ClusterSet, c1, c2 int) bool {
+	if ccs, ok := cs.(ConnectedClusterSet); ok {
+		return ccs.Connected(c1, c2)
+	}
+	return true
+}
+
+// GraphClusterSet is a ClusterSet built from a weighted graph, where only
+// clusters connected by at least one edge may be merged. Missing edges are
+// not treated as a default distance, the clusters are truly unmergeable. The
+// linkage of two connected clusters is computed over the edges between them,
+// so e.g. average linkage scores the average weight of those edges. This
+// enables spatially and structurally constrained agglomeration.
+//
+// The items enumerated by EachItem are int node indexes.
+type GraphClusterSet struct {
+	*graphClusterSet
+
+	// adj contains the connected clusters of every cluster
+	adj []map[int]struct{}
+}
+
+// NewGraphClusterSet creates a graph of n nodes with no edges, with one initial
+// cluster for each node. Use AddEdge to connect nodes before clustering.
+func NewGraphClusterSet(n int) *GraphClusterSet {
+	g := &GraphClusterSet{
+		graphClusterSet: newGraphClusterSet(n),
+		adj:             make([]map[int]struct{}, n),
+	}
+	for i := range g.adj {
+		g.adj[i] = make(map[int]struct{})
+	}
+	return g
+}
+
+// AddEdge connects nodes a and b with an edge of the given distance. Edges
+// must be added before clustering starts.
+func (g *GraphClusterSet) AddEdge(a, b int, dist float64) {
+	if a == b {
+		return
+	}
+	g.link(a, b, dist)
+	g.adj[a][b] = struct{}{}
+	g.adj[b][a] = struct{}{}
+}
+
+// Connected returns true if any edge connects the two clusters.
+func (g *GraphClusterSet) Connected(c1, c2 int) bool {
+	_, ok := g.adj[c1][c2]
+	return ok
+}
+
+// ClusterDistance reports unconnected clusters as infinitely far apart, and
+// otherwise defers to the LinkageType over the edges between them.
+func (g *GraphClusterSet) ClusterDistance(c1, c2 int) (float64, bool) {
+	if !g.Connected(c1, c2) {
+		return math.Inf(1), true
+	}
+	return 0, false
+}
+
+// Merge the two clusters together, and combine their connections.
+func (g *GraphClusterSet) Merge(i, j int) (kept, swappedIn int) {
+	kept, swappedIn = g.graphClusterSet.Merge(i, j)
+	removed := j
+	if kept == j {
+		removed = i
+	}
+
+	// connect the removed cluster's neighbors to the kept cluster
+	for k := range g.adj[removed] {
+		delete(g.adj[k], removed)
+		if k != kept {
+			g.adj[k][kept] = struct{}{}
+			g.adj[kept][k] = struct{}{}
+		}
+	}
+	delete(g.adj[kept], removed)
+
+	// relabel the swapped in cluster
+	if swappedIn != removed {
+		g.adj[removed] = g.adj[swappedIn]
+		for k := range g.adj[removed] {
+			delete(g.adj[k], swappedIn)
+			g.adj[k][removed] = struct{}{}
+		}
+	}
+	g.adj = g.adj[:len(g.adj)-1]
+	return kept, swappedIn
+}
@@ -0,0 +1,25 @@
+package clustering
+
+import "testing"
+
+func TestGraphClusterSet(t *testing.T) {
+	g := testGraph()
+
+	h := &HClustering{
+		ClusterSet:     g,
+		Checker:        Threshold(100),
+		LinkageType:    AverageLinkage(),
+		CacheDistances: true,
+	}
+	h.Run()
+	if h.Result().StopReason != NoCandidates || g.Count() != 2 {
+		t.Errorf("expected 2 unconnected clusters, got %d (%v)", g.Count(), h.Result().StopReason)
+	}
+	if g.Connected(0, 1) {
+		t.Errorf("final clusters should not be connected")
+	}
+	// {0,1,2} joins 3 at the average of the edges 2-3 and 0-3
+	if m := h.Dendrogram().Merges; m[len(m)-1].Height != 6 {
+		t.Errorf("expected last merge at height 6, got %+v", m)
+	}
+}
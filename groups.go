@@ -0,0 +1,51 @@
+package clustering
+
+// Group identifies one of the pre-aggregated groups of a GroupClusterSet. It is
+// the ClusterItem type enumerated by the set's EachItem.
+type Group int
+
+// GroupClusterSet is a ClusterSet whose units are pre-aggregated groups of
+// items (e.g. sessions of events). Distances are computed directly between
+// groups by a user-provided function, skipping item-level distances entirely.
+type GroupClusterSet struct {
+	clusterList
+
+	groups [][]ClusterItem
+	dist   func(g1, g2 int) float64
+}
+
+// NewGroupClusterSet creates a ClusterSet with one initial cluster for each of
+// the groups, where dist computes the distance between groups g1 and g2 (as
+// indexes into groups). The items enumerated by EachItem are Group values, use
+// EachMember to enumerate the original items of a cluster.
+func NewGroupClusterSet(groups [][]ClusterItem, dist func(g1, g2 int) float64) *GroupClusterSet {
+	g := &GroupClusterSet{
+		groups: groups,
+		dist:   dist,
+	}
+	g.clusters = make([][]ClusterItem, len(groups))
+	for i := range groups {
+		g.clusters[i] = []ClusterItem{Group(i)}
+	}
+	return g
+}
+
+// Distance returns the distance between the two groups.
+func (g *GroupClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	return g.dist(int(item1.(Group)), int(item2.(Group)))
+}
+
+// ItemDistance implements ItemDistanceSet, as the distance does not depend on
+// the clusters of the items.
+func (g *GroupClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return g.Distance(-1, -1, item1, item2), true
+}
+
+// EachMember enumerates every original item of every group in the cluster.
+func (g *GroupClusterSet) EachMember(cluster int, cb func(item ClusterItem)) {
+	for _, x := range g.clusters[cluster] {
+		for _, item := range g.groups[x.(Group)] {
+			cb(item)
+		}
+	}
+}
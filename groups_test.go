@@ -0,0 +1,50 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestGroupClusterSet(t *testing.T) {
+	groups := [][]ClusterItem{{1.0, 2.0}, {3.0}, {10.0, 11.0, 12.0}}
+	mean := func(g int) float64 {
+		s := 0.0
+		for _, x := range groups[g] {
+			s += x.(float64)
+		}
+		return s / float64(len(groups[g]))
+	}
+	var calls int
+	g := NewGroupClusterSet(groups, func(g1, g2 int) float64 {
+		calls++
+		return math.Abs(mean(g1) - mean(g2))
+	})
+	Cluster(g, Threshold(5), AverageLinkage())
+	if g.Count() != 2 {
+		t.Fatalf("expected 2 clusters, got %d", g.Count())
+	}
+
+	var members []string
+	g.EachCluster(-1, func(cluster int) {
+		g.EachItem(cluster, func(x ClusterItem) {
+			if _, ok := x.(Group); !ok {
+				t.Errorf("expected Group items, got %T", x)
+			}
+		})
+		var m []float64
+		g.EachMember(cluster, func(x ClusterItem) {
+			m = append(m, x.(float64))
+		})
+		sort.Float64s(m)
+		members = append(members, fmt.Sprint(m))
+	})
+	sort.Strings(members)
+	if fmt.Sprint(members) != "[[1 2 3] [10 11 12]]" {
+		t.Errorf("unexpected cluster members %v", members)
+	}
+	if calls > 6 {
+		t.Errorf("expected only group-level distances, got %d calls", calls)
+	}
+}
@@ -0,0 +1,42 @@
+package clustering
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// HeightStep describes the state of clustering after a single merge.
+type HeightStep struct {
+	// Height is the linkage score of the merge.
+	Height float64 `json:"height"`
+
+	// Clusters is the number of clusters remaining after the merge.
+	Clusters int `json:"clusters"`
+}
+
+// HeightSeries returns the height of every merge in order, together with the
+// number of clusters remaining after it. Plotting heights against cluster
+// counts gives the "elbow" curve commonly used to choose a threshold (see
+// Threshold) or number of clusters (see CutK). Heights are non-decreasing for
+// the built-in linkage methods except CentroidLinkage.
+func (d *Dendrogram) HeightSeries() []HeightStep {
+	res := make([]HeightStep, len(d.Merges))
+	for k, m := range d.Merges {
+		res[k] = HeightStep{Height: m.Height, Clusters: len(d.Leaves) - k - 1}
+	}
+	return res
+}
+
+// WriteHeightSeriesCSV writes the HeightSeries of d to w as CSV, with the
+// header "step,height,clusters".
+func WriteHeightSeriesCSV(w io.Writer, d *Dendrogram) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"step", "height", "clusters"})
+	for k, s := range d.HeightSeries() {
+		cw.Write([]string{strconv.Itoa(k), strconv.FormatFloat(s.Height, 'g', -1, 64),
+			strconv.Itoa(s.Clusters)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
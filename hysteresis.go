@@ -0,0 +1,63 @@
+package clustering
+
+import "math"
+
+// ClusterHysteresis clusters the input set (in-place) using two thresholds.
+// First, clusters are merged normally until the tight merge threshold is hit.
+// Then, in a second pass similar to canopy refinement, any remaining singleton
+// clusters are attached to the closest multi-item cluster if their linkage is
+// within the looser attach threshold. This lets borderline items join existing
+// strong clusters instead of forming fragments.
+func ClusterHysteresis(c ClusterSet, lt LinkageType, merge, attach float64) {
+	h := &HClustering{
+		ClusterSet:  c,
+		Checker:     Threshold(merge),
+		LinkageType: lt,
+	}
+	for h.ClusterSet.Count() > 1 {
+		if !h.MergeNext() {
+			break
+		}
+	}
+	h.AttachFragments(attach)
+}
+
+// AttachFragments attaches singleton clusters to their closest multi-item
+// cluster, if their linkage score is within threshold. Fragments are attached
+// closest first, and attached items are no longer considered fragments.
+// Returns the number of fragments attached.
+func (h *HClustering) AttachFragments(threshold float64) int {
+	if h.history == nil {
+		h.history = newHistory(h.ClusterSet)
+	}
+
+	n := 0
+	for {
+		sizes := make([]int, h.ClusterSet.Count())
+		h.ClusterSet.EachCluster(-1, func(cluster int) {
+			sizes[cluster] = clusterSize(h.ClusterSet, cluster)
+		})
+
+		bestScore := math.MaxFloat64
+		var bestPair []int
+		h.ClusterSet.EachCluster(-1, func(c1 int) {
+			h.ClusterSet.EachCluster(c1, func(c2 int) {
+				// exactly one of the clusters must be a fragment
+				if (sizes[c1] == 1) == (sizes[c2] == 1) || h.isFrozen(c1) || h.isFrozen(c2) {
+					return
+				}
+				score := h.dist(c1, c2)
+				if score <= threshold && score < bestScore {
+					bestScore = score
+					bestPair = []int{c1, c2}
+				}
+			})
+		})
+		if len(bestPair) == 0 {
+			return n
+		}
+
+		h.merge(bestPair[0], bestPair[1], bestScore)
+		n++
+	}
+}
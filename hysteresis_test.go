@@ -0,0 +1,80 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+)
+
+func hysteresisSet() ClusterSet {
+	pos := map[string]float64{
+		"a0": 0, "a1": 0.1, "a2": 0.2, "x": 0.8,
+		"b0": 5, "b1": 5.1, "y": 5.9,
+		"z": 20, "v": 30, "w": 30.5,
+	}
+	d := make(DistanceMap)
+	for a, pa := range pos {
+		d[a] = make(map[ClusterItem]float64)
+		for b, pb := range pos {
+			if a < b {
+				d[a][b] = math.Abs(pa - pb)
+			}
+		}
+	}
+	return NewDistanceMapClusterSet(d)
+}
+
+func clusterNames(c ClusterSet) string {
+	var res []string
+	c.EachCluster(-1, func(cluster int) {
+		var items []string
+		c.EachItem(cluster, func(x ClusterItem) {
+			items = append(items, x.(string))
+		})
+		sort.Strings(items)
+		res = append(res, fmt.Sprint(items))
+	})
+	sort.Strings(res)
+	return fmt.Sprint(res)
+}
+
+func TestClusterHysteresis(t *testing.T) {
+	c := hysteresisSet()
+	ClusterHysteresis(c, SingleLinkage(), 0.15, 1.0)
+	// x and y attach to the nearby clusters, but the fragments v and w are
+	// never attached to each other
+	if s := clusterNames(c); s != "[[a0 a1 a2 x] [b0 b1 y] [v] [w] [z]]" {
+		t.Errorf("unexpected clusters %s", s)
+	}
+
+	c = hysteresisSet()
+	ClusterHysteresis(c, SingleLinkage(), 0.15, 0.7)
+	if s := clusterNames(c); s != "[[a0 a1 a2 x] [b0 b1] [v] [w] [y] [z]]" {
+		t.Errorf("unexpected clusters with a tighter attach threshold %s", s)
+	}
+}
+
+func TestAttachFragments(t *testing.T) {
+	h := &HClustering{
+		ClusterSet:  hysteresisSet(),
+		Checker:     Threshold(0.15),
+		LinkageType: SingleLinkage(),
+	}
+	for h.MergeNext() {
+	}
+	if h.ClusterSet.Count() != 7 {
+		t.Fatalf("expected 7 clusters after merging, got %d", h.ClusterSet.Count())
+	}
+	if n := h.AttachFragments(1.0); n != 2 {
+		t.Errorf("expected 2 fragments attached, got %d", n)
+	}
+	// attached fragments are recorded in the dendrogram
+	m := h.Dendrogram().Merges
+	if len(m) != 5 || math.Abs(m[3].Height-0.6) > 1e-9 || math.Abs(m[4].Height-0.8) > 1e-9 {
+		t.Errorf("unexpected merges %+v", m)
+	}
+	if n := h.AttachFragments(1.0); n != 0 {
+		t.Errorf("expected no more fragments to attach, got %d", n)
+	}
+}
@@ -0,0 +1,80 @@
+package clustering
+
+import "sort"
+
+// ClusterSizer is an optional interface for ClusterSets that can report the
+// number of items in a cluster without enumerating them.
+type ClusterSizer interface {
+	// ClusterSize returns the number of items in the cluster.
+	ClusterSize(cluster int) int
+}
+
+// ClusterIterator lazily iterates over clusters from largest to smallest,
+// materializing member lists only on demand. Callers that only need the top
+// few clusters of a very large result don't pay for building everything.
+//
+//	it := clustering.ClustersBySize(clusters)
+//	for it.Next() {
+//	  fmt.Println(it.Cluster(), it.Size(), it.Items())
+//	}
+type ClusterIterator struct {
+	cs    ClusterSet
+	order []int
+	sizes []int
+	pos   int
+}
+
+// ClustersBySize returns an iterator over the clusters of c in descending
+// order of size. Ties are ordered by cluster id. The ClusterSet must not be
+// modified during iteration.
+func ClustersBySize(c ClusterSet) *ClusterIterator {
+	it := &ClusterIterator{cs: c, pos: -1}
+	it.sizes = make([]int, c.Count())
+	c.EachCluster(-1, func(cluster int) {
+		it.order = append(it.order, cluster)
+		it.sizes[cluster] = clusterSize(c, cluster)
+	})
+	sort.SliceStable(it.order, func(i, j int) bool {
+		return it.sizes[it.order[i]] > it.sizes[it.order[j]]
+	})
+	return it
+}
+
+// Next advances to the next largest cluster, and returns false when there are
+// no more clusters.
+func (it *ClusterIterator) Next() bool {
+	it.pos++
+	return it.pos < len(it.order)
+}
+
+// Cluster returns the id of the current cluster.
+func (it *ClusterIterator) Cluster() int {
+	return it.order[it.pos]
+}
+
+// Size returns the number of items in the current cluster.
+func (it *ClusterIterator) Size() int {
+	return it.sizes[it.order[it.pos]]
+}
+
+// Items materializes the list of items in the current cluster.
+func (it *ClusterIterator) Items() []ClusterItem {
+	res := make([]ClusterItem, 0, it.Size())
+	it.cs.EachItem(it.Cluster(), func(x ClusterItem) {
+		res = append(res, x)
+	})
+	return res
+}
+
+// clusterSize returns the number of items in the cluster, using ClusterSizer
+// if available.
+func clusterSize(c ClusterSet, cluster int) int {
+	if cs, ok := c.(ClusterSizer); ok {
+		return cs.ClusterSize(cluster)
+	}
+	n := 0
+	c.EachItem(cluster, func(ClusterItem) {
+		n++
+	})
+	return n
+}
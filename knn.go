@@ -0,0 +1,178 @@
+package clustering
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pbnjay/clustering/metrics"
+)
+
+// Neighbor is an edge of a k-nearest-neighbor graph.
+type Neighbor struct {
+	// Index is the index of the neighboring item.
+	Index int
+
+	// Distance is the distance to the neighboring item.
+	Distance float64
+}
+
+// NewKNNGraphClusterSet creates a sparse ClusterSet from a k-nearest-neighbor
+// graph, where neighbors[i] lists the neighbors of item i. Only the pairs of
+// items in the graph have a finite distance, every other pair is treated as
+// infinitely far apart. This allows single and average linkage clustering of
+// datasets far too large for a full pairwise distance matrix. Edges are
+// symmetric, if both directions are given the last one is used.
+//
+// The items enumerated by EachItem are int indexes into neighbors.
+func NewKNNGraphClusterSet(neighbors [][]Neighbor) ClusterSet {
+	g := newGraphClusterSet(len(neighbors))
+	for i, list := range neighbors {
+		for _, x := range list {
+			g.link(i, x.Index, x.Distance)
+		}
+	}
+	return g
+}
+
+// NewKNNClusterSet builds the exact k-nearest-neighbor graph of the points
+// using metric, and returns it as a sparse ClusterSet (see
+// NewKNNGraphClusterSet). Computing the graph compares every pair of points,
+// but only O(n*k) memory is used. If metric is nil, metrics.Euclidean is used.
+func NewKNNClusterSet(points [][]float64, k int, metric Metric) ClusterSet {
+	if metric == nil {
+		metric = metrics.Euclidean
+	}
+	return NewKNNGraphClusterSet(exactNeighbors(points, k, metric))
+}
+
+/////////////
+
+// graphClusterSet is a ClusterSet over a sparse graph of int items, where the
+// distance between unlinked items is +Inf. Linkages are computed only over the
+// edges between two clusters, so e.g. average linkage scores the average
+// weight of those edges, and clusters without any edge between them are never
+// merged. As these scores have no Lance-Williams form, cached scores are
+// recomputed after every merge.
+type graphClusterSet struct {
+	clusterList
+
+	edges map[[2]int]float64
+}
+
+func newGraphClusterSet(n int) *graphClusterSet {
+	g := &graphClusterSet{edges: make(map[[2]int]float64)}
+	g.clusters = make([][]ClusterItem, n)
+	for i := range g.clusters {
+		g.clusters[i] = []ClusterItem{i}
+	}
+	return g
+}
+
+func (g *graphClusterSet) link(a, b int, dist float64) {
+	if b < a {
+		a, b = b, a
+	}
+	g.edges[[2]int{a, b}] = dist
+}
+
+func (g *graphClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	a, b := item1.(int), item2.(int)
+	if b < a {
+		a, b = b, a
+	}
+	if d, ok := g.edges[[2]int{a, b}]; ok {
+		return d
+	}
+	return math.Inf(1)
+}
+
+// ItemDistance implements ItemDistanceSet, as the distance does not depend on
+// the clusters of the items.
+func (g *graphClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return g.Distance(-1, -1, item1, item2), true
+}
+
+// EachItemDistance enumerates only the items of c2 with an edge to item1.
+func (g *graphClusterSet) EachItemDistance(c1, c2 int, item1 ClusterItem, cb func(ClusterItem, float64)) {
+	a := item1.(int)
+	g.EachItem(c2, func(item2 ClusterItem) {
+		b := item2.(int)
+		lo, hi := a, b
+		if hi < lo {
+			lo, hi = hi, lo
+		}
+		if d, ok := g.edges[[2]int{lo, hi}]; ok {
+			cb(item2, d)
+		}
+	})
+}
+
+// ClusterDistance returns +Inf for clusters without any edge between them,
+// and otherwise defers to the LinkageType over the edges.
+func (g *graphClusterSet) ClusterDistance(c1, c2 int) (float64, bool) {
+	linked := false
+	g.EachItem(c1, func(item1 ClusterItem) {
+		if !linked {
+			g.EachItemDistance(c1, c2, item1, func(ClusterItem, float64) {
+				linked = true
+			})
+		}
+	})
+	if !linked {
+		return math.Inf(1), true
+	}
+	return 0, false
+}
+
+/////////////
+
+func hasNeighbor(list []Neighbor, idx int) bool {
+	for _, x := range list {
+		if x.Index == idx {
+			return true
+		}
+	}
+	return false
+}
+
+// exactNeighbors returns the k nearest neighbors of each vector, sorted by
+// distance, by comparing every pair of vectors.
+func exactNeighbors(vectors [][]float64, k int, metric Metric) [][]Neighbor {
+	nn := make([][]Neighbor, len(vectors))
+	for i := range vectors {
+		nn[i] = exactNeighborsOf(vectors, i, k, metric)
+	}
+	return nn
+}
+
+func exactNeighborsOf(vectors [][]float64, i, k int, metric Metric) []Neighbor {
+	all := make([]Neighbor, 0, len(vectors)-1)
+	for j := range vectors {
+		if j != i {
+			all = append(all, Neighbor{j, metric(vectors[i], vectors[j])})
+		}
+	}
+	sort.Slice(all, func(a, b int) bool { return all[a].Distance < all[b].Distance })
+	if len(all) > k {
+		all = all[:k]
+	}
+	return all
+}
+
+// insertNeighbor adds x to the sorted list of at most k neighbors if it is
+// closer than the current furthest neighbor. Returns true if it was added.
+func insertNeighbor(list []Neighbor, k int, x Neighbor) ([]Neighbor, bool) {
+	if k <= 0 || hasNeighbor(list, x.Index) {
+		return list, false
+	}
+	if len(list) >= k && x.Distance >= list[len(list)-1].Distance {
+		return list, false
+	}
+	pos := sort.Search(len(list), func(i int) bool { return list[i].Distance > x.Distance })
+	if len(list) < k {
+		list = append(list, Neighbor{})
+	}
+	copy(list[pos+1:], list[pos:])
+	list[pos] = x
+	return list, true
+}
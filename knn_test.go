@@ -0,0 +1,63 @@
+package clustering
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKNNClusterSet(t *testing.T) {
+	var points [][]float64
+	for i := 0; i < 20; i++ {
+		points = append(points, []float64{float64(i%10) * 0.1, float64(i/10) * 100})
+	}
+	c := NewKNNClusterSet(points, 3, nil)
+	Cluster(c, Threshold(1), SingleLinkage())
+	if c.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", c.Count())
+	}
+	if d := c.Distance(0, 19, 0, 19); !math.IsInf(d, 1) {
+		t.Errorf("expected distant items to be unlinked, got %g", d)
+	}
+}
+
+func TestKNNAverageLinkage(t *testing.T) {
+	// two groups of 5 points far apart, where each point only links to its
+	// 2 nearest neighbors so that most pairs within a group are unlinked
+	var points [][]float64
+	for i := 0; i < 10; i++ {
+		points = append(points, []float64{float64(i%5) + float64(i/5)*100})
+	}
+	for _, cache := range []bool{false, true} {
+		c := NewKNNClusterSet(points, 2, nil)
+		h := &HClustering{
+			ClusterSet:     c,
+			Checker:        Threshold(100),
+			LinkageType:    AverageLinkage(),
+			CacheDistances: cache,
+		}
+		h.Run()
+		if h.Result().StopReason != NoCandidates || c.Count() != 2 {
+			t.Errorf("cache=%v: expected 2 unlinked clusters, got %d (%v)", cache, c.Count(), h.Result().StopReason)
+		}
+		for _, m := range h.Dendrogram().Merges {
+			if m.Height > 2 {
+				t.Errorf("cache=%v: expected only edges to be averaged, got %+v", cache, m)
+			}
+		}
+	}
+
+	g := NewKNNGraphClusterSet([][]Neighbor{
+		{{1, 1}, {2, 3}},
+		{{0, 1}},
+		{{0, 3}},
+		{},
+	})
+	h := &HClustering{ClusterSet: g, Checker: Threshold(100), LinkageType: AverageLinkage()}
+	h.Run()
+	if g.Count() != 2 {
+		t.Errorf("expected the isolated item to stay unmerged, got %d clusters", g.Count())
+	}
+	if m := h.Dendrogram().Merges; len(m) != 2 || m[1].Height != 3 {
+		t.Errorf("expected merges at 1 and 3, got %+v", m)
+	}
+}
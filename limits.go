@@ -0,0 +1,80 @@
+package clustering
+
+import "fmt"
+
+// Limits bounds the resources that a single clustering run may use, so that
+// services can bound the worst-case cost of each request. Zero values mean
+// unlimited.
+type Limits struct {
+	// MaxDistanceEvals limits the number of item-pair distance evaluations.
+	MaxDistanceEvals int
+
+	// MaxCacheBytes limits the memory used by the distance cache (see
+	// HClustering.CacheDistances).
+	MaxCacheBytes int
+
+	// MaxMerges limits the number of merges performed.
+	MaxMerges int
+}
+
+// Limit identifies one of the resource Limits.
+type Limit int
+
+// The available resource limits.
+const (
+	LimitDistanceEvals Limit = iota
+	LimitCacheBytes
+	LimitMerges
+)
+
+func (l Limit) String() string {
+	switch l {
+	case LimitDistanceEvals:
+		return "distance evaluations"
+	case LimitCacheBytes:
+		return "cache bytes"
+	case LimitMerges:
+		return "merges"
+	}
+	return fmt.Sprintf("Limit(%d)", int(l))
+}
+
+// LimitError is the error reported when clustering stops because one of the
+// configured Limits was exceeded.
+type LimitError struct {
+	// Limit identifies which limit was exceeded.
+	Limit Limit
+
+	// Max is the configured value of the limit.
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("clustering: exceeded limit of %d %s", e.Max, e.Limit)
+}
+
+// Err returns the error that stopped clustering, if any. A *LimitError is
+// returned when one of the configured Limits was exceeded.
+func (h *HClustering) Err() error {
+	return h.err
+}
+
+// Run merges clusters until the Checker stops clustering, there are no more
+// clusters to merge, or an error occurs. It returns the same error as Err.
+func (h *HClustering) Run() error {
+	for h.MergeNext() {
+	}
+	return h.err
+}
+
+// exceeded records a LimitError for the limit and returns true if value is
+// greater than the configured max.
+func (h *HClustering) exceeded(l Limit, max, value int) bool {
+	if max <= 0 || value <= max {
+		return false
+	}
+	if h.err == nil {
+		h.err = &LimitError{Limit: l, Max: max}
+	}
+	return true
+}
@@ -1,5 +1,7 @@
 package clustering
 
+import "math"
+
 // LinkageType is an interface that defines how two clusters are scored
 // based on the pairwise distances of their items.
 type LinkageType interface {
@@ -19,6 +21,23 @@ type LinkageType interface {
 	LWParams() []float64
 }
 
+// WeightedLinkage is an optional interface for LinkageTypes that account for
+// the weights of items in a WeightedClusterSet. When both are used, PutWeighted
+// is called instead of Put, with the weights of both items.
+type WeightedLinkage interface {
+	// PutWeighted adds a new distance observation for the item-pair, where
+	// the items have weights w1 and w2.
+	PutWeighted(item1, item2 ClusterItem, dist, w1, w2 float64)
+}
+
+// sizedLinkage is implemented by the provided linkages whose Lance-Williams
+// parameters depend only on the sizes (or total weights) ni and nj of the
+// merged clusters, so that cached scores can be updated without recomputing
+// the linkage of the merged pair from its items.
+type sizedLinkage interface {
+	lwParamsSized(ni, nj float64) []float64
+}
+
 // CompleteLinkage implements complete-linkage clustering, which is defined as
 // the maximum distance between any pair of items from the two clusters.
 func CompleteLinkage() LinkageType {
@@ -46,6 +65,15 @@ func WeightedAverageLinkage() LinkageType {
 	return &avgLinkage{isWeighted: true}
 }
 
+// GeometricMeanLinkage implements clustering based on the geometric mean of
+// all distances between all pairs of items in the two clusters. This is useful
+// when distances span several orders of magnitude (e.g. sequence e-values),
+// where an arithmetic average is dominated by the largest values. There is no
+// Lance-Williams form for this linkage, so scores are always recomputed.
+func GeometricMeanLinkage() LinkageType {
+	return &geoMeanLinkage{}
+}
+
 ////////////////
 
 type maxLinkage struct {
@@ -70,6 +98,14 @@ func (c *maxLinkage) LWParams() []float64 {
 	return []float64{0.5, 0.5, 0.0, 0.5}
 }
 
+func (c *maxLinkage) lwParamsSized(ni, nj float64) []float64 {
+	return c.LWParams()
+}
+
+func (c *maxLinkage) Describe() Description {
+	return Description{Name: "complete"}
+}
+
 ////////////////
 
 type minLinkage struct {
@@ -94,6 +130,14 @@ func (c *minLinkage) LWParams() []float64 {
 	return []float64{0.5, 0.5, 0.0, -0.5}
 }
 
+func (c *minLinkage) lwParamsSized(ni, nj float64) []float64 {
+	return c.LWParams()
+}
+
+func (c *minLinkage) Describe() Description {
+	return Description{Name: "single"}
+}
+
 ////////////////
 
 type avgLinkage struct {
@@ -101,16 +145,16 @@ type avgLinkage struct {
 	totalPairs float64
 
 	isWeighted  bool
-	leftCounts  map[ClusterItem]struct{}
-	rightCounts map[ClusterItem]struct{}
+	leftCounts  map[ClusterItem]float64
+	rightCounts map[ClusterItem]float64
 }
 
 func (c *avgLinkage) Reset() {
 	c.avgDist = 0.0
 	c.totalPairs = 0.0
 	if !c.isWeighted {
-		c.leftCounts = make(map[ClusterItem]struct{})
-		c.rightCounts = make(map[ClusterItem]struct{})
+		c.leftCounts = make(map[ClusterItem]float64)
+		c.rightCounts = make(map[ClusterItem]float64)
 	}
 }
 
@@ -122,11 +166,15 @@ func (c *avgLinkage) Get() float64 {
 }
 
 func (c *avgLinkage) Put(a, b ClusterItem, dist float64) {
-	c.avgDist += dist
-	c.totalPairs++
+	c.PutWeighted(a, b, dist, 1.0, 1.0)
+}
+
+func (c *avgLinkage) PutWeighted(a, b ClusterItem, dist, wa, wb float64) {
+	c.avgDist += wa * wb * dist
+	c.totalPairs += wa * wb
 	if !c.isWeighted {
-		c.leftCounts[a] = struct{}{}
-		c.rightCounts[b] = struct{}{}
+		c.leftCounts[a] = wa
+		c.rightCounts[b] = wb
 	}
 }
 
@@ -134,7 +182,69 @@ func (c *avgLinkage) LWParams() []float64 {
 	if c.isWeighted {
 		return []float64{0.5, 0.5, 0.0, 0.0}
 	}
-	ni := float64(len(c.leftCounts))
-	nj := float64(len(c.rightCounts))
+	ni, nj := 0.0, 0.0
+	for _, w := range c.leftCounts {
+		ni += w
+	}
+	for _, w := range c.rightCounts {
+		nj += w
+	}
+	return []float64{ni / (ni + nj), nj / (ni + nj), 0.0, 0.0}
+}
+
+func (c *avgLinkage) lwParamsSized(ni, nj float64) []float64 {
+	if c.isWeighted {
+		return []float64{0.5, 0.5, 0.0, 0.0}
+	}
 	return []float64{ni / (ni + nj), nj / (ni + nj), 0.0, 0.0}
 }
+
+func (c *avgLinkage) Describe() Description {
+	if c.isWeighted {
+		return Description{Name: "weighted-average"}
+	}
+	return Description{Name: "average"}
+}
+
+////////////////
+
+type geoMeanLinkage struct {
+	logSum     float64
+	totalPairs float64
+	hasZero    bool
+}
+
+func (c *geoMeanLinkage) Reset() {
+	c.logSum = 0.0
+	c.totalPairs = 0.0
+	c.hasZero = false
+}
+
+func (c *geoMeanLinkage) Get() float64 {
+	if c.totalPairs <= 0.0 || c.hasZero {
+		return 0.0
+	}
+	return math.Exp(c.logSum / c.totalPairs)
+}
+
+func (c *geoMeanLinkage) Put(a, b ClusterItem, dist float64) {
+	c.PutWeighted(a, b, dist, 1.0, 1.0)
+}
+
+func (c *geoMeanLinkage) PutWeighted(a, b ClusterItem, dist, wa, wb float64) {
+	c.totalPairs += wa * wb
+	if dist <= 0.0 {
+		// any zero distance makes the product (and so the mean) zero
+		c.hasZero = true
+		return
+	}
+	c.logSum += wa * wb * math.Log(dist)
+}
+
+func (c *geoMeanLinkage) LWParams() []float64 {
+	return nil
+}
+
+func (c *geoMeanLinkage) Describe() Description {
+	return Description{Name: "geometric-mean"}
+}
@@ -1,6 +1,9 @@
 package clustering
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 // ClusterItem represents a generic cluster item key. For implementation
 // purposes, it should be comparable / suitable as a map key.
@@ -52,6 +55,50 @@ type OptimizedClusterSet interface {
 	EachItemDistance(c1, c2 int, item1 ClusterItem, cb func(item2 ClusterItem, dist float64))
 }
 
+// ClusterDistanceSet allows implementors to supply the distance between two
+// clusters directly, bypassing item enumeration and the LinkageType, when it can
+// be computed cheaply (e.g. from stored centroids). This interface is optional.
+type ClusterDistanceSet interface {
+	// ClusterDistance returns the distance between clusters c1 and c2 and true,
+	// or false to fall back to the LinkageType over all pairs of items.
+	ClusterDistance(c1, c2 int) (float64, bool)
+}
+
+// ItemDistanceSet is an optional interface for ClusterSets that can supply the
+// distance between any two items, regardless of their clusters. Distance is
+// only defined between items in separate clusters, so this is required to
+// compute distances within a cluster, e.g. for medoids or intra-cluster
+// statistics. The ClusterSets created by this package implement it.
+type ItemDistanceSet interface {
+	// ItemDistance returns the distance between the two items and true, or
+	// false if it is not available (e.g. from a wrapper of a ClusterSet that
+	// does not implement ItemDistanceSet).
+	ItemDistance(item1, item2 ClusterItem) (float64, bool)
+}
+
+// WeightedClusterSet is an optional interface for ClusterSets whose items carry
+// a weight or multiplicity, e.g. so that one record can stand in for many
+// identical observations. Linkages that implement WeightedLinkage (such as
+// average linkage) weight every item-pair distance by the product of the item
+// weights, making them exact for the expanded data.
+type WeightedClusterSet interface {
+	// Weight returns the weight of the item.
+	Weight(item ClusterItem) float64
+}
+
+// RemovableClusterSet is an optional interface for ClusterSets that support
+// removing items, e.g. so that outliers flagged mid-analysis can be dropped
+// without rebuilding everything from scratch. See HClustering.RemoveItem.
+// Only the ClusterSets created by the NewDistanceMapClusterSet family of
+// constructors implement it.
+type RemovableClusterSet interface {
+	// RemoveItem removes the item from its cluster, and returns the id of the
+	// cluster, or -1 if the item was not found. If the cluster is now empty
+	// it is removed and empty is true, and (as with Merge) the last cluster
+	// is moved into its position.
+	RemoveItem(item ClusterItem) (cluster int, empty bool)
+}
+
 type defaultOptimizedClusterSet struct {
 	cs ClusterSet
 }
@@ -63,6 +110,70 @@ func (x *defaultOptimizedClusterSet) EachItemDistance(c1, c2 int, item1 ClusterI
 	})
 }
 
+// eachItemDistance calls cb with the distance from item1 to every item of
+// cluster c2, using OptimizedClusterSet when cs implements it.
+func eachItemDistance(cs ClusterSet, c1, c2 int, item1 ClusterItem, cb func(ClusterItem, float64)) {
+	ocs, ok := cs.(OptimizedClusterSet)
+	if !ok {
+		ocs = &defaultOptimizedClusterSet{cs}
+	}
+	ocs.EachItemDistance(c1, c2, item1, cb)
+}
+
+// weightOf returns the weight of an item, or 1 if cs does not implement
+// WeightedClusterSet.
+func weightOf(cs ClusterSet, item ClusterItem) float64 {
+	if wcs, ok := cs.(WeightedClusterSet); ok {
+		return wcs.Weight(item)
+	}
+	return 1.0
+}
+
+// clusterDistanceOf returns the distance between two clusters and true, or
+// false if cs does not implement ClusterDistanceSet.
+func clusterDistanceOf(cs ClusterSet, c1, c2 int) (float64, bool) {
+	if cds, ok := cs.(ClusterDistanceSet); ok {
+		return cds.ClusterDistance(c1, c2)
+	}
+	return 0, false
+}
+
+// itemDistanceOf returns the distance between two items regardless of their
+// clusters and true, or false if cs does not implement ItemDistanceSet.
+func itemDistanceOf(cs ClusterSet, item1, item2 ClusterItem) (float64, bool) {
+	if ids, ok := cs.(ItemDistanceSet); ok {
+		return ids.ItemDistance(item1, item2)
+	}
+	return 0, false
+}
+
+// itemDistance returns the distance between item1 of cluster c1 and item2 of
+// cluster c2 and true. If the clusters are the same, the distance is only
+// available from an ItemDistanceSet, otherwise false is returned.
+func itemDistance(cs ClusterSet, c1, c2 int, item1, item2 ClusterItem) (float64, bool) {
+	if c1 != c2 {
+		return cs.Distance(c1, c2, item1, item2), true
+	}
+	return itemDistanceOf(cs, item1, item2)
+}
+
+// clusterSetWrapper is implemented by ClusterSets that wrap another ClusterSet
+// and forward its optional interfaces, so that it is possible to tell which
+// of them are actually provided.
+type clusterSetWrapper interface {
+	wrapped() ClusterSet
+}
+
+// providesClusterDistance returns true if cs, or the ClusterSet it wraps,
+// implements ClusterDistanceSet.
+func providesClusterDistance(cs ClusterSet) bool {
+	if w, ok := cs.(clusterSetWrapper); ok {
+		return providesClusterDistance(w.wrapped())
+	}
+	_, ok := cs.(ClusterDistanceSet)
+	return ok
+}
+
 // HClustering is a hierarchical clustering wrapper for arbitrary data sets.
 type HClustering struct {
 	// LinkageType is the method used to select clusters to merge.
@@ -74,8 +185,64 @@ type HClustering struct {
 	// ClusterSet is used to enumerate and manipulate the set of clusters.
 	ClusterSet ClusterSet
 
-	lwCache   []float64
-	distCache map[int]map[int]float64
+	// CacheDistances enables caching of the linkage score between every pair
+	// of clusters, trading O(n^2) memory for far fewer distance calculations.
+	// When the LinkageType provides Lance-Williams parameters, cached scores
+	// are updated in-place after each merge, otherwise only the scores
+	// involving the merged clusters are recomputed.
+	CacheDistances bool
+
+	// CacheStorage selects how cached scores are stored. CacheFloat32 halves
+	// the memory used by the cache. CacheUint16 requires CacheRange to hold
+	// the minimum and maximum expected scores, and quarters the memory used
+	// by the cache at a small cost in precision. Clustering panics if the
+	// range is empty or not finite.
+	CacheStorage CacheStorage
+	CacheRange   [2]float64
+
+	// MonotonicHeights corrects inversions produced by non-monotone linkages,
+	// where a merge scores lower than the merges that formed its clusters.
+	// When enabled, the height of such a merge is raised to the height of its
+	// tallest child, so that threshold cuts and dendrograms are well-defined.
+	// The corrected height is the one passed to the Checker and recorded.
+	MonotonicHeights bool
+
+	// Memo, if set, is consulted for every item-pair distance before asking
+	// the ClusterSet, and is updated with every newly computed distance. The
+	// distances from an item to a cluster are only taken from the Memo when
+	// all of them are found, otherwise they are enumerated by the ClusterSet
+	// (see OptimizedClusterSet). The same MemoStore can be shared by many runs.
+	Memo *MemoStore
+
+	// Precision configures periodic verification of cached scores that were
+	// updated using the Lance-Williams method, protecting long runs from
+	// accumulated floating-point error.
+	Precision PrecisionCheck
+
+	// Limits bounds the resources used by clustering. When a limit is
+	// exceeded, MergeNext returns false and Err reports a *LimitError.
+	Limits Limits
+
+	// VetoPairs, if set, is called for every candidate pair of clusters before
+	// it is scored. Returning true skips the pair, so that it will not be
+	// merged at this step, but clustering continues with the other pairs.
+	// Since cluster ids change as clusters are merged, the predicate should
+	// examine the items of each cluster rather than remembering ids.
+	VetoPairs func(c1, c2 int) bool
+
+	distCache *scoreCache
+	history   *history
+	frozen    map[int]struct{}
+
+	distEvals int
+	merges    int
+	err       error
+	stop      StopReason
+	identical bool
+
+	scored     bool
+	finalScore float64
+	finalPair  [2]int
 }
 
 //////////////////
@@ -88,31 +255,46 @@ func Cluster(c ClusterSet, chk Checker, lt LinkageType) {
 		Checker:     chk,
 		LinkageType: lt,
 	}
-
-	for h.ClusterSet.Count() > 1 {
-		if !h.MergeNext() {
-			break
-		}
-	}
+	h.Run()
 }
 
 // calculate the distance between cluster i and cluster j.
 // also caches and reuses prior calculations
 func (h *HClustering) dist(i, j int) float64 {
 	if h.distCache != nil {
-		if i > j {
-			i, j = j, i
+		if s, ok := h.distCache.get(i, j); ok {
+			return s
 		}
-		if _, f := h.distCache[i]; f {
-			if s, f2 := h.distCache[i][j]; f2 {
-				return s
-			}
-		} else {
-			// prep for saving to cache
-			h.distCache[i] = make(map[int]float64)
+	}
+
+	s := h.linkage(i, j)
+	if h.distCache != nil {
+		h.distCache.set(i, j, s)
+	}
+	return s
+}
+
+// linkage computes the linkage score between cluster i and cluster j from
+// the pairwise distances of their items, without using the cache.
+func (h *HClustering) linkage(i, j int) float64 {
+	if cds, ok := h.ClusterSet.(ClusterDistanceSet); ok {
+		if d, ok := cds.ClusterDistance(i, j); ok {
+			return d
 		}
 	}
+	if vl, ok := h.LinkageType.(VectorLinkage); ok {
+		return h.vectorScore(vl, i, j)
+	}
+
 	h.LinkageType.Reset()
+	put := h.LinkageType.Put
+	wcs, ok1 := h.ClusterSet.(WeightedClusterSet)
+	wl, ok2 := h.LinkageType.(WeightedLinkage)
+	if ok1 && ok2 {
+		put = func(a, b ClusterItem, dist float64) {
+			wl.PutWeighted(a, b, dist, wcs.Weight(a), wcs.Weight(b))
+		}
+	}
 
 	ocs, ok := h.ClusterSet.(OptimizedClusterSet)
 	if !ok {
@@ -120,108 +302,264 @@ func (h *HClustering) dist(i, j int) float64 {
 	}
 
 	h.ClusterSet.EachItem(i, func(a ClusterItem) {
+		if h.Memo != nil && h.memoized(i, j, a, put) {
+			return
+		}
 		ocs.EachItemDistance(i, j, a, func(b ClusterItem, dist float64) {
-			h.LinkageType.Put(a, b, dist)
+			h.distEvals++
+			if h.Memo != nil {
+				h.Memo.Put(a, b, dist)
+			}
+			put(a, b, dist)
 		})
 	})
 
-	s := h.LinkageType.Get()
-	if h.distCache != nil {
-		h.distCache[i][j] = s
+	return h.LinkageType.Get()
+}
+
+// memoized puts the distances from item a to every item of cluster j into the
+// linkage if they are all found in the Memo, and returns true. Otherwise
+// nothing is put, and the distances must be enumerated by the ClusterSet, so
+// that e.g. the missing edges of a graph are never scored.
+func (h *HClustering) memoized(i, j int, a ClusterItem, put func(a, b ClusterItem, dist float64)) bool {
+	var bs []ClusterItem
+	var dists []float64
+	found := true
+	h.ClusterSet.EachItem(j, func(b ClusterItem) {
+		if !found {
+			return
+		}
+		dist, ok := h.Memo.Get(a, b)
+		bs = append(bs, b)
+		dists = append(dists, dist)
+		found = ok
+	})
+	if !found {
+		return false
 	}
-	return s
+	for k, b := range bs {
+		put(a, b, dists[k])
+	}
+	return true
 }
 
-// merges clusters i and j, and calculates the new distances resulting from it.
-// 1) call ClusterSet.Merge(i,j)
-// 2) remove cluster r=ClusterSet.Count() from distance cache
-// 3) for each cluster x:
-// 3a) update distances for (i,j) and remove r
-func (h *HClustering) mergeAndUpdateAll(i, j int) {
-	nc := h.ClusterSet.Count()
+// itemWeight returns the weight of the item when item weights are used by the
+// linkage, otherwise 1.
+func (h *HClustering) itemWeight(x ClusterItem) float64 {
+	wcs, ok1 := h.ClusterSet.(WeightedClusterSet)
+	_, ok2 := h.LinkageType.(WeightedLinkage)
+	if !ok1 || !ok2 {
+		return 1.0
+	}
+	return wcs.Weight(x)
+}
 
-	diks := []float64{}
-	djks := []float64{}
-	for k := 0; k < nc; k++ {
-		diks = append(diks, h.dist(i, k))
-		djks = append(djks, h.dist(j, k))
+// clusterWeight returns the number of items in the cluster, or their total
+// weight when item weights are used by the linkage.
+func (h *HClustering) clusterWeight(cluster int) float64 {
+	wcs, ok1 := h.ClusterSet.(WeightedClusterSet)
+	_, ok2 := h.LinkageType.(WeightedLinkage)
+	if !ok1 || !ok2 {
+		return float64(clusterSize(h.ClusterSet, cluster))
 	}
+	w := 0.0
+	h.ClusterSet.EachItem(cluster, func(x ClusterItem) {
+		w += wcs.Weight(x)
+	})
+	return w
+}
 
-	origDist := diks[j]
-	ni, nj := h.ClusterSet.Merge(i, j)
+// merges clusters i and j, and calculates the new distances resulting from it.
+// 1) determine lance-williams parameters from the (i,j) linkage
+// 2) call ClusterSet.Merge(i,j)
+// 3) update (or invalidate) the cached distances for the kept cluster
+// 4) move the cached distances of the swapped-in cluster into the removed slot
+func (h *HClustering) mergeAndUpdateAll(i, j int) (kept, swappedIn int) {
+	nc := h.ClusterSet.Count()
 
-	if nj != j {
-		// where did nj go to?
-		r := j
-		if ni == j {
-			r = i
-		}
+	var dij float64
+	var lw []float64
+	if sl, ok := h.LinkageType.(sizedLinkage); ok {
+		dij = h.dist(i, j)
+		lw = sl.lwParamsSized(h.clusterWeight(i), h.clusterWeight(j))
+	} else {
+		// recompute (i,j) so that the linkage state reflects this pair, which
+		// may be required for size-dependent parameters
+		dij = h.linkage(i, j)
+		lw = h.LinkageType.LWParams()
+	}
+	if providesClusterDistance(h.ClusterSet) {
+		// direct cluster distances have no lance-williams form
+		lw = nil
+	}
 
-		//move cached distances from nj into r
+	var diks, djks []float64
+	if len(lw) == 4 {
+		diks = make([]float64, nc)
+		djks = make([]float64, nc)
 		for k := 0; k < nc; k++ {
-			if k == nj {
+			if k == i || k == j {
 				continue
 			}
-			x1, y1 := k, r
-			if x1 > y1 {
-				x1, y1 = r, k
-			}
-
-			x2, y2 := k, nj
-			if x2 > y2 {
-				x2, y2 = nj, k
-			}
-			h.distCache[x1][y1] = h.distCache[x2][y2]
+			diks[k] = h.dist(i, k)
+			djks[k] = h.dist(j, k)
 		}
+	}
 
-		// now remove unused cache values
-		for k := 0; k < nc; k++ {
-			if k == nj {
-				delete(h.distCache, nj)
-				continue
-			}
-			if _, f := h.distCache[k]; f {
-				delete(h.distCache[k], nj)
-			}
-		}
+	kept, swappedIn = h.ClusterSet.Merge(i, j)
+	removed := j
+	if kept == j {
+		removed = i
 	}
 
 	// apply lance-williams update method to all affected pairs
-	nc--
 	for k := 0; k < nc; k++ {
+		if k == i || k == j {
+			continue
+		}
+		if len(lw) != 4 {
+			h.distCache.clear(kept, k)
+			continue
+		}
 		dik := diks[k]
 		djk := djks[k]
 		dd := dik - djk
 		if dd < 0.0 {
 			dd = -dd
 		}
+		d := lw[0]*dik + lw[1]*djk + lw[2]*dij + lw[3]*dd
+		h.distCache.set(kept, k, d)
+	}
+
+	if swappedIn != removed {
+		h.distCache.move(swappedIn, removed, nc)
+	}
+	h.distCache.truncate(nc - 1)
+	return kept, swappedIn
+}
 
-		d := h.lwCache[0]*dik + h.lwCache[1]*djk + h.lwCache[2]*origDist + h.lwCache[3]*dd
-		if ni < k {
-			h.distCache[ni][k] = d
-		} else {
-			h.distCache[k][ni] = d
+// PrecisionCheck configures periodic verification of cached linkage scores
+// against exact recomputation from the items. It only applies when
+// CacheDistances is enabled. It should not be used with linkages whose
+// Lance-Williams form differs from recomputation (e.g. WeightedAverageLinkage),
+// as their cached scores are expected to differ.
+type PrecisionCheck struct {
+	// Every is the number of merges between verifications, or 0 to disable.
+	Every int
+
+	// Sample is the number of cached scores to verify each time.
+	Sample int
+
+	// Tolerance is the maximum absolute difference allowed between a cached
+	// score and its exact value. If any sampled score drifts further than this,
+	// the whole cache is discarded and rebuilt from exact values.
+	Tolerance float64
+}
+
+// verifyCache recomputes a sample of the cached scores, and resets the cache
+// if any have drifted beyond the tolerance.
+func (h *HClustering) verifyCache() {
+	for _, p := range h.distCache.sample(h.Precision.Sample) {
+		cached, _ := h.distCache.get(p[0], p[1])
+		if math.Abs(cached-h.linkage(p[0], p[1])) > h.Precision.Tolerance {
+			h.distCache.reset()
+			return
 		}
 	}
+}
 
+// merge clusters i and j at the given height, updating the distance cache and
+// recording the merge in the history.
+func (h *HClustering) merge(i, j int, height float64) {
+	var kept, swappedIn int
+	if h.distCache == nil {
+		kept, swappedIn = h.ClusterSet.Merge(i, j)
+	} else {
+		kept, swappedIn = h.mergeAndUpdateAll(i, j)
+	}
+
+	removed := j
+	if kept == j {
+		removed = i
+	}
+	h.history.merge(kept, removed, swappedIn, height)
+	h.merges++
+
+	if h.distCache != nil && h.Precision.Every > 0 && h.merges%h.Precision.Every == 0 {
+		h.verifyCache()
+	}
 }
 
 // MergeNext finds the next pair of clusters to merge by applying the linkage
 // method to all pairs and selecting the best result. It then verifies criteria
 // are met before merging them. It returns true if the pair of clusters was
-// merged successfully, otherwise false.
+// merged successfully, otherwise false, and Result describes why.
 func (h *HClustering) MergeNext() bool {
 	bestScore := math.MaxFloat64
+	worstScore := -math.MaxFloat64
 	var bestPair []int
 
-	if len(h.lwCache) != 4 {
-		h.lwCache = h.LinkageType.LWParams()
-		//h.distCache = make(map[int]map[int]float64)
+	if h.err != nil {
+		return false
+	}
+	n := h.ClusterSet.Count()
+	if n < 2 {
+		h.stop = SingleCluster
+		if h.merges == 0 {
+			h.stop = TrivialInput
+		}
+		return false
+	}
+	if h.CacheDistances && h.distCache == nil {
+		if h.exceeded(LimitCacheBytes, h.Limits.MaxCacheBytes, h.CacheStorage.bytes()*n*(n-1)/2) {
+			return h.stopped(LimitExceeded)
+		}
+		switch {
+		case h.CacheStorage == CacheUint16:
+			lo, hi := h.CacheRange[0], h.CacheRange[1]
+			if !(lo < hi) || math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+				panic(fmt.Sprintf("clustering: invalid CacheRange %v for CacheUint16", h.CacheRange))
+			}
+			h.distCache = newQuantizedCache(n, lo, hi)
+		case h.CacheStorage == CacheFloat32:
+			h.distCache = newFloat32Cache(n)
+		default:
+			h.distCache = newScoreCache(n)
+		}
+	}
+	if h.history == nil {
+		h.history = newHistory(h.ClusterSet)
+	}
+
+	var cands []CandidateChecker
+	var scores []float64
+	if !h.scored {
+		cands = candidateCheckers(h.Checker)
 	}
 
+	conn, _ := h.ClusterSet.(ConnectedClusterSet)
 	h.ClusterSet.EachCluster(-1, func(c1 int) {
+		if h.isFrozen(c1) {
+			return
+		}
 		h.ClusterSet.EachCluster(c1, func(c2 int) {
+			if h.err != nil || h.isFrozen(c2) {
+				return
+			}
+			if h.VetoPairs != nil && h.VetoPairs(c1, c2) {
+				return
+			}
+			if conn != nil && !conn.Connected(c1, c2) {
+				return
+			}
 			score := h.dist(c1, c2)
+			if h.exceeded(LimitDistanceEvals, h.Limits.MaxDistanceEvals, h.distEvals) {
+				return
+			}
+			if cands != nil {
+				scores = append(scores, score)
+			}
+			worstScore = math.Max(worstScore, score)
 			if score < bestScore {
 				bestScore = score
 				bestPair = []int{c1, c2}
@@ -229,18 +567,193 @@ func (h *HClustering) MergeNext() bool {
 		})
 	})
 
+	if h.err != nil {
+		return h.stopped(LimitExceeded)
+	}
+	for _, cc := range cands {
+		cc.CheckCandidates(scores)
+	}
 	if len(bestPair) == 0 || bestScore == math.MaxFloat64 {
-		return false
+		return h.stopped(NoCandidates)
+	}
+	if h.merges == 0 && n > 2 && bestScore == worstScore {
+		h.identical = true
+	}
+
+	if h.MonotonicHeights {
+		bestScore = math.Max(bestScore, h.history.height(bestPair[0]))
+		bestScore = math.Max(bestScore, h.history.height(bestPair[1]))
+	}
+	h.scored = true
+	h.finalScore = bestScore
+	h.finalPair = [2]int{bestPair[0], bestPair[1]}
+
+	if !h.check(bestPair[0], bestPair[1], bestScore) {
+		return h.stopped(CheckerStopped)
+	}
+
+	if h.exceeded(LimitMerges, h.Limits.MaxMerges, h.merges+1) {
+		return h.stopped(LimitExceeded)
+	}
+
+	h.merge(bestPair[0], bestPair[1], bestScore)
+	return true
+}
+
+// check consults the Checker about merging clusters i and j, using the
+// MergeChecker interface when it is available.
+func (h *HClustering) check(i, j int, score float64) bool {
+	mc, ok := h.Checker.(MergeChecker)
+	if !ok {
+		return h.Checker.Check(h.ClusterSet, i, j, score)
 	}
+	return mc.CheckMerge(h.ClusterSet, PendingMerge{
+		I: i, J: j, Score: score,
+		SizeI:  clusterSize(h.ClusterSet, i),
+		SizeJ:  clusterSize(h.ClusterSet, j),
+		Merges: h.merges,
+	})
+}
 
-	if !h.Checker.Check(h.ClusterSet, bestPair[0], bestPair[1], bestScore) {
+// checkMerge consults chk about the pending merge, using the MergeChecker
+// interface when it is available.
+func checkMerge(chk Checker, clusters ClusterSet, m PendingMerge) bool {
+	if mc, ok := chk.(MergeChecker); ok {
+		return mc.CheckMerge(clusters, m)
+	}
+	return chk.Check(clusters, m.I, m.J, m.Score)
+}
+
+// checkerWrapper is implemented by Checkers that wrap other Checkers.
+type checkerWrapper interface {
+	inner() []Checker
+}
+
+// candidateCheckers returns every CandidateChecker within chk, including
+// those wrapped by combinators.
+func candidateCheckers(chk Checker) []CandidateChecker {
+	var res []CandidateChecker
+	if cc, ok := chk.(CandidateChecker); ok {
+		res = append(res, cc)
+	}
+	if w, ok := chk.(checkerWrapper); ok {
+		for _, c := range w.inner() {
+			res = append(res, candidateCheckers(c)...)
+		}
+	}
+	return res
+}
+
+// stopped records the reason clustering stopped, and returns false.
+func (h *HClustering) stopped(r StopReason) bool {
+	h.stop = r
+	return false
+}
+
+// RemoveItem removes the item from the ClusterSet, which must implement
+// RemovableClusterSet, and repairs the cached scores of its cluster so that
+// clustering can continue. It returns false if the item was not removed. The
+// item is also left out of the Dendrogram, including the merges made before
+// its removal, so that cuts and exports only contain the remaining items.
+func (h *HClustering) RemoveItem(item ClusterItem) bool {
+	rcs, ok := h.ClusterSet.(RemovableClusterSet)
+	if !ok {
+		return false
+	}
+	if h.history == nil {
+		h.history = newHistory(h.ClusterSet)
+	}
+	n := h.ClusterSet.Count()
+	cluster, empty := rcs.RemoveItem(item)
+	if cluster < 0 {
 		return false
 	}
+	if h.history.removed == nil {
+		h.history.removed = make(map[ClusterItem]struct{})
+	}
+	h.history.removed[item] = struct{}{}
+	if !empty {
+		h.history.sizes[cluster]--
+		for k := 0; h.distCache != nil && k < n; k++ {
+			h.distCache.clear(cluster, k)
+		}
+		return true
+	}
 
-	if h.distCache == nil {
-		h.ClusterSet.Merge(bestPair[0], bestPair[1])
-	} else {
-		h.mergeAndUpdateAll(bestPair[0], bestPair[1])
+	h.history.remove(cluster)
+	if h.distCache != nil {
+		if cluster != n-1 {
+			h.distCache.move(n-1, cluster, n)
+		}
+		h.distCache.truncate(n - 1)
 	}
 	return true
 }
+
+// Freeze exempts the cluster from any further merging, e.g. once an operator
+// has confirmed the cluster is correct. Clustering continues to refine the
+// remaining clusters. Frozen clusters keep their status even if their cluster
+// id changes due to other merges.
+func (h *HClustering) Freeze(cluster int) {
+	if h.history == nil {
+		h.history = newHistory(h.ClusterSet)
+	}
+	if h.frozen == nil {
+		h.frozen = make(map[int]struct{})
+	}
+	h.frozen[h.history.nodes[cluster]] = struct{}{}
+}
+
+// Unfreeze allows a previously frozen cluster to be merged again.
+func (h *HClustering) Unfreeze(cluster int) {
+	if h.history == nil || h.frozen == nil {
+		return
+	}
+	delete(h.frozen, h.history.nodes[cluster])
+}
+
+// IsFrozen returns true if the cluster has been frozen.
+func (h *HClustering) IsFrozen(cluster int) bool {
+	return h.isFrozen(cluster)
+}
+
+func (h *HClustering) isFrozen(cluster int) bool {
+	if len(h.frozen) == 0 {
+		return false
+	}
+	_, ok := h.frozen[h.history.nodes[cluster]]
+	return ok
+}
+
+// Dendrogram returns the merge history recorded so far. The leaves of the
+// dendrogram are the clusters that existed when clustering started.
+func (h *HClustering) Dendrogram() *Dendrogram {
+	if h.history == nil {
+		h.history = newHistory(h.ClusterSet)
+	}
+	leaves, merges, _ := h.history.tree()
+	return &Dendrogram{
+		Leaves: leaves,
+		Merges: merges,
+	}
+}
+
+// DistanceMatrix returns the current linkage score between every pair of
+// clusters, indexed by cluster id. It may be called at any point, including
+// after clustering has stopped, to inspect how far apart the clusters are.
+// When CacheDistances is enabled the cached scores are used where available.
+func (h *HClustering) DistanceMatrix() [][]float64 {
+	n := h.ClusterSet.Count()
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+	}
+	h.ClusterSet.EachCluster(-1, func(c1 int) {
+		h.ClusterSet.EachCluster(c1, func(c2 int) {
+			d := h.dist(c1, c2)
+			m[c1][c2] = d
+			m[c2][c1] = d
+		})
+	})
+	return m
+}
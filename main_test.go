@@ -0,0 +1,657 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// testDistanceMap builds a DistanceMap of n items placed at deterministic
+// pseudo-random positions on a line.
+func testDistanceMap(n int) DistanceMap {
+	pos := make([]float64, n)
+	x := uint32(12345)
+	for i := range pos {
+		x = x*1103515245 + 12345
+		pos[i] = float64(x%10000) / 10000.0
+	}
+	d := make(DistanceMap)
+	for i := 0; i < n; i++ {
+		a := fmt.Sprint("item", i)
+		d[a] = make(map[ClusterItem]float64)
+		for j := i + 1; j < n; j++ {
+			d[a][fmt.Sprint("item", j)] = math.Abs(pos[i] - pos[j])
+		}
+	}
+	return d
+}
+
+// testLinePositions are the positions on a line of the items "a", "b", "c"
+// and "d" used by testLine and testLineMatrix.
+var testLinePositions = []float64{0, 1, 3, 7}
+
+// testLine returns the DistanceMap of the items at testLinePositions.
+func testLine() DistanceMap {
+	d := make(DistanceMap)
+	for i := 0; i < len(testLinePositions)-1; i++ {
+		a := string(rune('a' + i))
+		d[a] = make(map[ClusterItem]float64)
+		for j := i + 1; j < len(testLinePositions); j++ {
+			d[a][string(rune('a'+j))] = testLinePositions[j] - testLinePositions[i]
+		}
+	}
+	return d
+}
+
+// testLineMatrix returns the condensed distance matrix of the items at
+// testLinePositions.
+func testLineMatrix() []float64 {
+	var res []float64
+	for i := range testLinePositions {
+		for j := i + 1; j < len(testLinePositions); j++ {
+			res = append(res, testLinePositions[j]-testLinePositions[i])
+		}
+	}
+	return res
+}
+
+func clusterSizes(cs ClusterSet) map[int]int {
+	sizes := make(map[int]int)
+	cs.EachCluster(-1, func(cluster int) {
+		n := 0
+		cs.EachItem(cluster, func(x ClusterItem) { n++ })
+		sizes[n]++
+	})
+	return sizes
+}
+
+// shrinkingLinkage is a non-monotone linkage whose score shrinks as clusters
+// grow, so that merges can score lower than the merges before them.
+type shrinkingLinkage struct {
+	min float64
+	n   int
+}
+
+func (l *shrinkingLinkage) Reset() {
+	l.min, l.n = math.Inf(1), 0
+}
+
+func (l *shrinkingLinkage) Put(item1, item2 ClusterItem, dist float64) {
+	l.min = math.Min(l.min, dist)
+	l.n++
+}
+
+func (l *shrinkingLinkage) Get() float64 {
+	return l.min / float64(l.n)
+}
+
+func (l *shrinkingLinkage) LWParams() []float64 {
+	return nil
+}
+
+func TestMonotonicHeights(t *testing.T) {
+	// {a b} joins c at 1.6/2, below the first merge height
+	data := DistanceMap{
+		"a": {"b": 1, "c": 1.6},
+		"b": {"c": 1.6},
+	}
+	for _, monotonic := range []bool{false, true} {
+		h := &HClustering{
+			ClusterSet:       NewDistanceMapClusterSet(data),
+			Checker:          Threshold(10),
+			LinkageType:      &shrinkingLinkage{},
+			MonotonicHeights: monotonic,
+		}
+		for h.MergeNext() {
+		}
+		m := h.Dendrogram().Merges
+		want := 0.8
+		if monotonic {
+			want = 1
+		}
+		if len(m) != 2 || m[0].Height != 1 || math.Abs(m[1].Height-want) > 1e-12 {
+			t.Errorf("monotonic=%v: unexpected merges %+v", monotonic, m)
+		}
+	}
+}
+
+// centroidDistanceSet scores clusters of float64 items directly by the
+// distance between their means, or falls back to the LinkageType if direct is
+// false.
+type centroidDistanceSet struct {
+	ClusterSet
+	direct bool
+}
+
+func (c *centroidDistanceSet) mean(cluster int) float64 {
+	sum, n := 0.0, 0
+	c.EachItem(cluster, func(x ClusterItem) {
+		sum += x.(float64)
+		n++
+	})
+	return sum / float64(n)
+}
+
+func (c *centroidDistanceSet) ClusterDistance(c1, c2 int) (float64, bool) {
+	if !c.direct {
+		return 0, false
+	}
+	return math.Abs(c.mean(c1) - c.mean(c2)), true
+}
+
+func TestClusterDistanceSet(t *testing.T) {
+	// items on a line at their own positions
+	points := DistanceMap{
+		0.0: {1.0: 1, 5.0: 5, 12.0: 12},
+		1.0: {5.0: 4, 12.0: 11},
+		5.0: {12.0: 7},
+	}
+	for _, cache := range []bool{false, true} {
+		for _, direct := range []bool{false, true} {
+			h := &HClustering{
+				ClusterSet:     &centroidDistanceSet{NewDistanceMapClusterSet(points), direct},
+				Checker:        Threshold(100),
+				LinkageType:    CompleteLinkage(),
+				CacheDistances: cache,
+			}
+			h.Run()
+			// cached scores must not be updated using the complete linkage
+			// Lance-Williams form when distances are direct
+			var heights []float64
+			for _, m := range h.Dendrogram().Merges {
+				heights = append(heights, m.Height)
+			}
+			want := "[1 5 12]"
+			if direct {
+				want = "[1 4.5 10]"
+			}
+			if s := fmt.Sprint(heights); s != want {
+				t.Errorf("cache=%v direct=%v: expected merge heights %s, got %s", cache, direct, want, s)
+			}
+		}
+	}
+}
+
+func TestCachedDistances(t *testing.T) {
+	linkages := map[string]func() LinkageType{
+		"complete": CompleteLinkage,
+		"single":   SingleLinkage,
+		"average":  AverageLinkage,
+		"geomean":  GeometricMeanLinkage,
+	}
+	for name, lt := range linkages {
+		data := testDistanceMap(30)
+
+		plain := NewDistanceMapClusterSet(data)
+		Cluster(plain, MaxClusters(4), lt())
+
+		cached := NewDistanceMapClusterSet(data)
+		h := &HClustering{
+			ClusterSet:     cached,
+			Checker:        MaxClusters(4),
+			LinkageType:    lt(),
+			CacheDistances: true,
+		}
+		for h.MergeNext() {
+		}
+
+		if cached.Count() != 4 {
+			t.Errorf("%s: cached clustering should stop at 4 clusters, got %d", name, cached.Count())
+		}
+		if fmt.Sprint(clusterSizes(plain)) != fmt.Sprint(clusterSizes(cached)) {
+			t.Errorf("%s: cached clustering differs: %v vs %v", name,
+				clusterSizes(plain), clusterSizes(cached))
+		}
+
+		m := h.DistanceMatrix()
+		if len(m) != 4 || m[0][1] != m[1][0] || m[0][0] != 0.0 {
+			t.Errorf("%s: unexpected distance matrix %v", name, m)
+		}
+		lt := h.linkage(0, 1)
+		if math.Abs(m[0][1]-lt) > 1e-9 {
+			t.Errorf("%s: cached distance %f differs from recomputed %f", name, m[0][1], lt)
+		}
+	}
+}
+
+func TestQuantizedCache(t *testing.T) {
+	data := testDistanceMap(30)
+	plain := NewDistanceMapClusterSet(data)
+	Cluster(plain, MaxClusters(4), CompleteLinkage())
+
+	quant := NewDistanceMapClusterSet(data)
+	h := &HClustering{
+		ClusterSet:     quant,
+		Checker:        MaxClusters(4),
+		LinkageType:    CompleteLinkage(),
+		CacheDistances: true,
+		CacheStorage:   CacheUint16,
+		CacheRange:     [2]float64{0, 1},
+	}
+	h.Run()
+
+	if fmt.Sprint(clusterSizes(plain)) != fmt.Sprint(clusterSizes(quant)) {
+		t.Errorf("quantized clustering differs: %v vs %v", clusterSizes(plain), clusterSizes(quant))
+	}
+	if len(h.distCache.q) == 0 || h.distCache.vals != nil {
+		t.Errorf("expected quantized cache storage")
+	}
+	v, _ := h.distCache.get(0, 1)
+	if math.Abs(v-h.linkage(0, 1)) > 1e-4 {
+		t.Errorf("quantized distance %f differs from recomputed %f", v, h.linkage(0, 1))
+	}
+}
+
+func TestQuantizedCacheInf(t *testing.T) {
+	s := newQuantizedCache(3, 0, 1)
+	s.set(0, 1, math.Inf(1))
+	s.set(0, 2, 5)
+	if v, ok := s.get(0, 1); !ok || !math.IsInf(v, 1) {
+		t.Errorf("expected +Inf to be kept, got %g", v)
+	}
+	if v, _ := s.get(0, 2); v != 1 {
+		t.Errorf("expected finite score to be clamped to 1, got %g", v)
+	}
+
+	// the unlinked pairs must not become mergeable at the top of the range
+	g := NewKNNGraphClusterSet([][]Neighbor{{{1, 0.5}}, {}, {{3, 0.5}}, {}})
+	h := &HClustering{
+		ClusterSet:     g,
+		Checker:        Threshold(100),
+		LinkageType:    AverageLinkage(),
+		CacheDistances: true,
+		CacheStorage:   CacheUint16,
+		CacheRange:     [2]float64{0, 1},
+	}
+	h.Run()
+	if g.Count() != 2 || h.Result().StopReason != NoCandidates {
+		t.Errorf("expected 2 unlinked clusters, got %d (%v)", g.Count(), h.Result().StopReason)
+	}
+
+	// an invalid range is never silently replaced by exact storage
+	for _, r := range [][2]float64{{}, {1, 0}, {0, math.Inf(1)}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic for CacheRange %v", r)
+				}
+			}()
+			h := &HClustering{
+				ClusterSet:     NewDistanceMapClusterSet(testDistanceMap(4)),
+				Checker:        Threshold(100),
+				LinkageType:    AverageLinkage(),
+				CacheDistances: true,
+				CacheStorage:   CacheUint16,
+				CacheRange:     r,
+			}
+			h.Run()
+		}()
+	}
+}
+
+func TestFrozenClusters(t *testing.T) {
+	d := NewDistanceMapClusterSet(testLine())
+	h := &HClustering{
+		ClusterSet:  d,
+		Checker:     Threshold(100),
+		LinkageType: SingleLinkage(),
+	}
+
+	// merge a+b, then freeze it
+	h.MergeNext()
+	frozen := -1
+	d.EachCluster(-1, func(cluster int) {
+		n := 0
+		d.EachItem(cluster, func(x ClusterItem) { n++ })
+		if n == 2 {
+			frozen = cluster
+		}
+	})
+	h.Freeze(frozen)
+
+	for h.MergeNext() {
+	}
+	if d.Count() != 2 {
+		t.Errorf("frozen cluster should not be merged, got %d clusters", d.Count())
+	}
+	if fmt.Sprint(clusterSizes(d)) != "map[2:2]" {
+		t.Errorf("expected two clusters of two items, got %v", clusterSizes(d))
+	}
+}
+
+func TestRemoveItem(t *testing.T) {
+	// items on a line at positions 0, 1, 3, 7 and 20
+	d := NewDistanceMapClusterSet(DistanceMap{
+		"a": {"b": 1, "c": 3, "d": 7, "x": 20},
+		"b": {"c": 2, "d": 6, "x": 19},
+		"c": {"d": 4, "x": 17},
+		"d": {"x": 13},
+	})
+	h := &HClustering{
+		ClusterSet:     d,
+		Checker:        MaxClusters(2),
+		LinkageType:    CompleteLinkage(),
+		CacheDistances: true,
+	}
+	h.MergeNext()
+	if !h.RemoveItem("x") || h.RemoveItem("x") || d.Count() != 3 {
+		t.Fatalf("expected outlier to be removed once, leaving 3 clusters")
+	}
+	if !h.RemoveItem("b") || d.Count() != 3 {
+		t.Fatalf("removing from a merged cluster should keep 3 clusters")
+	}
+
+	h.Run()
+	if fmt.Sprint(clusterSizes(d)) != "map[1:1 2:1]" {
+		t.Errorf("expected clusters {a c} and {d}, got %v", clusterSizes(d))
+	}
+
+	// the removed items are left out of the tree, so {a b} becomes {a}
+	tree := h.Dendrogram()
+	if len(tree.Leaves) != 3 || len(tree.Merges) != 1 || tree.Merges[0].Size != 2 {
+		t.Errorf("expected leaves a, c and d with one merge, got %v %+v", tree.Leaves, tree.Merges)
+	}
+	cut := tree.CutK(2)
+	if _, ok := cut["x"]; ok || len(cut) != 3 || cut["a"] != cut["c"] || cut["a"] == cut["d"] {
+		t.Errorf("expected cut {a c} {d} without removed items, got %v", cut)
+	}
+	items, _ := tree.Cophenetic()
+	if len(items) != 3 {
+		t.Errorf("expected 3 items in the cophenetic matrix, got %v", items)
+	}
+	for i := 0; i < d.Count(); i++ {
+		for j := i + 1; j < d.Count(); j++ {
+			if v, ok := h.distCache.get(i, j); ok && v != h.linkage(i, j) {
+				t.Errorf("cached score %g differs from recomputed %g", v, h.linkage(i, j))
+			}
+		}
+	}
+}
+
+func TestVetoPairs(t *testing.T) {
+	d := NewDistanceMapClusterSet(testLine())
+	has := func(cluster int, item ClusterItem) bool {
+		found := false
+		d.EachItem(cluster, func(x ClusterItem) { found = found || x == item })
+		return found
+	}
+	h := &HClustering{
+		ClusterSet:  d,
+		Checker:     Threshold(100),
+		LinkageType: SingleLinkage(),
+		VetoPairs: func(c1, c2 int) bool {
+			return (has(c1, "b") && has(c2, "c")) || (has(c1, "c") && has(c2, "b"))
+		},
+	}
+	h.Run()
+
+	if h.Result().StopReason != NoCandidates {
+		t.Errorf("expected no candidates to remain, got %v", h.Result().StopReason)
+	}
+	if fmt.Sprint(clusterSizes(d)) != "map[2:2]" {
+		t.Errorf("expected two clusters of two items, got %v", clusterSizes(d))
+	}
+}
+
+func TestMemoStore(t *testing.T) {
+	memo := NewMemoStore(0)
+	for run := 0; run < 2; run++ {
+		h := &HClustering{
+			ClusterSet:  NewDistanceMapClusterSet(testDistanceMap(10)),
+			Checker:     MaxClusters(3),
+			LinkageType: AverageLinkage(),
+			Memo:        memo,
+		}
+		for h.MergeNext() {
+		}
+	}
+	st := memo.Stats()
+	if st.Entries != 45 || st.Misses != 45 || st.HitRate() < 0.5 {
+		t.Errorf("unexpected memo stats after 2 runs: %+v", st)
+	}
+
+	memo = NewMemoStore(2)
+	memo.Put("a", "b", 1)
+	memo.Put("a", "c", 2)
+	memo.Put("b", "c", 3)
+	if _, ok := memo.Get("b", "a"); ok {
+		t.Errorf("least recently used pair should have been evicted")
+	}
+	if d, ok := memo.Get("c", "b"); !ok || d != 3 {
+		t.Errorf("pair should be found in either order")
+	}
+
+	// both orders share a single entry
+	memo = NewMemoStore(0)
+	memo.Put("a", "b", 1)
+	memo.Put("b", "a", 2)
+	if d, ok := memo.Get("a", "b"); !ok || d != 2 || memo.Stats().Entries != 1 {
+		t.Errorf("expected a single entry with the last distance, got %v and %+v", d, memo.Stats())
+	}
+
+	// the missing edges of a graph are never scored, even once the edges
+	// are in the memo
+	memo = NewMemoStore(0)
+	want := mergeHeights(testGraph())
+	for run := 0; run < 2; run++ {
+		h := &HClustering{
+			ClusterSet:  testGraph(),
+			Checker:     Threshold(100),
+			LinkageType: AverageLinkage(),
+			Memo:        memo,
+		}
+		h.Run()
+		var got []float64
+		for _, m := range h.Dendrogram().Merges {
+			got = append(got, m.Height)
+		}
+		sameHeights(t, fmt.Sprint("graph run ", run), want, got)
+	}
+	if memo.Stats().Entries != 5 {
+		t.Errorf("expected only the 5 edges in the memo, got %+v", memo.Stats())
+	}
+}
+
+func TestCachedClusterSet(t *testing.T) {
+	calls := 0
+	inner := NewDistanceMapClusterSet(testDistanceMap(10))
+	cs := CachedClusterSet(&countingClusterSet{inner, &calls})
+	Cluster(cs, MaxClusters(2), AverageLinkage())
+
+	if cs.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", cs.Count())
+	}
+	if calls != 45 || cs.Stats().Misses != 45 || cs.Stats().Hits == 0 {
+		t.Errorf("expected each of 45 pairs computed once, got %d calls and %+v", calls, cs.Stats())
+	}
+}
+
+func TestCachedClusterSetForwarding(t *testing.T) {
+	dm, weights := testWeightedDistanceMap(10)
+	sameHeights(t, "weighted", mergeHeights(NewWeightedDistanceMapClusterSet(dm, weights)),
+		mergeHeights(CachedClusterSet(NewWeightedDistanceMapClusterSet(dm, weights))))
+
+	// non-edges are never scored, even once the edges are memoized
+	cs := CachedClusterSet(testGraph())
+	sameHeights(t, "graph", mergeHeights(testGraph()), mergeHeights(cs))
+	if cs.Count() != 2 || cs.Stats().Entries != 5 {
+		t.Errorf("expected 2 clusters and the 5 edges memoized, got %d and %+v", cs.Count(), cs.Stats())
+	}
+
+	// cluster distances are not memoized
+	points := DistanceMap{
+		0.0: {1.0: 1, 5.0: 5, 12.0: 12},
+		1.0: {5.0: 4, 12.0: 11},
+		5.0: {12.0: 7},
+	}
+	cs = CachedClusterSet(&centroidDistanceSet{NewDistanceMapClusterSet(points), true})
+	Cluster(cs, MaxClusters(2), AverageLinkage())
+	if cs.Count() != 2 || cs.Stats().Entries != 0 {
+		t.Errorf("expected cluster distances to bypass the memo, got %+v", cs.Stats())
+	}
+}
+
+// testWeightedDistanceMap returns testDistanceMap(n) with item weights.
+func testWeightedDistanceMap(n int) (DistanceMap, map[ClusterItem]float64) {
+	weights := map[ClusterItem]float64{}
+	for k := 0; k < n; k++ {
+		weights[fmt.Sprint("item", k)] = float64(1 + k%3)
+	}
+	return testDistanceMap(n), weights
+}
+
+// testGraph returns a path 0-1-2-3 plus a distant but directly connected pair
+// 4-5, where 3 and 4 are not connected.
+func testGraph() *GraphClusterSet {
+	g := NewGraphClusterSet(6)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 2)
+	g.AddEdge(0, 3, 10)
+	g.AddEdge(4, 5, 5)
+	return g
+}
+
+// mergeHeights clusters cs completely with average linkage, and returns the
+// height of every merge.
+func mergeHeights(cs ClusterSet) []float64 {
+	h := &HClustering{
+		ClusterSet:  cs,
+		Checker:     Threshold(100),
+		LinkageType: AverageLinkage(),
+	}
+	h.Run()
+	var res []float64
+	for _, m := range h.Dendrogram().Merges {
+		res = append(res, m.Height)
+	}
+	return res
+}
+
+// sameHeights reports an error unless the merge heights a and b are equal,
+// up to rounding.
+func sameHeights(t *testing.T, name string, a, b []float64) {
+	t.Helper()
+	if len(a) != len(b) {
+		t.Errorf("%s: expected %v, got %v", name, a, b)
+		return
+	}
+	for k := range a {
+		if math.Abs(a[k]-b[k]) > 1e-12 {
+			t.Errorf("%s: expected %v, got %v", name, a, b)
+			return
+		}
+	}
+}
+
+func TestCachedDistanceEvals(t *testing.T) {
+	for name, lt := range map[string]LinkageType{"average": AverageLinkage(), "complete": CompleteLinkage()} {
+		var calls int
+		h := &HClustering{
+			ClusterSet:     &countingClusterSet{NewDistanceMapClusterSet(testDistanceMap(20)), &calls},
+			Checker:        MaxClusters(1),
+			LinkageType:    lt,
+			CacheDistances: true,
+		}
+		h.Run()
+		// only the first pass computes distances, later scores are updated
+		if calls != 190 {
+			t.Errorf("%s: expected 190 distance calls, got %d", name, calls)
+		}
+	}
+}
+
+type countingClusterSet struct {
+	ClusterSet
+	calls *int
+}
+
+func (c *countingClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	*c.calls++
+	return c.ClusterSet.Distance(c1, c2, item1, item2)
+}
+
+func TestLimits(t *testing.T) {
+	h := &HClustering{
+		ClusterSet:  NewDistanceMapClusterSet(testDistanceMap(10)),
+		Checker:     Threshold(100),
+		LinkageType: AverageLinkage(),
+		Limits:      Limits{MaxMerges: 3},
+	}
+	err := h.Run()
+	if le, ok := err.(*LimitError); !ok || le.Limit != LimitMerges || h.ClusterSet.Count() != 7 {
+		t.Errorf("expected merge limit to stop at 7 clusters, got %v with %d", err, h.ClusterSet.Count())
+	}
+
+	h = &HClustering{
+		ClusterSet:  NewDistanceMapClusterSet(testDistanceMap(10)),
+		Checker:     Threshold(100),
+		LinkageType: AverageLinkage(),
+		Limits:      Limits{MaxDistanceEvals: 100},
+	}
+	err = h.Run()
+	if le, ok := err.(*LimitError); !ok || le.Limit != LimitDistanceEvals {
+		t.Errorf("expected distance evaluation limit, got %v", err)
+	}
+
+	h = &HClustering{
+		ClusterSet:     NewDistanceMapClusterSet(testDistanceMap(10)),
+		Checker:        Threshold(100),
+		LinkageType:    AverageLinkage(),
+		CacheDistances: true,
+		Limits:         Limits{MaxCacheBytes: 100},
+	}
+	err = h.Run()
+	if le, ok := err.(*LimitError); !ok || le.Limit != LimitCacheBytes || h.ClusterSet.Count() != 10 {
+		t.Errorf("expected cache limit before any merges, got %v", err)
+	}
+}
+
+func TestTrivialInput(t *testing.T) {
+	for _, data := range []DistanceMap{nil, {"a": {}}} {
+		h := &HClustering{
+			ClusterSet:  NewDistanceMapClusterSet(data),
+			Checker:     Threshold(1.0),
+			LinkageType: CompleteLinkage(),
+		}
+		if err := h.Run(); err != nil || h.Result().StopReason != TrivialInput {
+			t.Errorf("expected TrivialInput for %v, got %v", data, h.Result())
+		}
+	}
+
+	h := &HClustering{
+		ClusterSet: NewDistanceMapClusterSet(DistanceMap{
+			"a": {"b": 0.5, "c": 0.5},
+			"b": {"c": 0.5},
+		}),
+		Checker:     Threshold(1.0),
+		LinkageType: CompleteLinkage(),
+	}
+	h.Run()
+	r := h.Result()
+	if r.StopReason != SingleCluster || !r.IdenticalDistances {
+		t.Errorf("expected single cluster with identical distances, got %+v", r)
+	}
+}
+
+func TestPrecisionCheck(t *testing.T) {
+	h := &HClustering{
+		ClusterSet:     NewDistanceMapClusterSet(testDistanceMap(20)),
+		Checker:        MaxClusters(5),
+		LinkageType:    AverageLinkage(),
+		CacheDistances: true,
+		Precision:      PrecisionCheck{Every: 2, Sample: 10, Tolerance: 1e-9},
+	}
+	h.Run()
+	if h.ClusterSet.Count() != 5 {
+		t.Errorf("expected 5 clusters, got %d", h.ClusterSet.Count())
+	}
+
+	// corrupt the cache, and make sure it is rebuilt
+	h.distCache.set(0, 1, 1000.0)
+	h.Precision.Sample = 100
+	h.verifyCache()
+	if _, ok := h.distCache.get(0, 1); ok {
+		t.Errorf("drifted cache should have been reset")
+	}
+}
@@ -0,0 +1,149 @@
+package clustering
+
+import "math"
+
+// ClusterMatch is an optimal one-to-one assignment between the clusters of two
+// partitions of (mostly) the same items.
+type ClusterMatch struct {
+	// Mapping maps cluster ids in the second partition to the matched cluster
+	// ids in the first partition.
+	Mapping map[int]int
+
+	// Overlap maps cluster ids in the second partition to the number of items
+	// shared with the matched cluster in the first partition.
+	Overlap map[int]int
+
+	// UnmatchedFirst and UnmatchedSecond are the cluster ids in each partition
+	// without a match (i.e. clusters that disappeared or newly appeared).
+	UnmatchedFirst, UnmatchedSecond []int
+}
+
+// MatchClusters aligns the cluster ids of two clustering runs by finding the
+// one-to-one assignment that maximizes the total number of shared members,
+// using the Hungarian algorithm. Clusters that share no members are never
+// matched. This allows downstream systems to keep stable cluster identities
+// when the same data is re-clustered.
+//
+// Runs in O(n^3) time where n is the larger number of clusters.
+func MatchClusters(first, second ClusterSet) *ClusterMatch {
+	where := make(map[ClusterItem]int)
+	first.EachCluster(-1, func(cluster int) {
+		first.EachItem(cluster, func(x ClusterItem) {
+			where[x] = cluster
+		})
+	})
+
+	n1, n2 := first.Count(), second.Count()
+	overlap := make([][]int, n2)
+	for i := range overlap {
+		overlap[i] = make([]int, n1)
+	}
+	second.EachCluster(-1, func(cluster int) {
+		second.EachItem(cluster, func(x ClusterItem) {
+			if c1, ok := where[x]; ok {
+				overlap[cluster][c1]++
+			}
+		})
+	})
+
+	n := n1
+	if n2 > n {
+		n = n2
+	}
+	cost := make([][]float64, n)
+	for i := range cost {
+		cost[i] = make([]float64, n)
+		if i >= n2 {
+			continue
+		}
+		for j := 0; j < n1; j++ {
+			cost[i][j] = -float64(overlap[i][j])
+		}
+	}
+
+	m := &ClusterMatch{
+		Mapping: make(map[int]int),
+		Overlap: make(map[int]int),
+	}
+	matched := make([]bool, n1)
+	for i, j := range hungarian(cost) {
+		if i >= n2 {
+			continue
+		}
+		if j >= n1 || overlap[i][j] == 0 {
+			m.UnmatchedSecond = append(m.UnmatchedSecond, i)
+			continue
+		}
+		m.Mapping[i] = j
+		m.Overlap[i] = overlap[i][j]
+		matched[j] = true
+	}
+	for j, ok := range matched {
+		if !ok {
+			m.UnmatchedFirst = append(m.UnmatchedFirst, j)
+		}
+	}
+	return m
+}
+
+// hungarian solves the square assignment problem for the given cost matrix,
+// returning the column assigned to each row such that the total cost is
+// minimized.
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+	// potentials and assignments are 1-indexed, column 0 is a sentinel
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	p := make([]int, n+1)
+	way := make([]int, n+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for p[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.Inf(1), 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	res := make([]int, n)
+	for j := 1; j <= n; j++ {
+		if p[j] != 0 {
+			res[p[j]-1] = j - 1
+		}
+	}
+	return res
+}
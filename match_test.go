@@ -0,0 +1,40 @@
+package clustering
+
+import "testing"
+
+type testPartition struct {
+	clusterList
+}
+
+func (testPartition) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	return 0
+}
+
+func TestMatchClusters(t *testing.T) {
+	a := &testPartition{clusterList{[][]ClusterItem{
+		{1, 2, 3}, {4, 5}, {6, 7, 8}, {9},
+	}}}
+	b := &testPartition{clusterList{[][]ClusterItem{
+		{6, 7}, {1, 2, 4}, {3, 5}, {8, 10},
+	}}}
+
+	m := MatchClusters(a, b)
+	want := map[int]int{0: 2, 1: 0, 2: 1}
+	if len(m.Mapping) != len(want) {
+		t.Fatalf("expected %d matches, got %v", len(want), m.Mapping)
+	}
+	for k, v := range want {
+		if m.Mapping[k] != v {
+			t.Errorf("expected cluster %d to match %d, got %v", k, v, m.Mapping)
+		}
+	}
+	if m.Overlap[1] != 2 {
+		t.Errorf("expected overlap of 2, got %d", m.Overlap[1])
+	}
+	if len(m.UnmatchedFirst) != 1 || m.UnmatchedFirst[0] != 3 {
+		t.Errorf("expected cluster 3 unmatched in first, got %v", m.UnmatchedFirst)
+	}
+	if len(m.UnmatchedSecond) != 1 || m.UnmatchedSecond[0] != 3 {
+		t.Errorf("expected cluster 3 unmatched in second, got %v", m.UnmatchedSecond)
+	}
+}
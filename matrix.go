@@ -0,0 +1,128 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+)
+
+// MatrixClusterSet is a ClusterSet backed by a condensed distance matrix, i.e.
+// the upper triangle of a symmetric matrix flattened row by row, as produced by
+// SciPy's scipy.spatial.distance.pdist. Lookups are O(1) slice indexes, and the
+// memory used is a small fraction of an equivalent DistanceMap.
+//
+// The items enumerated by EachItem are int indexes into the labels, use Label
+// to retrieve the original label for an item.
+type MatrixClusterSet struct {
+	clusterList
+
+	labels []string
+
+	// exactly one of the condensed matrices is used
+	condensed   []float64
+	condensed32 []float32
+	quantized   []uint16
+	scale       float64
+}
+
+// NewMatrixClusterSet creates a ClusterSet with one initial cluster for each
+// label, where the distance between labels i < j is stored in condensed at
+// index n*i - i*(i+1)/2 + (j-i-1). It panics if condensed does not contain
+// exactly n*(n-1)/2 distances.
+func NewMatrixClusterSet(labels []string, condensed []float64) *MatrixClusterSet {
+	m := newMatrixClusterSet(labels, len(condensed))
+	m.condensed = condensed
+	return m
+}
+
+// NewMatrix32ClusterSet is like NewMatrixClusterSet, but uses a single
+// precision condensed matrix, halving the memory used for large problems.
+func NewMatrix32ClusterSet(labels []string, condensed []float32) *MatrixClusterSet {
+	m := newMatrixClusterSet(labels, len(condensed))
+	m.condensed32 = condensed
+	return m
+}
+
+// NewQuantizedMatrixClusterSet is like NewMatrixClusterSet, but uses a
+// condensed matrix of distances quantized to uint16, where each distance is
+// condensed[x]*scale. This quarters the memory used for massive similarity
+// matrices (e.g. sequence identity percentages) where 1/65535 resolution is
+// plenty. See QuantizeDistances.
+func NewQuantizedMatrixClusterSet(labels []string, condensed []uint16, scale float64) *MatrixClusterSet {
+	m := newMatrixClusterSet(labels, len(condensed))
+	m.quantized = condensed
+	m.scale = scale
+	return m
+}
+
+// QuantizeDistances converts distances in the range [0, max] to uint16 values
+// for NewQuantizedMatrixClusterSet, and returns the corresponding scale.
+// Distances outside the range are clamped to it. It returns an error if max is
+// not a positive finite number.
+func QuantizeDistances(condensed []float64, max float64) ([]uint16, float64, error) {
+	if !(max > 0) || math.IsInf(max, 1) {
+		return nil, 0, fmt.Errorf("clustering: invalid quantization range %g", max)
+	}
+	scale := max / math.MaxUint16
+	res := make([]uint16, len(condensed))
+	for i, d := range condensed {
+		res[i] = uint16(math.Round(math.Max(0, math.Min(max, d)) / scale))
+	}
+	return res, scale, nil
+}
+
+func newMatrixClusterSet(labels []string, count int) *MatrixClusterSet {
+	n := len(labels)
+	if count != n*(n-1)/2 {
+		panic(fmt.Sprintf("clustering: condensed matrix has %d distances, expected %d for %d labels",
+			count, n*(n-1)/2, n))
+	}
+	m := &MatrixClusterSet{labels: labels}
+	m.clusters = make([][]ClusterItem, n)
+	for i := range labels {
+		m.clusters[i] = []ClusterItem{i}
+	}
+	return m
+}
+
+// Distance returns the distance between the two items.
+func (m *MatrixClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	i, j := item1.(int), item2.(int)
+	if i == j {
+		return 0.0
+	}
+	x := condensedIndex(len(m.labels), i, j)
+	switch {
+	case m.condensed32 != nil:
+		return float64(m.condensed32[x])
+	case m.quantized != nil:
+		return float64(m.quantized[x]) * m.scale
+	}
+	return m.condensed[x]
+}
+
+// ItemDistance implements ItemDistanceSet, as the distance does not depend on
+// the clusters of the items.
+func (m *MatrixClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return m.Distance(-1, -1, item1, item2), true
+}
+
+// Label returns the original label of an item.
+func (m *MatrixClusterSet) Label(item ClusterItem) string {
+	return m.labels[item.(int)]
+}
+
+// EachLabel enumerates the original labels of every item in the cluster.
+func (m *MatrixClusterSet) EachLabel(cluster int, cb func(label string)) {
+	for _, x := range m.clusters[cluster] {
+		cb(m.labels[x.(int)])
+	}
+}
+
+// condensedIndex returns the index of the distance between items i != j in a
+// condensed matrix of n items.
+func condensedIndex(n, i, j int) int {
+	if j < i {
+		i, j = j, i
+	}
+	return n*i - i*(i+1)/2 + (j - i - 1)
+}
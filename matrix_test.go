@@ -0,0 +1,78 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestMatrixClusterSet(t *testing.T) {
+	m := NewMatrixClusterSet([]string{"a", "b", "c", "d"}, testLineMatrix())
+	if m.Distance(0, 3, 3, 1) != 6 || m.Distance(0, 1, 0, 1) != 1 {
+		t.Errorf("unexpected condensed matrix lookup")
+	}
+
+	Cluster(m, Threshold(2), SingleLinkage())
+	if m.Count() != 2 {
+		t.Fatalf("expected 2 clusters, got %d", m.Count())
+	}
+	var labels []string
+	m.EachCluster(-1, func(cluster int) {
+		m.EachLabel(cluster, func(x string) { labels = append(labels, x) })
+	})
+	if fmt.Sprint(labels) != "[a b c d]" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+}
+
+func TestFloat32Storage(t *testing.T) {
+	m := NewMatrix32ClusterSet([]string{"a", "b", "c", "d"}, []float32{
+		1, 3, 7,
+		2, 6,
+		4,
+	})
+	h := &HClustering{
+		ClusterSet:     m,
+		Checker:        Threshold(2),
+		LinkageType:    AverageLinkage(),
+		CacheDistances: true,
+		CacheStorage:   CacheFloat32,
+	}
+	h.Run()
+	if m.Count() != 3 || len(h.distCache.f32) != 3 {
+		t.Errorf("expected 3 clusters with float32 cache, got %d", m.Count())
+	}
+
+	d := NewDistanceMap32ClusterSet(DistanceMap32{
+		"a": {"b": 1, "c": 3, "d": 7},
+		"b": {"c": 2, "d": 6},
+		"c": {"d": 4},
+	})
+	Cluster(d, Threshold(2), SingleLinkage())
+	if d.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", d.Count())
+	}
+}
+
+func TestQuantizedMatrixClusterSet(t *testing.T) {
+	q, scale, err := QuantizeDistances(testLineMatrix(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewQuantizedMatrixClusterSet([]string{"a", "b", "c", "d"}, q, scale)
+	if d := m.Distance(0, 3, 3, 1); d < 5.9999 || d > 6.0001 {
+		t.Errorf("unexpected quantized distance %g", d)
+	}
+	Cluster(m, Threshold(2.0001), SingleLinkage())
+	if m.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", m.Count())
+	}
+}
+
+func TestQuantizeDistancesRange(t *testing.T) {
+	for _, max := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, _, err := QuantizeDistances([]float64{1}, max); err == nil {
+			t.Errorf("expected an error for max %g", max)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package clustering
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// MemoStore is a concurrency-safe store of item-pair distances that can be
+// shared by many clustering runs (e.g. in a long-running service), so that
+// expensive distance computations are not repeated across runs. Memory use is
+// bounded by evicting the least recently used pairs.
+//
+// The store assumes that a given pair of items always has the same distance,
+// so it should only be shared between runs using the same distance function.
+type MemoStore struct {
+	mu      sync.Mutex
+	max     int
+	entries map[memoKey]*list.Element
+	lru     *list.List
+
+	hits, misses, evictions uint64
+}
+
+// MemoStats reports the effectiveness of a MemoStore.
+type MemoStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+// HitRate returns the fraction of lookups that were found in the store.
+func (s MemoStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0.0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewMemoStore creates a MemoStore holding at most maxEntries item pairs. If
+// maxEntries <= 0 the store is unbounded.
+func NewMemoStore(maxEntries int) *MemoStore {
+	return &MemoStore{
+		max:     maxEntries,
+		entries: make(map[memoKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the stored distance between a and b, in either order.
+func (m *MemoStore) Get(a, b ClusterItem) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[newMemoKey(a, b)]
+	if !ok {
+		m.misses++
+		return 0.0, false
+	}
+	m.hits++
+	m.lru.MoveToFront(e)
+	return e.Value.(*memoEntry).dist, true
+}
+
+// Put stores the distance between a and b, in either order.
+func (m *MemoStore) Put(a, b ClusterItem, dist float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := newMemoKey(a, b)
+	if e, ok := m.entries[k]; ok {
+		e.Value.(*memoEntry).dist = dist
+		m.lru.MoveToFront(e)
+		return
+	}
+	m.entries[k] = m.lru.PushFront(&memoEntry{key: k, dist: dist})
+
+	for m.max > 0 && m.lru.Len() > m.max {
+		e := m.lru.Back()
+		m.lru.Remove(e)
+		delete(m.entries, e.Value.(*memoEntry).key)
+		m.evictions++
+	}
+}
+
+// Stats returns the current hit, miss and eviction counts.
+func (m *MemoStore) Stats() MemoStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return MemoStats{
+		Hits:      m.hits,
+		Misses:    m.misses,
+		Evictions: m.evictions,
+		Entries:   m.lru.Len(),
+	}
+}
+
+/////////////
+
+type memoKey struct {
+	a, b ClusterItem
+}
+
+// newMemoKey returns the key of the unordered pair of items a and b, so that
+// both orders share the same entry.
+func newMemoKey(a, b ClusterItem) memoKey {
+	if itemAfter(a, b) {
+		a, b = b, a
+	}
+	return memoKey{a, b}
+}
+
+// itemAfter imposes an arbitrary but consistent order on items. Items of the
+// common types are compared directly, others by their printed form.
+func itemAfter(a, b ClusterItem) bool {
+	switch x := a.(type) {
+	case int:
+		if y, ok := b.(int); ok {
+			return x > y
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return x > y
+		}
+	}
+	return fmt.Sprintf("%T %v", a, a) > fmt.Sprintf("%T %v", b, b)
+}
+
+type memoEntry struct {
+	key  memoKey
+	dist float64
+}
+
+/////////////
+
+// MemoClusterSet is a ClusterSet wrapper that memoizes Distance for every
+// pair of items, so that expensive distance functions (edit distance, DTW,
+// remote calls) are never evaluated twice for the same pair, regardless of
+// the clusters the items are in. Unlike a MemoStore, the memo is unbounded and
+// private to the wrapped set, so it is not safe for concurrent use. The
+// optional interfaces of the wrapped set are forwarded, and cluster distances
+// it provides (see ClusterDistanceSet) are passed through without caching.
+type MemoClusterSet struct {
+	ClusterSet
+
+	memo         map[memoKey]float64
+	hits, misses uint64
+}
+
+// CachedClusterSet wraps cs, memoizing the distance between every pair of
+// items.
+func CachedClusterSet(cs ClusterSet) *MemoClusterSet {
+	return &MemoClusterSet{
+		ClusterSet: cs,
+		memo:       make(map[memoKey]float64),
+	}
+}
+
+// Distance returns the memoized distance between the two items, or computes
+// and stores it on first use.
+func (m *MemoClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	if d, ok := m.lookup(item1, item2); ok {
+		m.hits++
+		return d
+	}
+	m.misses++
+	d := m.ClusterSet.Distance(c1, c2, item1, item2)
+	m.memo[newMemoKey(item1, item2)] = d
+	return d
+}
+
+// lookup returns the memoized distance between the two items, in either order.
+func (m *MemoClusterSet) lookup(item1, item2 ClusterItem) (float64, bool) {
+	d, ok := m.memo[newMemoKey(item1, item2)]
+	return d, ok
+}
+
+// EachItemDistance implements OptimizedClusterSet. When the underlying
+// ClusterSet implements it, the distances it enumerates are memoized, and
+// served from the memo once every item of c2 has been seen.
+func (m *MemoClusterSet) EachItemDistance(c1, c2 int, item1 ClusterItem, cb func(ClusterItem, float64)) {
+	ocs, ok := m.ClusterSet.(OptimizedClusterSet)
+	if !ok {
+		m.ClusterSet.EachItem(c2, func(item2 ClusterItem) {
+			cb(item2, m.Distance(c1, c2, item1, item2))
+		})
+		return
+	}
+
+	complete := true
+	m.ClusterSet.EachItem(c2, func(item2 ClusterItem) {
+		if _, ok := m.lookup(item1, item2); !ok {
+			complete = false
+		}
+	})
+	if !complete {
+		ocs.EachItemDistance(c1, c2, item1, func(item2 ClusterItem, d float64) {
+			if _, ok := m.lookup(item1, item2); !ok {
+				m.misses++
+				m.memo[newMemoKey(item1, item2)] = d
+			}
+			cb(item2, d)
+		})
+		return
+	}
+	m.ClusterSet.EachItem(c2, func(item2 ClusterItem) {
+		d, _ := m.lookup(item1, item2)
+		m.hits++
+		cb(item2, d)
+	})
+}
+
+// Weight implements WeightedClusterSet when the underlying ClusterSet does,
+// and otherwise returns 1.
+func (m *MemoClusterSet) Weight(item ClusterItem) float64 {
+	return weightOf(m.ClusterSet, item)
+}
+
+// ClusterDistance implements ClusterDistanceSet when the underlying
+// ClusterSet does. Cluster distances are not memoized, as cluster ids change
+// with every merge.
+func (m *MemoClusterSet) ClusterDistance(c1, c2 int) (float64, bool) {
+	return clusterDistanceOf(m.ClusterSet, c1, c2)
+}
+
+// Connected implements ConnectedClusterSet when the underlying ClusterSet
+// does, and otherwise allows every merge.
+func (m *MemoClusterSet) Connected(c1, c2 int) bool {
+	return connectedOf(m.ClusterSet, c1, c2)
+}
+
+func (m *MemoClusterSet) wrapped() ClusterSet {
+	return m.ClusterSet
+}
+
+// ItemDistance implements ItemDistanceSet when the underlying ClusterSet does,
+// using the memo.
+func (m *MemoClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	if d, ok := m.lookup(item1, item2); ok {
+		m.hits++
+		return d, true
+	}
+	d, ok := itemDistanceOf(m.ClusterSet, item1, item2)
+	if ok {
+		m.misses++
+		m.memo[newMemoKey(item1, item2)] = d
+	}
+	return d, ok
+}
+
+// Coordinates implements VectorClusterSet when the underlying ClusterSet does.
+func (m *MemoClusterSet) Coordinates(item ClusterItem) []float64 {
+	return coordinatesOf(m.ClusterSet, item)
+}
+
+// Centroid implements VectorClusterSet when the underlying ClusterSet does.
+func (m *MemoClusterSet) Centroid(cluster int) []float64 {
+	return centroidOf(m.ClusterSet, cluster)
+}
+
+// Stats returns the hit and miss counts of the memo.
+func (m *MemoClusterSet) Stats() MemoStats {
+	return MemoStats{
+		Hits:    m.hits,
+		Misses:  m.misses,
+		Entries: len(m.memo),
+	}
+}
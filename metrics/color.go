@@ -0,0 +1,82 @@
+package metrics
+
+import "math"
+
+// CIE76 returns the CIE 1976 color difference between two colors given as
+// {L*, a*, b*} values, which is the Euclidean distance in CIELAB space. A
+// difference of about 2.3 is just noticeable.
+func CIE76(a, b []float64) float64 {
+	return Euclidean(a[:3], b[:3])
+}
+
+// CIEDE2000 returns the CIEDE2000 color difference between two colors given
+// as {L*, a*, b*} values, with the standard weighting factors kL = kC = kH = 1.
+// It corrects the perceptual non-uniformity of CIE76, especially for blues and
+// near-neutral colors, so that color groups are perceptually sensible.
+func CIEDE2000(lab1, lab2 []float64) float64 {
+	const deg = math.Pi / 180
+	l1, a1, b1 := lab1[0], lab1[1], lab1[2]
+	l2, a2, b2 := lab2[0], lab2[1], lab2[2]
+
+	c1 := math.Hypot(a1, b1)
+	c2 := math.Hypot(a2, b2)
+	cbar7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cbar7/(cbar7+math.Pow(25, 7))))
+
+	a1p, a2p := (1+g)*a1, (1+g)*a2
+	c1p, c2p := math.Hypot(a1p, b1), math.Hypot(a2p, b2)
+	h1p, h2p := hueAngle(b1, a1p), hueAngle(b2, a2p)
+
+	dL := l2 - l1
+	dC := c2p - c1p
+	var dh float64
+	switch {
+	case c1p*c2p == 0:
+		dh = 0
+	case math.Abs(h2p-h1p) <= 180:
+		dh = h2p - h1p
+	case h2p-h1p > 180:
+		dh = h2p - h1p - 360
+	default:
+		dh = h2p - h1p + 360
+	}
+	dH := 2 * math.Sqrt(c1p*c2p) * math.Sin(dh/2*deg)
+
+	lbar := (l1 + l2) / 2
+	cbarp := (c1p + c2p) / 2
+	var hbarp float64
+	switch {
+	case c1p*c2p == 0:
+		hbarp = h1p + h2p
+	case math.Abs(h1p-h2p) <= 180:
+		hbarp = (h1p + h2p) / 2
+	case h1p+h2p < 360:
+		hbarp = (h1p + h2p + 360) / 2
+	default:
+		hbarp = (h1p + h2p - 360) / 2
+	}
+
+	t := 1 - 0.17*math.Cos((hbarp-30)*deg) + 0.24*math.Cos(2*hbarp*deg) +
+		0.32*math.Cos((3*hbarp+6)*deg) - 0.20*math.Cos((4*hbarp-63)*deg)
+	dTheta := 30 * math.Exp(-math.Pow((hbarp-275)/25, 2))
+	cbarp7 := math.Pow(cbarp, 7)
+	rc := 2 * math.Sqrt(cbarp7/(cbarp7+math.Pow(25, 7)))
+	sl := 1 + 0.015*(lbar-50)*(lbar-50)/math.Sqrt(20+(lbar-50)*(lbar-50))
+	sc := 1 + 0.045*cbarp
+	sh := 1 + 0.015*cbarp*t
+	rt := -math.Sin(2*dTheta*deg) * rc
+
+	return math.Sqrt((dL/sl)*(dL/sl) + (dC/sc)*(dC/sc) + (dH/sh)*(dH/sh) + rt*(dC/sc)*(dH/sh))
+}
+
+// hueAngle returns the hue angle atan2(b, a) in degrees within [0, 360).
+func hueAngle(b, a float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+	h := math.Atan2(b, a) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Pearson returns the correlation distance 1 - r between a and b, where r is
+// the Pearson correlation coefficient, so that rows are compared by shape
+// rather than magnitude. It ranges from 0 (perfectly correlated) to 2
+// (perfectly anti-correlated). Vectors without variance have r = 0.
+func Pearson(a, b []float64) float64 {
+	return 1.0 - correlation(a, b)
+}
+
+// AbsPearson returns the distance 1 - |r|, which treats perfectly
+// anti-correlated rows as identical.
+func AbsPearson(a, b []float64) float64 {
+	return 1.0 - math.Abs(correlation(a, b))
+}
+
+// Spearman returns the rank correlation distance 1 - ρ between a and b, where
+// ρ is the Pearson correlation of the ranks of their values (ties get their
+// average rank). This is robust to outliers and any monotone transformation.
+func Spearman(a, b []float64) float64 {
+	return 1.0 - correlation(ranks(a), ranks(b))
+}
+
+// AbsSpearman returns the distance 1 - |ρ|, which treats perfectly
+// anti-correlated rankings as identical.
+func AbsSpearman(a, b []float64) float64 {
+	return 1.0 - math.Abs(correlation(ranks(a), ranks(b)))
+}
+
+// correlation returns the Pearson correlation coefficient of a and b, or 0 if
+// either has no variance.
+func correlation(a, b []float64) float64 {
+	n := float64(len(a))
+	if n == 0 {
+		return 0
+	}
+	var ma, mb float64
+	for i := range a {
+		ma += a[i]
+		mb += b[i]
+	}
+	ma /= n
+	mb /= n
+	var cov, va, vb float64
+	for i := range a {
+		cov += (a[i] - ma) * (b[i] - mb)
+		va += (a[i] - ma) * (a[i] - ma)
+		vb += (b[i] - mb) * (b[i] - mb)
+	}
+	if va == 0 || vb == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(va*vb)
+}
+
+// ranks returns the 1-based rank of every value, using the average rank for
+// ties.
+func ranks(v []float64) []float64 {
+	idx := make([]int, len(v))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(x, y int) bool { return v[idx[x]] < v[idx[y]] })
+
+	res := make([]float64, len(v))
+	for i := 0; i < len(idx); {
+		j := i + 1
+		for j < len(idx) && v[idx[j]] == v[idx[i]] {
+			j++
+		}
+		r := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			res[idx[k]] = r
+		}
+		i = j
+	}
+	return res
+}
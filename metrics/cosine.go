@@ -0,0 +1,56 @@
+package metrics
+
+import "math"
+
+// Cosine returns the cosine distance 1 - a·b/(|a||b|) between a and b, which
+// ranges from 0 (same direction) to 2 (opposite directions). Zero vectors have
+// distance 1 to every vector. Note that cosine distance is not a true metric,
+// see Angular.
+func Cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 1.0
+	}
+	return 1.0 - dot/math.Sqrt(na*nb)
+}
+
+// NormalizedCosine returns the cosine distance 1 - a·b for vectors that are
+// already normalized to unit length (see Normalize), skipping the norms. This
+// is the fast path for embedding vectors, which are usually stored normalized.
+func NormalizedCosine(a, b []float64) float64 {
+	dot := 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return 1.0 - dot
+}
+
+// Angular returns the angle between a and b divided by pi, which ranges from
+// 0 to 1. Unlike Cosine, angular distance satisfies the triangle inequality.
+// Zero vectors have distance 0.5 to every vector.
+func Angular(a, b []float64) float64 {
+	sim := 1.0 - Cosine(a, b)
+	return math.Acos(math.Max(-1, math.Min(1, sim))) / math.Pi
+}
+
+// Normalize scales v in place to unit length, so that NormalizedCosine can be
+// used. Zero vectors are left unchanged. It returns v.
+func Normalize(v []float64) []float64 {
+	s := 0.0
+	for _, x := range v {
+		s += x * x
+	}
+	if s == 0 {
+		return v
+	}
+	s = math.Sqrt(s)
+	for i := range v {
+		v[i] /= s
+	}
+	return v
+}
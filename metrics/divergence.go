@@ -0,0 +1,79 @@
+package metrics
+
+import "math"
+
+// JensenShannon returns the Jensen-Shannon divergence (in bits) between two
+// probability distributions, such as topic distributions or histograms, which
+// are normalized to sum to 1. It ranges from 0 (identical) to 1 (disjoint
+// support), and is finite even where one distribution is zero. Empty (or
+// all-zero) inputs have divergence 0 to each other, and the maximum of 1 to
+// any other distribution.
+func JensenShannon(a, b []float64) float64 {
+	p, q := distribution(a, 0), distribution(b, 0)
+	if p == nil || q == nil {
+		if p == nil && q == nil {
+			return 0.0
+		}
+		return 1.0
+	}
+	js := 0.0
+	for i := range p {
+		m := (p[i] + q[i]) / 2
+		js += (entropyTerm(p[i], m) + entropyTerm(q[i], m)) / 2
+	}
+	return math.Max(0, js)
+}
+
+// JensenShannonDistance returns the square root of JensenShannon, which
+// satisfies the triangle inequality.
+func JensenShannonDistance(a, b []float64) float64 {
+	return math.Sqrt(JensenShannon(a, b))
+}
+
+// SymmetricKL returns a metric computing the symmetrized Kullback-Leibler
+// divergence KL(p||q) + KL(q||p) (in bits) between two distributions, which
+// are normalized to sum to 1 after adding smoothing to every bin. Smoothing
+// must be > 0 for distributions with zero bins, or the divergence is infinite.
+// Inputs without mass (empty, or all zero without smoothing) have divergence 0
+// to each other, and +Inf to any other distribution.
+func SymmetricKL(smoothing float64) func(a, b []float64) float64 {
+	return func(a, b []float64) float64 {
+		p, q := distribution(a, smoothing), distribution(b, smoothing)
+		if p == nil || q == nil {
+			if p == nil && q == nil {
+				return 0.0
+			}
+			return math.Inf(1)
+		}
+		kl := 0.0
+		for i := range p {
+			kl += entropyTerm(p[i], q[i]) + entropyTerm(q[i], p[i])
+		}
+		return math.Max(0, kl)
+	}
+}
+
+// distribution returns v plus smoothing, normalized to sum to 1, or nil if
+// the sum is zero.
+func distribution(v []float64, smoothing float64) []float64 {
+	sum := 0.0
+	for _, x := range v {
+		sum += x + smoothing
+	}
+	if sum == 0 {
+		return nil
+	}
+	res := make([]float64, len(v))
+	for i, x := range v {
+		res[i] = (x + smoothing) / sum
+	}
+	return res
+}
+
+// entropyTerm returns p*log2(p/q), where 0*log(0/q) = 0.
+func entropyTerm(p, q float64) float64 {
+	if p == 0 {
+		return 0.0
+	}
+	return p * math.Log2(p/q)
+}
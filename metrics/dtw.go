@@ -0,0 +1,65 @@
+package metrics
+
+import "math"
+
+// DTW returns the dynamic time warping distance between two numeric
+// sequences, which may have different lengths: the minimum total absolute
+// difference over all monotone alignments of their elements. This compares
+// sequences by shape even when they are shifted or stretched in time.
+func DTW(a, b []float64) float64 {
+	return dtw(a, b, -1)
+}
+
+// DTWBand returns a metric computing the DTW distance with a Sakoe-Chiba band
+// of the given width, i.e. element i of a can only be aligned to elements of b
+// within window positions of i. This both speeds up DTW to O(n*window) and
+// prevents pathological alignments. The window is widened to the difference in
+// lengths if necessary.
+func DTWBand(window int) func(a, b []float64) float64 {
+	return func(a, b []float64) float64 {
+		return dtw(a, b, window)
+	}
+}
+
+// dtw computes the DTW distance using two rows of the cost matrix, where
+// window < 0 is unconstrained.
+func dtw(a, b []float64, window int) float64 {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		if n == m {
+			return 0.0
+		}
+		return math.Inf(1)
+	}
+	if window >= 0 && window < n-m {
+		window = n - m
+	}
+	if window >= 0 && window < m-n {
+		window = m - n
+	}
+
+	inf := math.Inf(1)
+	prev := make([]float64, m+1)
+	cur := make([]float64, m+1)
+	for j := range prev {
+		prev[j] = inf
+	}
+	prev[0] = 0
+
+	for i := 1; i <= n; i++ {
+		lo, hi := 1, m
+		if window >= 0 {
+			lo = max(1, i-window)
+			hi = min(m, i+window)
+		}
+		for j := range cur {
+			cur[j] = inf
+		}
+		for j := lo; j <= hi; j++ {
+			best := math.Min(prev[j-1], math.Min(prev[j], cur[j-1]))
+			cur[j] = math.Abs(a[i-1]-b[j-1]) + best
+		}
+		prev, cur = cur, prev
+	}
+	return prev[m]
+}
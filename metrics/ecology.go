@@ -0,0 +1,32 @@
+package metrics
+
+import "math"
+
+// Canberra returns the Canberra distance sum(|a-b|/(|a|+|b|)) between a and
+// b, a weighted Manhattan distance that is sensitive to small changes near
+// zero. Coordinates where both values are zero are skipped.
+func Canberra(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		if d := math.Abs(a[i]) + math.Abs(b[i]); d > 0 {
+			s += math.Abs(a[i]-b[i]) / d
+		}
+	}
+	return s
+}
+
+// BrayCurtis returns the Bray-Curtis dissimilarity sum|a-b|/sum|a+b| between
+// two vectors of non-negative abundances, such as species counts, which ranges
+// from 0 (identical) to 1 (nothing in common). Two empty samples have distance
+// 0.
+func BrayCurtis(a, b []float64) float64 {
+	var num, den float64
+	for i := range a {
+		num += math.Abs(a[i] - b[i])
+		den += math.Abs(a[i] + b[i])
+	}
+	if den == 0 {
+		return 0.0
+	}
+	return num / den
+}
@@ -0,0 +1,19 @@
+package metrics
+
+import "math"
+
+// EarthRadius is the mean radius of the earth in meters.
+const EarthRadius = 6371008.8
+
+// Haversine returns the great-circle distance in meters between two points
+// given as {latitude, longitude} in degrees, so that geographic points can be
+// clustered with thresholds in physical units. Any further coordinates are
+// ignored.
+func Haversine(a, b []float64) float64 {
+	rad := math.Pi / 180
+	dlat := (b[0] - a[0]) * rad
+	dlon := (b[1] - a[1]) * rad
+	h := math.Sin(dlat/2)*math.Sin(dlat/2) +
+		math.Cos(a[0]*rad)*math.Cos(b[0]*rad)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import "math"
+
+// GowerKind is the type of a field in a mixed-type record.
+type GowerKind int
+
+const (
+	// GowerNumeric fields contribute |a-b|/Range.
+	GowerNumeric GowerKind = iota
+
+	// GowerOrdinal fields hold ranks, and contribute |a-b|/Range like numeric
+	// fields.
+	GowerOrdinal
+
+	// GowerCategorical fields hold category codes, and contribute 0 if they
+	// are equal or 1 otherwise.
+	GowerCategorical
+
+	// GowerBoolean fields hold 0 (false) or non-zero (true), and contribute 0
+	// if they are equal or 1 otherwise. Following Gower, the field is ignored
+	// when both values are false, i.e. it is treated as an asymmetric binary
+	// feature where only shared presence counts as similarity.
+	GowerBoolean
+)
+
+// GowerField describes a field of a mixed-type record for Gower.
+type GowerField struct {
+	Kind GowerKind
+
+	// Weight of the field, or 1 if zero. Use a negative weight to ignore it.
+	Weight float64
+
+	// Range is the difference between the largest and smallest values of a
+	// numeric or ordinal field, see FitGowerRanges.
+	Range float64
+}
+
+// Gower returns a metric computing Gower's distance between records of mixed
+// numeric, ordinal, categorical and boolean fields, which is the weighted mean
+// of the per-field distances (each between 0 and 1). Records are encoded as
+// float64 vectors with one value per field, e.g. categorical fields as integer
+// codes. NaN values are treated as missing, and the field is ignored for that
+// pair.
+func Gower(fields []GowerField) func(a, b []float64) float64 {
+	return func(a, b []float64) float64 {
+		sum, total := 0.0, 0.0
+		for i, f := range fields {
+			w := f.Weight
+			if w == 0 {
+				w = 1
+			}
+			if w < 0 || math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+				continue
+			}
+
+			var d float64
+			switch f.Kind {
+			case GowerCategorical:
+				if a[i] != b[i] {
+					d = 1
+				}
+			case GowerBoolean:
+				if a[i] == 0 && b[i] == 0 {
+					continue
+				}
+				if (a[i] == 0) != (b[i] == 0) {
+					d = 1
+				}
+			default:
+				if f.Range > 0 {
+					d = math.Min(1, math.Abs(a[i]-b[i])/f.Range)
+				}
+			}
+			sum += w * d
+			total += w
+		}
+		if total == 0 {
+			return 0.0
+		}
+		return sum / total
+	}
+}
+
+// FitGowerRanges sets the Range of every numeric and ordinal field to the
+// difference between its largest and smallest non-missing values in records.
+func FitGowerRanges(fields []GowerField, records [][]float64) {
+	for i := range fields {
+		if fields[i].Kind != GowerNumeric && fields[i].Kind != GowerOrdinal {
+			continue
+		}
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, r := range records {
+			if !math.IsNaN(r[i]) {
+				lo = math.Min(lo, r[i])
+				hi = math.Max(hi, r[i])
+			}
+		}
+		if hi > lo {
+			fields[i].Range = hi - lo
+		} else {
+			fields[i].Range = 0
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Hamming returns the number of coordinates that differ between a and b.
+func Hamming(a, b []float64) float64 {
+	n := 0
+	for i := range a {
+		if a[i] != b[i] {
+			n++
+		}
+	}
+	return float64(n)
+}
+
+// HammingBytes returns the number of bits that differ between two byte
+// strings of the same length, e.g. perceptual hashes, using XOR and popcount
+// on 8 bytes at a time.
+func HammingBytes(a, b []byte) float64 {
+	n, i := 0, 0
+	for ; i+8 <= len(a); i += 8 {
+		n += bits.OnesCount64(binary.LittleEndian.Uint64(a[i:]) ^ binary.LittleEndian.Uint64(b[i:]))
+	}
+	for ; i < len(a); i++ {
+		n += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return float64(n)
+}
+
+// HammingBits returns the number of bits that differ between two packed bit
+// vectors of the same length, e.g. SimHash fingerprints.
+func HammingBits(a, b []uint64) float64 {
+	n := 0
+	for i := range a {
+		n += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return float64(n)
+}
+
+// Tanimoto returns the Tanimoto (Jaccard) distance 1 - |a&b|/|a|b| between two
+// packed bit vectors of the same length, such as chemical fingerprints, using
+// popcount on 64 bits at a time. Two empty fingerprints have distance 0.
+func Tanimoto(a, b []uint64) float64 {
+	inter, union := 0, 0
+	for i := range a {
+		inter += bits.OnesCount64(a[i] & b[i])
+		union += bits.OnesCount64(a[i] | b[i])
+	}
+	if union == 0 {
+		return 0.0
+	}
+	return 1.0 - float64(inter)/float64(union)
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+)
+
+// KmerProfile is the frequency of every k-mer (substring of length k) in a
+// sequence, for alignment-free comparison of DNA or protein sequences.
+type KmerProfile map[string]float64
+
+// NewKmerProfile counts the k-mers of seq, ignoring case, and normalizes the
+// counts to frequencies so that sequences of different lengths can be
+// compared. Sequences shorter than k have an empty profile.
+func NewKmerProfile(seq string, k int) KmerProfile {
+	seq = strings.ToUpper(seq)
+	p := make(KmerProfile)
+	if k <= 0 || len(seq) < k {
+		return p
+	}
+	n := len(seq) - k + 1
+	for i := 0; i < n; i++ {
+		p[seq[i:i+k]] += 1.0 / float64(n)
+	}
+	return p
+}
+
+// Cosine returns the cosine distance between two k-mer profiles. Empty
+// profiles have distance 1 to every profile.
+func (p KmerProfile) Cosine(q KmerProfile) float64 {
+	var dot, np, nq float64
+	for kmer, x := range p {
+		dot += x * q[kmer]
+		np += x * x
+	}
+	for _, y := range q {
+		nq += y * y
+	}
+	if np == 0 || nq == 0 {
+		return 1.0
+	}
+	return 1.0 - dot/math.Sqrt(np*nq)
+}
+
+// D2 returns the d2 distance between two k-mer profiles, i.e. the squared
+// Euclidean distance between their k-mer frequencies.
+func (p KmerProfile) D2(q KmerProfile) float64 {
+	s := 0.0
+	for kmer, x := range p {
+		d := x - q[kmer]
+		s += d * d
+	}
+	for kmer, y := range q {
+		if _, ok := p[kmer]; !ok {
+			s += y * y
+		}
+	}
+	return s
+}
+
+// KmerCosine returns a metric computing the cosine distance between the k-mer
+// profiles of two sequences. Profiles are computed on every call, so for large
+// inputs precompute them with NewKmerProfile instead.
+func KmerCosine(k int) func(a, b string) float64 {
+	return func(a, b string) float64 {
+		return NewKmerProfile(a, k).Cosine(NewKmerProfile(b, k))
+	}
+}
+
+// KmerD2 returns a metric computing the d2 distance between the k-mer
+// profiles of two sequences.
+func KmerD2(k int) func(a, b string) float64 {
+	return func(a, b string) float64 {
+		return NewKmerProfile(a, k).D2(NewKmerProfile(b, k))
+	}
+}
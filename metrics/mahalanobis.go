@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrNotPositiveDefinite is returned when a covariance or precision matrix
+// has no Cholesky factorization.
+var ErrNotPositiveDefinite = errors.New("metrics: matrix is not symmetric positive definite")
+
+// Mahalanobis returns a metric computing the Mahalanobis distance
+// sqrt((a-b)ᵀ S⁻¹ (a-b)) for the covariance matrix S, so that correlated
+// features can be clustered without manual whitening. S is factorized once
+// using a Cholesky decomposition, and each distance is computed by forward
+// substitution without inverting S.
+func Mahalanobis(cov [][]float64) (func(a, b []float64) float64, error) {
+	l, err := cholesky(cov)
+	if err != nil {
+		return nil, err
+	}
+	return func(a, b []float64) float64 {
+		// solve L y = a-b, then d² = |y|²
+		y := make([]float64, len(l))
+		s := 0.0
+		for i, row := range l {
+			v := a[i] - b[i]
+			for k := 0; k < i; k++ {
+				v -= row[k] * y[k]
+			}
+			y[i] = v / row[i]
+			s += y[i] * y[i]
+		}
+		return math.Sqrt(s)
+	}, nil
+}
+
+// MahalanobisPrecision is like Mahalanobis, but takes the precision matrix
+// (the inverse covariance matrix) P, computing sqrt((a-b)ᵀ P (a-b)).
+func MahalanobisPrecision(prec [][]float64) (func(a, b []float64) float64, error) {
+	l, err := cholesky(prec)
+	if err != nil {
+		return nil, err
+	}
+	return func(a, b []float64) float64 {
+		// d² = |Lᵀ(a-b)|²
+		s := 0.0
+		for k := range l {
+			v := 0.0
+			for i := k; i < len(l); i++ {
+				v += l[i][k] * (a[i] - b[i])
+			}
+			s += v * v
+		}
+		return math.Sqrt(s)
+	}, nil
+}
+
+// cholesky returns the lower triangular matrix L such that m = L Lᵀ.
+func cholesky(m [][]float64) ([][]float64, error) {
+	n := len(m)
+	l := make([][]float64, n)
+	for i := range l {
+		if len(m[i]) != n {
+			return nil, ErrNotPositiveDefinite
+		}
+		l[i] = make([]float64, i+1)
+		for j := 0; j <= i; j++ {
+			if m[i][j] != m[j][i] {
+				return nil, ErrNotPositiveDefinite
+			}
+			s := m[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if s <= 0 {
+					return nil, ErrNotPositiveDefinite
+				}
+				l[i][i] = math.Sqrt(s)
+			} else {
+				l[i][j] = s / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
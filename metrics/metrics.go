@@ -0,0 +1,55 @@
+// Package metrics provides distance metrics between feature vectors, for use
+// with clustering.NewPointsClusterSet and other vector-based ClusterSets.
+//
+// Every metric has the signature func(a, b []float64) float64, and assumes
+// both vectors have the same length.
+package metrics
+
+import "math"
+
+// Euclidean returns the straight-line (L2) distance between a and b.
+func Euclidean(a, b []float64) float64 {
+	return math.Sqrt(SquaredEuclidean(a, b))
+}
+
+// SquaredEuclidean returns the squared Euclidean distance between a and b,
+// which avoids the square root when only the ordering of distances matters.
+func SquaredEuclidean(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		s += d * d
+	}
+	return s
+}
+
+// Manhattan returns the city-block (L1) distance between a and b.
+func Manhattan(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += math.Abs(a[i] - b[i])
+	}
+	return s
+}
+
+// Chebyshev returns the maximum coordinate difference (L-infinity distance)
+// between a and b.
+func Chebyshev(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s = math.Max(s, math.Abs(a[i]-b[i]))
+	}
+	return s
+}
+
+// Minkowski returns a metric computing the Lp distance between two vectors,
+// which generalizes Manhattan (p=1) and Euclidean (p=2) distance.
+func Minkowski(p float64) func(a, b []float64) float64 {
+	return func(a, b []float64) float64 {
+		s := 0.0
+		for i := range a {
+			s += math.Pow(math.Abs(a[i]-b[i]), p)
+		}
+		return math.Pow(s, 1/p)
+	}
+}
@@ -0,0 +1,372 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	a, b := []float64{0, 0, 0}, []float64{1, 2, 2}
+	cases := map[string]struct {
+		metric func(a, b []float64) float64
+		want   float64
+	}{
+		"euclidean":         {Euclidean, 3},
+		"squared-euclidean": {SquaredEuclidean, 9},
+		"manhattan":         {Manhattan, 5},
+		"chebyshev":         {Chebyshev, 2},
+		"minkowski-1":       {Minkowski(1), 5},
+		"minkowski-2":       {Minkowski(2), 3},
+	}
+	for name, c := range cases {
+		if got := c.metric(a, b); math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("%s: expected %g, got %g", name, c.want, got)
+		}
+	}
+}
+
+func TestCosine(t *testing.T) {
+	a, b, c := []float64{1, 0}, []float64{3, 3}, []float64{-2, 0}
+	if got := Cosine(a, b); math.Abs(got-(1-math.Sqrt2/2)) > 1e-12 {
+		t.Errorf("expected cosine distance 1-sqrt(2)/2, got %g", got)
+	}
+	if Cosine(a, c) != 2 || Cosine(a, []float64{0, 0}) != 1 {
+		t.Errorf("unexpected cosine distance for opposite or zero vectors")
+	}
+	if got := Angular(a, b); math.Abs(got-0.25) > 1e-12 {
+		t.Errorf("expected angular distance 0.25, got %g", got)
+	}
+	if got := NormalizedCosine(a, Normalize(b)); math.Abs(got-Cosine(a, b)) > 1e-12 {
+		t.Errorf("normalized cosine %g differs from cosine %g", got, Cosine(a, b))
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	if got := Jaccard([]float64{1, 1, 0, 0}, []float64{0, 2, 1, 0}); math.Abs(got-2.0/3.0) > 1e-12 {
+		t.Errorf("expected binary jaccard distance 2/3, got %g", got)
+	}
+	if got := JaccardSet([]string{"a", "b", "c", "a"}, []string{"b", "c", "d"}); got != 0.5 {
+		t.Errorf("expected set jaccard distance 0.5, got %g", got)
+	}
+	if Jaccard([]float64{0}, []float64{0}) != 0 || JaccardSet[int](nil, nil) != 0 {
+		t.Errorf("empty sets should have distance 0")
+	}
+}
+
+func TestHamming(t *testing.T) {
+	if got := Hamming([]float64{1, 2, 3}, []float64{1, 0, 0}); got != 2 {
+		t.Errorf("expected 2 differing coordinates, got %g", got)
+	}
+	a := []byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0x0f}
+	b := []byte{0x0f, 0, 0, 0, 0, 0, 0, 1, 0x00}
+	if got := HammingBytes(a, b); got != 9 {
+		t.Errorf("expected 9 differing bits, got %g", got)
+	}
+	if got := HammingBits([]uint64{0, 1 << 63}, []uint64{3, 0}); got != 3 {
+		t.Errorf("expected 3 differing bits, got %g", got)
+	}
+}
+
+func TestMahalanobis(t *testing.T) {
+	// variance 4 in x, 1 in y, covariance 1
+	cov := [][]float64{{4, 1}, {1, 1}}
+	prec := [][]float64{{1.0 / 3, -1.0 / 3}, {-1.0 / 3, 4.0 / 3}}
+	a, b := []float64{1, 2}, []float64{3, 1}
+
+	// (a-b)ᵀ P (a-b) for a-b = (-2, 1) is 4/3 + 4/3 + 4/3 = 4
+	m1, err := Mahalanobis(cov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := MahalanobisPrecision(prec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m1(a, b); math.Abs(got-2) > 1e-12 {
+		t.Errorf("expected mahalanobis distance 2, got %g", got)
+	}
+	if got := m2(a, b); math.Abs(got-2) > 1e-12 {
+		t.Errorf("expected precision mahalanobis distance 2, got %g", got)
+	}
+	if _, err := Mahalanobis([][]float64{{1, 2}, {2, 1}}); err != ErrNotPositiveDefinite {
+		t.Errorf("expected an error for an indefinite matrix, got %v", err)
+	}
+}
+
+func TestGower(t *testing.T) {
+	// age, plan type, premium, spend
+	fields := []GowerField{
+		{Kind: GowerNumeric},
+		{Kind: GowerCategorical},
+		{Kind: GowerBoolean},
+		{Kind: GowerNumeric, Weight: 2},
+	}
+	records := [][]float64{
+		{20, 1, 0, 100},
+		{60, 2, 1, 300},
+		{40, 1, 0, math.NaN()},
+	}
+	FitGowerRanges(fields, records)
+	if fields[0].Range != 40 || fields[3].Range != 200 {
+		t.Fatalf("unexpected fitted ranges %+v", fields)
+	}
+
+	m := Gower(fields)
+	if got := m(records[0], records[1]); got != 1 {
+		t.Errorf("expected gower distance 1, got %g", got)
+	}
+	// age differs by half the range, the boolean and missing spend are ignored
+	if got := m(records[0], records[2]); got != 0.25 {
+		t.Errorf("expected gower distance 0.25, got %g", got)
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	a := []float64{1, 2, 3, 4}
+	if got := Pearson(a, []float64{10, 20, 30, 40}); math.Abs(got) > 1e-12 {
+		t.Errorf("expected pearson distance 0 for scaled rows, got %g", got)
+	}
+	if got := Pearson(a, []float64{4, 3, 2, 1}); math.Abs(got-2) > 1e-12 {
+		t.Errorf("expected pearson distance 2 for reversed rows, got %g", got)
+	}
+	if got := AbsPearson(a, []float64{4, 3, 2, 1}); math.Abs(got) > 1e-12 {
+		t.Errorf("expected absolute pearson distance 0, got %g", got)
+	}
+	// monotone but not linear
+	if got := Spearman(a, []float64{1, 10, 100, 1000}); math.Abs(got) > 1e-12 {
+		t.Errorf("expected spearman distance 0 for monotone rows, got %g", got)
+	}
+	// ties get ranks {4, 2.5, 2.5, 1}
+	if got := AbsSpearman(a, []float64{9, 5, 5, 1}); math.Abs(got-(1-4.5/math.Sqrt(22.5))) > 1e-12 {
+		t.Errorf("unexpected absolute spearman distance with ties %g", got)
+	}
+}
+
+func TestDTW(t *testing.T) {
+	a := []float64{0, 1, 2, 1, 0}
+	b := []float64{0, 0, 1, 2, 1, 0}
+	if got := DTW(a, b); got != 0 {
+		t.Errorf("expected DTW distance 0 for a stretched sequence, got %g", got)
+	}
+	if got := DTW(a, []float64{1, 2, 3, 2, 1}); got != 3 {
+		t.Errorf("expected DTW distance 3 for a shifted sequence, got %g", got)
+	}
+	// the band prevents aligning the spike at position 1 to position 3
+	c, d := []float64{0, 5, 0, 0, 0}, []float64{0, 0, 0, 5, 0}
+	if DTW(c, d) != 0 {
+		t.Errorf("expected unconstrained DTW distance 0")
+	}
+	if got := DTWBand(1)(c, d); got != 10 {
+		t.Errorf("expected banded DTW distance 10, got %g", got)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	if got := Levenshtein("kitten", "sitting"); got != 3 {
+		t.Errorf("expected levenshtein distance 3, got %g", got)
+	}
+	if got := Levenshtein("teh", "the"); got != 2 {
+		t.Errorf("expected levenshtein distance 2 for a transposition, got %g", got)
+	}
+	if got := Damerau("teh", "the"); got != 1 {
+		t.Errorf("expected damerau distance 1 for a transposition, got %g", got)
+	}
+	if got := NormalizedLevenshtein("kitten", "sitting"); math.Abs(got-3.0/7.0) > 1e-12 {
+		t.Errorf("expected normalized levenshtein distance 3/7, got %g", got)
+	}
+	if NormalizedDamerau("", "") != 0 || NormalizedLevenshtein("abc", "") != 1 {
+		t.Errorf("unexpected normalized distance for empty strings")
+	}
+
+	// standard examples from Winkler (1990)
+	if got := Jaro("MARTHA", "MARHTA"); math.Abs(got-(1-0.944444)) > 1e-6 {
+		t.Errorf("unexpected jaro distance %g", got)
+	}
+	if got := JaroWinkler("MARTHA", "MARHTA"); math.Abs(got-(1-0.961111)) > 1e-6 {
+		t.Errorf("unexpected jaro-winkler distance %g", got)
+	}
+	if got := JaroWinkler("DIXON", "DICKSONX"); math.Abs(got-(1-0.813333)) > 1e-6 {
+		t.Errorf("unexpected jaro-winkler distance %g", got)
+	}
+}
+
+func TestHaversine(t *testing.T) {
+	// one degree of latitude, and a quarter of the equator
+	if got := Haversine([]float64{0, 0}, []float64{1, 0}); math.Abs(got-EarthRadius*math.Pi/180) > 1e-6 {
+		t.Errorf("unexpected distance for one degree %g", got)
+	}
+	if got := Haversine([]float64{0, -45}, []float64{0, 45}); math.Abs(got-EarthRadius*math.Pi/2) > 1e-6 {
+		t.Errorf("unexpected distance for a quarter of the equator %g", got)
+	}
+}
+
+func TestEcology(t *testing.T) {
+	a, b := []float64{6, 7, 4, 0}, []float64{10, 0, 6, 0}
+	if got := Canberra(a, b); math.Abs(got-(4.0/16+1+2.0/10)) > 1e-12 {
+		t.Errorf("unexpected canberra distance %g", got)
+	}
+	if got := BrayCurtis(a, b); math.Abs(got-13.0/33.0) > 1e-12 {
+		t.Errorf("unexpected bray-curtis dissimilarity %g", got)
+	}
+	if BrayCurtis([]float64{0}, []float64{0}) != 0 {
+		t.Errorf("empty samples should have distance 0")
+	}
+}
+
+func TestTanimoto(t *testing.T) {
+	a := []uint64{0xf, 1 << 63}
+	b := []uint64{0x3, 0}
+	if got := Tanimoto(a, b); got != 0.6 {
+		t.Errorf("expected tanimoto distance 0.6, got %g", got)
+	}
+	if Tanimoto([]uint64{0}, []uint64{0}) != 0 {
+		t.Errorf("empty fingerprints should have distance 0")
+	}
+}
+
+func TestDivergence(t *testing.T) {
+	a, b := []float64{1, 1, 0}, []float64{0, 0, 5}
+	if got := JensenShannon(a, b); math.Abs(got-1) > 1e-12 {
+		t.Errorf("expected JS divergence 1 for disjoint distributions, got %g", got)
+	}
+	if got := JensenShannon(a, []float64{2, 2, 0}); math.Abs(got) > 1e-12 {
+		t.Errorf("expected JS divergence 0 for scaled histograms, got %g", got)
+	}
+	// p = (1/2, 1/2), q = (1/4, 3/4)
+	want := 0.5*math.Log2(2) + 0.5*math.Log2(2.0/3.0) + 0.25*math.Log2(0.5) + 0.75*math.Log2(1.5)
+	if got := SymmetricKL(0)([]float64{1, 1}, []float64{1, 3}); math.Abs(got-want) > 1e-12 {
+		t.Errorf("expected symmetric KL %g, got %g", want, got)
+	}
+	if got := SymmetricKL(1e-3)(a, b); math.IsInf(got, 0) || got <= 0 {
+		t.Errorf("expected finite smoothed KL, got %g", got)
+	}
+
+	// an empty histogram is only identical to another empty histogram
+	empty := []float64{0, 0, 0}
+	if got := JensenShannon(empty, nil); got != 0 {
+		t.Errorf("expected JS divergence 0 between empty histograms, got %g", got)
+	}
+	if got := JensenShannon(a, empty); got != 1 {
+		t.Errorf("expected JS divergence 1 to an empty histogram, got %g", got)
+	}
+	if got := SymmetricKL(0)(empty, empty); got != 0 {
+		t.Errorf("expected symmetric KL 0 between empty histograms, got %g", got)
+	}
+	if got := SymmetricKL(0)(nil, b); !math.IsInf(got, 1) {
+		t.Errorf("expected infinite symmetric KL to an empty histogram, got %g", got)
+	}
+}
+
+func TestWasserstein(t *testing.T) {
+	if got := Wasserstein([]float64{1, 0, 0}, []float64{0, 0, 2}); math.Abs(got-2) > 1e-12 {
+		t.Errorf("expected all mass moved 2 bins, got %g", got)
+	}
+	if got := Wasserstein([]float64{1, 1, 0}, []float64{0, 1, 1}); math.Abs(got-1) > 1e-12 {
+		t.Errorf("expected histogram distance 1, got %g", got)
+	}
+	if got := WassersteinSamples([]float64{3, 1, 2}, []float64{11, 12, 13}); math.Abs(got-10) > 1e-12 {
+		t.Errorf("expected shifted samples distance 10, got %g", got)
+	}
+	if got := WassersteinSamples([]float64{0, 1}, []float64{0, 0, 1, 1}); math.Abs(got) > 1e-12 {
+		t.Errorf("expected identical distributions to have distance 0, got %g", got)
+	}
+	if got := WassersteinSamples([]float64{0}, []float64{0, 2}); math.Abs(got-1) > 1e-12 {
+		t.Errorf("expected distance 1, got %g", got)
+	}
+
+	// an empty distribution is only identical to another empty distribution
+	if got := Wasserstein([]float64{0, 0}, nil); got != 0 {
+		t.Errorf("expected distance 0 between empty histograms, got %g", got)
+	}
+	if got := Wasserstein([]float64{1, 0}, []float64{0, 0}); !math.IsNaN(got) {
+		t.Errorf("expected NaN to an empty histogram, got %g", got)
+	}
+	if got := WassersteinSamples(nil, nil); got != 0 {
+		t.Errorf("expected distance 0 between empty samples, got %g", got)
+	}
+	if got := WassersteinSamples(nil, []float64{1, 2}); !math.IsNaN(got) {
+		t.Errorf("expected NaN to empty samples, got %g", got)
+	}
+}
+
+func TestColor(t *testing.T) {
+	if got := CIE76([]float64{50, 0, 0}, []float64{53, 4, 0}); got != 5 {
+		t.Errorf("expected CIE76 difference 5, got %g", got)
+	}
+
+	// test data from Sharma, Wu and Dalal (2005)
+	cases := []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{50, 2.6772, -79.7751}, []float64{50, 0, -82.7485}, 2.0425},
+		{[]float64{50, 0, 0}, []float64{50, -1, 2}, 2.3669},
+		{[]float64{50, 2.5, 0}, []float64{73, 25, -18}, 27.1492},
+		{[]float64{50, 2.5, 0}, []float64{50, 3.2592, 0.335}, 1.0},
+		{[]float64{22.7233, 20.0904, -46.694}, []float64{23.0331, 14.973, -42.5619}, 2.0373},
+	}
+	for _, c := range cases {
+		if got := CIEDE2000(c.a, c.b); math.Abs(got-c.want) > 1e-4 {
+			t.Errorf("CIEDE2000(%v, %v): expected %g, got %g", c.a, c.b, c.want, got)
+		}
+		if got := CIEDE2000(c.b, c.a); math.Abs(got-c.want) > 1e-4 {
+			t.Errorf("CIEDE2000 should be symmetric, got %g", got)
+		}
+	}
+}
+
+func TestMinHash(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 100; i++ {
+		a = append(a, fmt.Sprint("w", i))
+		b = append(b, fmt.Sprint("w", i+50))
+	}
+	// exact jaccard distance is 1 - 50/150
+	sa, sb := MinHashSignature(a, 256), MinHashSignature(b, 256)
+	if got := MinHash(sa, sb); math.Abs(got-2.0/3.0) > 0.1 {
+		t.Errorf("expected estimated jaccard distance near 2/3, got %g", got)
+	}
+	if MinHash(sa, MinHashSignature(a, 256)) != 0 {
+		t.Errorf("identical sets should have identical signatures")
+	}
+
+	fa, fb := SimHashFingerprint(a), SimHashFingerprint(append(a[:95:95], "x"))
+	if d := SimHash(fa, fb); d > SimHash(fa, SimHashFingerprint(b[50:])) || d > 0.25 {
+		t.Errorf("near duplicates should have close fingerprints, got %g", d)
+	}
+}
+
+func TestKmer(t *testing.T) {
+	p := NewKmerProfile("acgtacgt", 2)
+	if len(p) != 4 || math.Abs(p["AC"]-2.0/7.0) > 1e-12 {
+		t.Errorf("unexpected 2-mer profile %v", p)
+	}
+	// the same repeat at different lengths has the same profile
+	if got := KmerCosine(3)("ACGACGACG", "ACGACGACGACGACG"); got > 0.01 {
+		t.Errorf("expected small cosine distance for repeats, got %g", got)
+	}
+	if got := KmerD2(2)("AAAA", "CCCC"); got != 2 {
+		t.Errorf("expected d2 distance 2 for disjoint profiles, got %g", got)
+	}
+	if got := KmerCosine(2)("AAAA", "CCCC"); got != 1 {
+		t.Errorf("expected cosine distance 1 for disjoint profiles, got %g", got)
+	}
+}
+
+func TestPairwiseMatrix(t *testing.T) {
+	points := [][]float64{{0, 0}, {3, 4}, {6, 8}, {0, 1}}
+	got := PairwiseMatrix(points, Euclidean, 3)
+	want := []float64{5, 10, 1, 5, math.Sqrt(18), math.Sqrt(85)}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d distances, got %d", len(want), len(got))
+	}
+	for x := range want {
+		if math.Abs(got[x]-want[x]) > 1e-12 {
+			t.Errorf("distance %d: expected %g, got %g", x, want[x], got[x])
+		}
+	}
+	if len(PairwiseMatrix(nil, Euclidean, 0)) != 0 {
+		t.Errorf("expected no distances for no points")
+	}
+}
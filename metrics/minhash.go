@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// MinHash returns the estimated Jaccard distance between the sets represented
+// by two MinHash signatures of the same length (see MinHashSignature): the
+// fraction of positions where the signatures differ. The standard error of
+// the estimate is about 1/sqrt(len(a)).
+func MinHash(a, b []uint64) float64 {
+	if len(a) == 0 {
+		return 0.0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return 1.0 - float64(same)/float64(len(a))
+}
+
+// SimHash returns the estimated angular distance between two SimHash
+// fingerprints (see SimHashFingerprint): the fraction of their 64 bits that
+// differ.
+func SimHash(a, b uint64) float64 {
+	return float64(bits.OnesCount64(a^b)) / 64
+}
+
+// MinHashSignature returns a MinHash signature of size k for a set of tokens
+// (e.g. words or shingles of a document). Signatures created with the same k
+// can be compared with MinHash to estimate the Jaccard distance of the sets
+// without comparing them directly. Duplicate tokens are ignored.
+func MinHashSignature(tokens []string, k int) []uint64 {
+	sig := make([]uint64, k)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for _, t := range tokens {
+		h := hashToken(t)
+		for i := range sig {
+			if v := mix(h + uint64(i)*0x9e3779b97f4a7c15); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// SimHashFingerprint returns the 64-bit SimHash fingerprint of a bag of
+// tokens, where every occurrence of a token votes on each bit. Documents with
+// similar tokens have fingerprints that differ in few bits.
+func SimHashFingerprint(tokens []string) uint64 {
+	var votes [64]int
+	for _, t := range tokens {
+		h := mix(hashToken(t))
+		for b := range votes {
+			if h&(1<<uint(b)) != 0 {
+				votes[b]++
+			} else {
+				votes[b]--
+			}
+		}
+	}
+	var res uint64
+	for b, v := range votes {
+		if v > 0 {
+			res |= 1 << uint(b)
+		}
+	}
+	return res
+}
+
+func hashToken(t string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(t))
+	return h.Sum64()
+}
+
+// mix is the splitmix64 finalizer, which turns a hash and seed into an
+// independent hash function.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+)
+
+// PairwiseMatrix computes the distance between every pair of points using m,
+// and returns the condensed matrix (the upper triangle, row by row) expected
+// by clustering.NewMatrixClusterSet. Rows are computed concurrently by workers
+// goroutines, or runtime.NumCPU() if workers < 1, so m must be safe for
+// concurrent use.
+func PairwiseMatrix(points [][]float64, m func(a, b []float64) float64, workers int) []float64 {
+	n := len(points)
+	res := make([]float64, n*(n-1)/2)
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	// every row writes a disjoint range of the condensed matrix
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rows {
+				x := n*i - i*(i+1)/2
+				for j := i + 1; j < n; j, x = j+1, x+1 {
+					res[x] = m(points[i], points[j])
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		rows <- i
+	}
+	close(rows)
+	wg.Wait()
+	return res
+}
@@ -0,0 +1,44 @@
+package metrics
+
+// Jaccard returns the Jaccard distance between binary feature vectors, where
+// every non-zero coordinate is a present feature: 1 - |a∩b|/|a∪b|. Two vectors
+// without any features have distance 0.
+func Jaccard(a, b []float64) float64 {
+	inter, union := 0, 0
+	for i := range a {
+		x, y := a[i] != 0, b[i] != 0
+		if x && y {
+			inter++
+		}
+		if x || y {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0.0
+	}
+	return 1.0 - float64(inter)/float64(union)
+}
+
+// JaccardSet returns the Jaccard distance 1 - |a∩b|/|a∪b| between two sets
+// of values, such as tags or basket contents. Duplicate values are ignored.
+// Two empty sets have distance 0.
+func JaccardSet[T comparable](a, b []T) float64 {
+	seen := make(map[T]uint8, len(a)+len(b))
+	for _, x := range a {
+		seen[x] |= 1
+	}
+	for _, x := range b {
+		seen[x] |= 2
+	}
+	if len(seen) == 0 {
+		return 0.0
+	}
+	inter := 0
+	for _, v := range seen {
+		if v == 3 {
+			inter++
+		}
+	}
+	return 1.0 - float64(inter)/float64(len(seen))
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import "math"
+
+// Levenshtein returns the edit distance between two strings: the minimum
+// number of single-character insertions, deletions and substitutions needed
+// to change a into b. Characters are compared as runes.
+func Levenshtein(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return float64(prev[len(rb)])
+}
+
+// Damerau returns the optimal string alignment distance between two strings,
+// which is the Levenshtein distance where transposing two adjacent characters
+// also counts as a single edit (but no substring is edited more than once).
+// This suits typos such as "teh" for "the".
+func Damerau(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return float64(d[len(ra)][len(rb)])
+}
+
+// NormalizedLevenshtein returns the Levenshtein distance divided by the length
+// of the longer string, which ranges from 0 (identical) to 1.
+func NormalizedLevenshtein(a, b string) float64 {
+	return normalize(Levenshtein(a, b), a, b)
+}
+
+// NormalizedDamerau returns the Damerau distance divided by the length of the
+// longer string, which ranges from 0 (identical) to 1.
+func NormalizedDamerau(a, b string) float64 {
+	return normalize(Damerau(a, b), a, b)
+}
+
+func normalize(d float64, a, b string) float64 {
+	n := max(len([]rune(a)), len([]rune(b)))
+	if n == 0 {
+		return 0.0
+	}
+	return d / float64(n)
+}
+
+// Jaro returns the Jaro distance (1 - Jaro similarity) between two strings,
+// which ranges from 0 (identical) to 1 (no matching characters).
+func Jaro(a, b string) float64 {
+	return 1.0 - jaro([]rune(a), []rune(b))
+}
+
+// JaroWinkler returns the Jaro-Winkler distance between two strings, which is
+// the Jaro distance with a bonus for a common prefix of up to 4 characters,
+// using the standard scaling factor of 0.1. It ranges from 0 to 1, and is well
+// suited to short strings such as person names.
+func JaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	sim := jaro(ra, rb)
+	prefix := 0
+	for prefix < 4 && prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return 1.0 - (sim + float64(prefix)*0.1*(1-sim))
+}
+
+// jaro returns the Jaro similarity of a and b.
+func jaro(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	window := max(0, max(len(a), len(b))/2-1)
+	ma := make([]bool, len(a))
+	mb := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		lo := max(0, i-window)
+		hi := min(len(b), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !mb[j] && a[i] == b[j] {
+				ma[i], mb[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0.0
+	}
+
+	// count matched characters that are out of order
+	half, j := 0, 0
+	for i := range a {
+		if !ma[i] {
+			continue
+		}
+		for !mb[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			half++
+		}
+		j++
+	}
+	m := float64(matches)
+	t := math.Floor(float64(half) / 2)
+	return (m/float64(len(a)) + m/float64(len(b)) + (m-t)/m) / 3
+}
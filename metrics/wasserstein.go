@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Wasserstein returns the 1-D Wasserstein (earth mover's) distance between two
+// histograms over the same equally spaced bins, in units of bins: the minimum
+// mass times distance needed to move one histogram onto the other. Histograms
+// are normalized to sum to 1. Empty (or all-zero) histograms have distance 0
+// to each other, and NaN to any other histogram, as there is no mass to move.
+// It is computed in linear time from the difference of the cumulative
+// distributions.
+func Wasserstein(a, b []float64) float64 {
+	p, q := distribution(a, 0), distribution(b, 0)
+	if p == nil || q == nil {
+		if p == nil && q == nil {
+			return 0.0
+		}
+		return math.NaN()
+	}
+	cdf, s := 0.0, 0.0
+	for i := range p {
+		cdf += p[i] - q[i]
+		s += math.Abs(cdf)
+	}
+	return s
+}
+
+// WassersteinSamples returns the 1-D Wasserstein distance between the
+// empirical distributions of two sets of samples, such as request latencies,
+// which may have different sizes. The samples do not need to be sorted, and
+// are not modified. Two empty sets of samples have distance 0, and an empty set
+// has distance NaN to any other.
+func WassersteinSamples(a, b []float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		if len(a) == 0 && len(b) == 0 {
+			return 0.0
+		}
+		return math.NaN()
+	}
+	x := append([]float64(nil), a...)
+	y := append([]float64(nil), b...)
+	sort.Float64s(x)
+	sort.Float64s(y)
+
+	// integrate |Fx - Fy| over the merged sample values
+	s := 0.0
+	i, j := 0, 0
+	prev := math.Min(x[0], y[0])
+	for i < len(x) || j < len(y) {
+		var v float64
+		if j >= len(y) || (i < len(x) && x[i] <= y[j]) {
+			v = x[i]
+		} else {
+			v = y[j]
+		}
+		fx := float64(i) / float64(len(x))
+		fy := float64(j) / float64(len(y))
+		s += math.Abs(fx-fy) * (v - prev)
+		prev = v
+		for i < len(x) && x[i] == v {
+			i++
+		}
+		for j < len(y) && y[j] == v {
+			j++
+		}
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+//go:build unix
+
+package clustering
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"syscall"
+)
+
+// MmapClusterSet is a ClusterSet backed by a memory-mapped binary file
+// containing a condensed distance matrix, so that matrices far larger than
+// available RAM can be clustered. The operating system pages distances in and
+// out of memory as needed. It is only available on unix systems.
+//
+// The file must contain n*(n-1)/2 little-endian float64 values, in the same
+// order as NewMatrixClusterSet (e.g. as written by numpy's
+// pdist(x).astype('<f8').tofile(path)). The items enumerated by EachItem are
+// int indexes.
+type MmapClusterSet struct {
+	clusterList
+
+	n    int
+	data []byte
+}
+
+// OpenMmapClusterSet maps the condensed distance matrix file at path, which
+// must contain the distances between n items. Close must be called to unmap
+// the file once clustering is complete.
+func OpenMmapClusterSet(path string, n int) (*MmapClusterSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := int64(n) * int64(n-1) / 2 * 8
+	if fi.Size() != size {
+		return nil, fmt.Errorf("clustering: %s has %d bytes, expected %d for %d items",
+			path, fi.Size(), size, n)
+	}
+
+	m := &MmapClusterSet{n: n}
+	if size > 0 {
+		m.data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.clusters = make([][]ClusterItem, n)
+	for i := range m.clusters {
+		m.clusters[i] = []ClusterItem{i}
+	}
+	return m, nil
+}
+
+// Distance reads the distance between the two items from the mapped file.
+func (m *MmapClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	i, j := item1.(int), item2.(int)
+	if i == j {
+		return 0.0
+	}
+	x := condensedIndex(m.n, i, j) * 8
+	return math.Float64frombits(binary.LittleEndian.Uint64(m.data[x : x+8]))
+}
+
+// ItemDistance implements ItemDistanceSet, as the distance does not depend on
+// the clusters of the items.
+func (m *MmapClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return m.Distance(-1, -1, item1, item2), true
+}
+
+// Close unmaps the file. The ClusterSet must not be used afterwards.
+func (m *MmapClusterSet) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	err := syscall.Munmap(m.data)
+	m.data = nil
+	return err
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package clustering
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMmapClusterSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dists.bin")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.Write(f, binary.LittleEndian, testLineMatrix())
+	f.Close()
+
+	if _, err = OpenMmapClusterSet(path, 5); err == nil {
+		t.Errorf("expected size mismatch error")
+	}
+	m, err := OpenMmapClusterSet(path, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if m.Distance(0, 0, 3, 1) != 6 {
+		t.Errorf("unexpected distance %g", m.Distance(0, 0, 3, 1))
+	}
+	Cluster(m, Threshold(2), SingleLinkage())
+	if m.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", m.Count())
+	}
+}
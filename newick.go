@@ -0,0 +1,126 @@
+package clustering
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteNewick writes the dendrogram as a Newick tree, so that it can be opened
+// in phylogenetic viewers such as FigTree or iTOL. Branch lengths are the
+// difference between the heights of a node and its parent, where leaves have
+// height 0. Leaves are labeled with their items, and leaves containing several
+// items are written as zero-length polytomies. If clustering stopped before
+// reaching a single cluster, the remaining roots are joined at the height of
+// the tallest one.
+//
+// Node annotations are written as comments in the extended format read by
+// FigTree, e.g. "(a,b)[&color=red,score=0.5]:1".
+func (d *Dendrogram) WriteNewick(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	roots := d.Roots()
+	if len(roots) == 1 {
+		d.writeNewickNode(bw, roots[0], -1)
+	} else if len(roots) > 1 {
+		top := 0.0
+		for _, r := range roots {
+			top = max(top, d.nodeHeight(r))
+		}
+		bw.WriteByte('(')
+		for i, r := range roots {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			d.writeNewickNode(bw, r, top)
+		}
+		bw.WriteByte(')')
+	}
+	bw.WriteString(";\n")
+	return bw.Flush()
+}
+
+// nodeHeight returns the height of a node, where leaves have height 0.
+func (d *Dendrogram) nodeHeight(node int) float64 {
+	if node < len(d.Leaves) {
+		return 0.0
+	}
+	return d.Merges[node-len(d.Leaves)].Height
+}
+
+// writeNewickNode writes the subtree of node, whose parent is at height top,
+// or without a branch length if top < 0.
+func (d *Dendrogram) writeNewickNode(w *bufio.Writer, node int, top float64) {
+	h := d.nodeHeight(node)
+	if node < len(d.Leaves) {
+		items := d.Leaves[node]
+		if len(items) == 1 {
+			w.WriteString(newickLabel(fmt.Sprint(items[0])))
+		} else {
+			w.WriteByte('(')
+			for i, x := range items {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				w.WriteString(newickLabel(fmt.Sprint(x)))
+				w.WriteString(":0")
+			}
+			w.WriteByte(')')
+		}
+	} else {
+		m := d.Merges[node-len(d.Leaves)]
+		w.WriteByte('(')
+		d.writeNewickNode(w, m.A, h)
+		w.WriteByte(',')
+		d.writeNewickNode(w, m.B, h)
+		w.WriteByte(')')
+	}
+	d.writeNewickAnnotations(w, node)
+	if top >= 0 {
+		fmt.Fprintf(w, ":%g", top-h)
+	}
+}
+
+// writeNewickAnnotations writes the annotations of node as a comment, sorted
+// by name.
+func (d *Dendrogram) writeNewickAnnotations(w *bufio.Writer, node int) {
+	ann := d.Annotations[node]
+	if len(ann) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(ann))
+	for k := range ann {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.WriteString("[&")
+	for i, k := range keys {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(newickAnnotationValue(k))
+		w.WriteByte('=')
+		w.WriteString(newickAnnotationValue(fmt.Sprint(ann[k])))
+	}
+	w.WriteByte(']')
+}
+
+// newickAnnotationValue double-quotes a value if it contains characters that
+// are special within an annotation comment.
+func newickAnnotationValue(s string) string {
+	if s != "" && !strings.ContainsAny(s, "[]\",= \t\n") {
+		return s
+	}
+	return strconv.Quote(strings.NewReplacer("[", "(", "]", ")").Replace(s))
+}
+
+// newickLabel quotes a label if it contains characters that are special in
+// Newick, doubling any single quotes.
+func newickLabel(s string) string {
+	if s != "" && !strings.ContainsAny(s, "()[]':;, \t\n") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
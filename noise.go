@@ -0,0 +1,183 @@
+package clustering
+
+import (
+	"math"
+	"sort"
+)
+
+// NoiseFilter is a ClusterSet wrapper that excludes noise items from
+// clustering. Excluding outliers before agglomeration prevents chains of
+// outliers from distorting single and average linkage results.
+type NoiseFilter struct {
+	cs ClusterSet
+
+	// ids maps the filtered cluster ids to the underlying cluster ids.
+	ids   []int
+	noise []ClusterItem
+}
+
+// FilterNoise identifies items whose nearest-neighbor distance exceeds the
+// given percentile (0-100) of all nearest-neighbor distances, and returns a
+// wrapper that excludes every cluster consisting only of such items. Every
+// such item is reported by Noise, whether or not its cluster was excluded. This requires computing the
+// distance between every pair of items.
+//
+// As Distance is only defined between items in separate clusters, nearest
+// neighbors are only searched for in other clusters. Items that were already
+// clustered together (e.g. by PremergeWithin) do not count as each other's
+// neighbors.
+func FilterNoise(c ClusterSet, percentile float64) *NoiseFilter {
+	var items []ClusterItem
+	var clusters []int
+	c.EachCluster(-1, func(cluster int) {
+		c.EachItem(cluster, func(x ClusterItem) {
+			items = append(items, x)
+			clusters = append(clusters, cluster)
+		})
+	})
+
+	nn := make([]float64, len(items))
+	for i := range nn {
+		nn[i] = math.Inf(1)
+	}
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if clusters[i] == clusters[j] {
+				continue
+			}
+			d := c.Distance(clusters[i], clusters[j], items[i], items[j])
+			nn[i] = math.Min(nn[i], d)
+			nn[j] = math.Min(nn[j], d)
+		}
+	}
+	sorted := append([]float64{}, nn...)
+	sort.Float64s(sorted)
+	cutoff := percentileOf(sorted, percentile)
+
+	f := &NoiseFilter{cs: c}
+	allNoise := make(map[int]bool)
+	for i, x := range items {
+		isNoise := nn[i] > cutoff
+		if isNoise {
+			f.noise = append(f.noise, x)
+		}
+		if v, ok := allNoise[clusters[i]]; !ok || v {
+			allNoise[clusters[i]] = isNoise
+		}
+	}
+	c.EachCluster(-1, func(cluster int) {
+		if !allNoise[cluster] {
+			f.ids = append(f.ids, cluster)
+		}
+	})
+	return f
+}
+
+// Noise returns every item whose nearest-neighbor distance exceeded the
+// cutoff. This includes the items of every excluded cluster, but also noise
+// items that share a cluster with non-noise items: their cluster is not
+// excluded, so they are still clustered along with it.
+func (f *NoiseFilter) Noise() []ClusterItem {
+	return f.noise
+}
+
+// Count returns the number of non-noise clusters.
+func (f *NoiseFilter) Count() int {
+	return len(f.ids)
+}
+
+// EachCluster enumerates every non-noise cluster id "after" start.
+func (f *NoiseFilter) EachCluster(start int, cb func(cluster int)) {
+	for i := start + 1; i < len(f.ids); i++ {
+		cb(i)
+	}
+}
+
+// EachItem enumerates every item from the cluster.
+func (f *NoiseFilter) EachItem(cluster int, cb func(item ClusterItem)) {
+	f.cs.EachItem(f.ids[cluster], cb)
+}
+
+// Distance computes the distance between two items in separate clusters.
+func (f *NoiseFilter) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	return f.cs.Distance(f.ids[c1], f.ids[c2], item1, item2)
+}
+
+// EachItemDistance implements OptimizedClusterSet when the underlying
+// ClusterSet does.
+func (f *NoiseFilter) EachItemDistance(c1, c2 int, item1 ClusterItem, cb func(ClusterItem, float64)) {
+	if ocs, ok := f.cs.(OptimizedClusterSet); ok {
+		ocs.EachItemDistance(f.ids[c1], f.ids[c2], item1, cb)
+		return
+	}
+	f.EachItem(c2, func(item2 ClusterItem) {
+		cb(item2, f.Distance(c1, c2, item1, item2))
+	})
+}
+
+// ItemDistance implements ItemDistanceSet when the underlying ClusterSet does.
+func (f *NoiseFilter) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return itemDistanceOf(f.cs, item1, item2)
+}
+
+// Coordinates implements VectorClusterSet when the underlying ClusterSet does.
+func (f *NoiseFilter) Coordinates(item ClusterItem) []float64 {
+	return coordinatesOf(f.cs, item)
+}
+
+// Centroid implements VectorClusterSet when the underlying ClusterSet does.
+func (f *NoiseFilter) Centroid(cluster int) []float64 {
+	return centroidOf(f.cs, f.ids[cluster])
+}
+
+// Merge the two clusters together. The lower cluster id is always kept, and
+// the last cluster is swapped into the place of the merged cluster.
+func (f *NoiseFilter) Merge(i, j int) (kept, swappedIn int) {
+	if j < i {
+		i, j = j, i
+	}
+	f.ids = mergeSubset(f.cs, f.ids, i, j)
+	return i, len(f.ids)
+}
+
+// mergeSubset merges subset clusters i < j, where ids maps subset cluster ids
+// to the underlying ClusterSet's cluster ids. Cluster i is kept, and the last
+// subset cluster is moved into position j. Returns the updated ids.
+func mergeSubset(c ClusterSet, ids []int, i, j int) []int {
+	ui, uj := ids[i], ids[j]
+	kept, swappedIn := c.Merge(ui, uj)
+	removed := uj
+	if kept == uj {
+		removed = ui
+	}
+	if swappedIn != removed {
+		for x, u := range ids {
+			if u == swappedIn {
+				ids[x] = removed
+			}
+		}
+	}
+	ids[i] = kept
+
+	last := len(ids) - 1
+	ids[j] = ids[last]
+	return ids[:last]
+}
+
+// percentileOf returns the p-th percentile (0-100) of sorted values, using
+// linear interpolation between the closest ranks.
+func percentileOf(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	pos := (p / 100.0) * float64(len(sorted)-1)
+	if pos <= 0 {
+		return sorted[0]
+	}
+	if pos >= float64(len(sorted)-1) {
+		return sorted[len(sorted)-1]
+	}
+	lo := int(pos)
+	frac := pos - float64(lo)
+	return sorted[lo] + frac*(sorted[lo+1]-sorted[lo])
+}
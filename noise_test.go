@@ -0,0 +1,62 @@
+package clustering
+
+import (
+	"fmt"
+	"testing"
+)
+
+// strictClusterSet reports any Distance call between items of the same
+// cluster, which is outside the ClusterSet contract.
+type strictClusterSet struct {
+	ClusterSet
+	t *testing.T
+}
+
+func (s *strictClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	if c1 == c2 {
+		s.t.Errorf("Distance called within cluster %d for %v and %v", c1, item1, item2)
+	}
+	return s.ClusterSet.Distance(c1, c2, item1, item2)
+}
+
+func (s *strictClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return itemDistanceOf(s.ClusterSet, item1, item2)
+}
+
+func TestFilterNoise(t *testing.T) {
+	// items on a line at positions 0, 0.1, 0.2, 0.25 and 10
+	d := NewDistanceMapClusterSet(DistanceMap{
+		"a": {"b": 0.1, "c": 0.2, "d": 0.25, "e": 10},
+		"b": {"c": 0.1, "d": 0.15, "e": 9.9},
+		"c": {"d": 0.05, "e": 9.8},
+		"d": {"e": 9.75},
+	})
+	// merge c and d beforehand, so that a cluster holds several items
+	var cd []int
+	d.EachCluster(-1, func(cluster int) {
+		d.EachItem(cluster, func(x ClusterItem) {
+			if x == "c" || x == "d" {
+				cd = append(cd, cluster)
+			}
+		})
+	})
+	d.Merge(cd[0], cd[1])
+
+	f := FilterNoise(&strictClusterSet{d, t}, 75)
+	if fmt.Sprint(f.Noise()) != "[e]" {
+		t.Errorf("expected e to be noise, got %v", f.Noise())
+	}
+	if f.Count() != 3 {
+		t.Fatalf("expected 3 non-noise clusters, got %d", f.Count())
+	}
+
+	Cluster(f, Threshold(1), SingleLinkage())
+	if f.Count() != 1 || d.Count() != 2 {
+		t.Errorf("expected the noise to remain separate, got %d and %d clusters", f.Count(), d.Count())
+	}
+	f.EachItem(0, func(x ClusterItem) {
+		if x == "e" {
+			t.Error("noise item was clustered")
+		}
+	})
+}
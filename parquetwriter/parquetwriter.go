@@ -0,0 +1,89 @@
+// Package parquetwriter provides a clustering.ResultWriter that writes final
+// cluster memberships as a Parquet file, using the Apache Arrow Go
+// implementation, so that results can be loaded directly by Spark, DuckDB or
+// Pandas.
+package parquetwriter
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/pbnjay/clustering"
+)
+
+// BatchSize is the number of rows buffered before they are written as a row
+// group.
+const BatchSize = 64 * 1024
+
+// Schema is the schema of the written files: an int64 "cluster" column and a
+// string "item" column, with one row per item.
+var Schema = arrow.NewSchema([]arrow.Field{
+	{Name: "cluster", Type: arrow.PrimitiveTypes.Int64},
+	{Name: "item", Type: arrow.BinaryTypes.String},
+}, nil)
+
+// Writer is a clustering.ResultWriter that writes rows of the form
+// (cluster, item) to a snappy-compressed Parquet file. Items are formatted
+// with fmt.Sprint.
+type Writer struct {
+	fw   *pqarrow.FileWriter
+	b    *array.RecordBuilder
+	rows int
+}
+
+// New creates a Writer that writes a Parquet file to w. As required by
+// clustering.ResultWriter, Close finalizes the file but does not close w.
+func New(w io.Writer) (*Writer, error) {
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	fw, err := pqarrow.NewFileWriter(Schema, noClose{w}, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		fw: fw,
+		b:  array.NewRecordBuilder(memory.DefaultAllocator, Schema),
+	}, nil
+}
+
+// WriteItem records that item is a member of cluster.
+func (p *Writer) WriteItem(cluster int, item clustering.ClusterItem) error {
+	p.b.Field(0).(*array.Int64Builder).Append(int64(cluster))
+	p.b.Field(1).(*array.StringBuilder).Append(fmt.Sprint(item))
+	p.rows++
+	if p.rows >= BatchSize {
+		return p.flush()
+	}
+	return nil
+}
+
+// Close writes any buffered rows and the Parquet footer.
+func (p *Writer) Close() error {
+	err := p.flush()
+	if cerr := p.fw.Close(); err == nil {
+		err = cerr
+	}
+	p.b.Release()
+	return err
+}
+
+func (p *Writer) flush() error {
+	if p.rows == 0 {
+		return nil
+	}
+	rec := p.b.NewRecordBatch()
+	defer rec.Release()
+	p.rows = 0
+	return p.fw.Write(rec)
+}
+
+// noClose hides any Close method of the underlying writer, which the Parquet
+// writer would otherwise call.
+type noClose struct {
+	io.Writer
+}
@@ -0,0 +1,46 @@
+package parquetwriter
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/pbnjay/clustering"
+)
+
+func TestWriter(t *testing.T) {
+	d := clustering.NewDistanceMapClusterSet(clustering.DistanceMap{
+		"a": {"b": 1, "c": 5},
+		"b": {"c": 5},
+	})
+	var buf bytes.Buffer
+	w, err := New(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clustering.ClusterTo(d, clustering.Threshold(2), clustering.SingleLinkage(), w); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(buf.Bytes()), nil,
+		pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Release()
+	if tbl.NumRows() != 3 || tbl.NumCols() != 2 {
+		t.Fatalf("expected 3 rows of 2 columns, got %d of %d", tbl.NumRows(), tbl.NumCols())
+	}
+	clusters := tbl.Column(0).Data().Chunk(0).(*array.Int64)
+	items := tbl.Column(1).Data().Chunk(0).(*array.String)
+	got := make(map[string]int64)
+	for i := 0; i < items.Len(); i++ {
+		got[items.Value(i)] = clusters.Value(i)
+	}
+	if got["a"] != got["b"] || got["a"] == got["c"] {
+		t.Errorf("unexpected memberships %v", got)
+	}
+}
@@ -0,0 +1,53 @@
+package clustering
+
+import "github.com/pbnjay/clustering/metrics"
+
+// Metric computes the distance between two feature vectors. See the metrics
+// package for common implementations.
+type Metric func(a, b []float64) float64
+
+// PointsClusterSet is a ClusterSet over raw feature vectors, where distances
+// are computed on demand from the coordinates using a Metric. This avoids
+// precomputing a full DistanceMap.
+//
+// The items enumerated by EachItem are int indexes into the points, use Point
+// to retrieve the original vector for an item.
+type PointsClusterSet struct {
+	clusterList
+
+	points [][]float64
+	metric Metric
+}
+
+// NewPointsClusterSet creates a ClusterSet with one initial cluster for each
+// point. If metric is nil, metrics.Euclidean is used.
+func NewPointsClusterSet(points [][]float64, metric Metric) *PointsClusterSet {
+	if metric == nil {
+		metric = metrics.Euclidean
+	}
+	p := &PointsClusterSet{
+		points: points,
+		metric: metric,
+	}
+	p.clusters = make([][]ClusterItem, len(points))
+	for i := range points {
+		p.clusters[i] = []ClusterItem{i}
+	}
+	return p
+}
+
+// Distance computes the distance between the points of two items.
+func (p *PointsClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	return p.metric(p.points[item1.(int)], p.points[item2.(int)])
+}
+
+// ItemDistance implements ItemDistanceSet, as the distance does not depend on
+// the clusters of the items.
+func (p *PointsClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return p.Distance(-1, -1, item1, item2), true
+}
+
+// Point returns the feature vector of an item.
+func (p *PointsClusterSet) Point(item ClusterItem) []float64 {
+	return p.points[item.(int)]
+}
@@ -0,0 +1,107 @@
+package clustering
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pbnjay/clustering/metrics"
+)
+
+func TestPointsClusterSet(t *testing.T) {
+	p := NewPointsClusterSet([][]float64{
+		{0, 0}, {0, 1}, {1, 0},
+		{10, 10}, {10, 11}, {11, 10},
+	}, metrics.Manhattan)
+	Cluster(p, Threshold(3), CompleteLinkage())
+	if p.Count() != 2 {
+		t.Fatalf("expected 2 clusters, got %d", p.Count())
+	}
+	p.EachCluster(-1, func(cluster int) {
+		first := -1
+		p.EachItem(cluster, func(x ClusterItem) {
+			if first == -1 {
+				first = x.(int) / 3
+			} else if x.(int)/3 != first {
+				t.Errorf("cluster %d mixes groups", cluster)
+			}
+		})
+	})
+}
+
+func TestVectorLinkages(t *testing.T) {
+	expect := map[string]float64{
+		"centroid": 4.5,
+		"ward":     4.5 * math.Sqrt(4.0/3.0),
+	}
+	for name, lt := range map[string]LinkageType{"centroid": CentroidLinkage(), "ward": WardLinkage()} {
+		h := &HClustering{
+			ClusterSet:     NewPointsClusterSet([][]float64{{0}, {1}, {5}}, nil),
+			Checker:        Threshold(100),
+			LinkageType:    lt,
+			CacheDistances: true,
+		}
+		h.Run()
+		m := h.Dendrogram().Merges
+		if len(m) != 2 || m[0].Height != 1 || math.Abs(m[1].Height-expect[name]) > 1e-9 {
+			t.Errorf("%s: unexpected merges %+v", name, m)
+		}
+	}
+}
+
+func TestVectorLinkageWrappers(t *testing.T) {
+	points := [][]float64{{0, 0}, {1, 0}, {0, 2}, {5, 5}, {6, 5}, {9, 9}}
+	heights := func(c ClusterSet, lt LinkageType) []float64 {
+		var res []float64
+		for _, m := range ClusterWithTree(c, Threshold(100), lt).Merges {
+			res = append(res, m.Height)
+		}
+		return res
+	}
+	same := func(a, b []float64) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if math.Abs(a[i]-b[i]) > 1e-9 {
+				return false
+			}
+		}
+		return true
+	}
+
+	for name, lt := range map[string]func() LinkageType{"centroid": CentroidLinkage, "ward": WardLinkage} {
+		expect := heights(NewPointsClusterSet(points, nil), lt())
+
+		// a ClusterSet without coordinates, where centroids are derived from
+		// the Euclidean distances between items
+		dm := make(DistanceMap)
+		for i := range points {
+			dm[i] = make(map[ClusterItem]float64)
+			for j := i + 1; j < len(points); j++ {
+				dm[i][j] = metrics.Euclidean(points[i], points[j])
+			}
+		}
+
+		for wrapper, c := range map[string]ClusterSet{
+			"sync":       SynchronizedClusterSet(NewPointsClusterSet(points, nil)),
+			"cached":     CachedClusterSet(NewPointsClusterSet(points, nil)),
+			"noise":      FilterNoise(NewPointsClusterSet(points, nil), 100),
+			"centrality": OrderByCentrality(NewPointsClusterSet(points, nil)),
+			"variance":   TrackVariance(NewPointsClusterSet(points, nil)),
+			"distances":  NewDistanceMapClusterSet(dm),
+			"strict":     &strictClusterSet{NewDistanceMapClusterSet(dm), t},
+		} {
+			if got := heights(c, lt()); !same(got, expect) {
+				t.Errorf("%s %s: expected heights %v, got %v", name, wrapper, expect, got)
+			}
+		}
+	}
+
+	// the collapsed duplicate stands in for both copies
+	dups := [][]float64{{0}, {0}, {1}, {5}}
+	expect := heights(NewPointsClusterSet(dups, nil), WardLinkage())
+	got := heights(CollapseDuplicates(NewPointsClusterSet(dups, nil)), WardLinkage())
+	if !same(got, expect[1:]) || math.Abs(got[0]-math.Sqrt(4.0/3.0)) > 1e-9 {
+		t.Errorf("expected weighted heights %v, got %v", expect[1:], got)
+	}
+}
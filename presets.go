@@ -0,0 +1,139 @@
+package clustering
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pbnjay/clustering/metrics"
+)
+
+// Preset bundles a distance function, linkage method and Checker with sane
+// defaults for a common domain. Every field may be overridden before
+// clustering. Presets for arbitrary items define Distance and are used with
+// Cluster, presets for feature vectors define Metric and are used with
+// ClusterPoints.
+type Preset struct {
+	// Name identifies the preset.
+	Name string
+
+	// Distance computes the distance between two items.
+	Distance func(a, b ClusterItem) float64
+
+	// Metric computes the distance between two feature vectors.
+	Metric Metric
+
+	// Linkage is the method used to select clusters to merge.
+	Linkage LinkageType
+
+	// Checker is used to stop clustering.
+	Checker Checker
+
+	// Premerge enables merging every pair of items within PremergeDistance
+	// before clustering (see PremergeWithin). It is disabled in the zero
+	// Preset.
+	Premerge         bool
+	PremergeDistance float64
+}
+
+// Cluster clusters the items using the preset configuration, and returns the
+// resulting ClusterSet.
+func (p Preset) Cluster(items []ClusterItem) ClusterSet {
+	c := NewDistanceFuncClusterSet(items, p.Distance)
+	p.run(c)
+	return c
+}
+
+// ClusterPoints clusters the feature vectors using the preset configuration,
+// and returns the resulting ClusterSet.
+func (p Preset) ClusterPoints(points [][]float64) *PointsClusterSet {
+	c := NewPointsClusterSet(points, p.Metric)
+	p.run(c)
+	return c
+}
+
+func (p Preset) run(c ClusterSet) {
+	if p.Premerge {
+		PremergeWithin(c, p.PremergeDistance)
+	}
+	Cluster(c, p.Checker, p.Linkage)
+}
+
+// Presets provides preset configurations for common domains, e.g.:
+//
+//	clusters := clustering.Presets.GeoPoints(500).ClusterPoints(points)
+var Presets presets
+
+type presets struct{}
+
+// LogDeduplication groups log lines (string items) that differ only in their
+// variable fields. Numbers are masked out and lines are compared by the
+// Jaccard distance of their token sets, using complete linkage so that every
+// pair of lines in a group shares at least 70% of its tokens. Exact
+// duplicates are merged up front.
+func (presets) LogDeduplication() Preset {
+	return Preset{
+		Name:     "log-deduplication",
+		Distance: logLineDistance,
+		Linkage:  CompleteLinkage(),
+		Checker:  Threshold(0.3),
+		Premerge: true,
+	}
+}
+
+// GeoPoints groups geographic points, given as {latitude, longitude} vectors
+// in degrees, using the great-circle distance in meters.
+// Complete linkage is used so that every group fits within a circle of
+// roughly radiusMeters.
+func (presets) GeoPoints(radiusMeters float64) Preset {
+	return Preset{
+		Name:    "geo-points",
+		Metric:  metrics.Haversine,
+		Linkage: CompleteLinkage(),
+		Checker: Threshold(2 * radiusMeters),
+	}
+}
+
+// GeneExpression groups expression profiles, given as feature vectors, using
+// the standard correlation distance (1 - Pearson correlation) and average
+// linkage, stopping before two groups are merged whose profiles have an
+// average pairwise correlation below 0.5.
+func (presets) GeneExpression() Preset {
+	return Preset{
+		Name:    "gene-expression",
+		Metric:  metrics.Pearson,
+		Linkage: AverageLinkage(),
+		Checker: Threshold(0.5),
+	}
+}
+
+/////////////
+
+// logLineDistance returns the Jaccard distance between the token sets of two
+// log lines, after masking out numbers.
+func logLineDistance(a, b ClusterItem) float64 {
+	ta, tb := logTokens(a.(string)), logTokens(b.(string))
+	if len(ta) == 0 && len(tb) == 0 {
+		return 0
+	}
+	both := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			both++
+		}
+	}
+	return 1.0 - float64(both)/float64(len(ta)+len(tb)-both)
+}
+
+func logTokens(s string) map[string]struct{} {
+	res := make(map[string]struct{})
+	for _, t := range strings.Fields(s) {
+		t = strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return '#'
+			}
+			return r
+		}, t)
+		res[t] = struct{}{}
+	}
+	return res
+}
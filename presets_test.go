@@ -0,0 +1,53 @@
+package clustering
+
+import "testing"
+
+func TestPresets(t *testing.T) {
+	logs := Presets.LogDeduplication().Cluster([]ClusterItem{
+		"connection from 10.0.0.1 port 22 accepted",
+		"connection from 10.0.0.7 port 22 accepted",
+		"connection from 10.0.0.7 port 22 accepted",
+		"disk /dev/sda1 is 91% full",
+		"disk /dev/sda2 is 95% full",
+	})
+	if logs.Count() != 2 {
+		t.Errorf("expected 2 groups of log lines, got %d", logs.Count())
+	}
+
+	geo := Presets.GeoPoints(1000).ClusterPoints([][]float64{
+		{51.5007, -0.1246}, {51.5014, -0.1419},
+		{48.8584, 2.2945}, {48.8606, 2.3376},
+	})
+	if geo.Count() != 3 {
+		t.Errorf("expected 3 groups of points, got %d", geo.Count())
+	}
+
+	genes := Presets.GeneExpression().ClusterPoints([][]float64{
+		{1, 2, 3, 4}, {2, 4, 6, 9},
+		{4, 3, 2, 1}, {8, 6, 3, 2},
+	})
+	if genes.Count() != 2 {
+		t.Errorf("expected 2 groups of genes, got %d", genes.Count())
+	}
+}
+
+func TestPresetPremerge(t *testing.T) {
+	items := []ClusterItem{"a1", "a2", "b1"}
+	p := Preset{
+		Distance: func(a, b ClusterItem) float64 {
+			if a.(string)[0] == b.(string)[0] {
+				return 0
+			}
+			return 1
+		},
+		Linkage: SingleLinkage(),
+		Checker: Threshold(-1),
+	}
+	if n := p.Cluster(items).Count(); n != 3 {
+		t.Errorf("zero Premerge should not merge duplicates, got %d clusters", n)
+	}
+	p.Premerge = true
+	if n := p.Cluster(items).Count(); n != 2 {
+		t.Errorf("Premerge should merge duplicates, got %d clusters", n)
+	}
+}
@@ -0,0 +1,74 @@
+package clustering
+
+// Prune returns a copy of the dendrogram where every subtree containing fewer
+// than minSize items is collapsed into a single leaf, so that stray items no
+// longer clutter the tree. Collapsed leaves are annotated with "noise" set to
+// true, which is included when exporting the tree. Merges between subtrees of
+// at least minSize items are kept, and node annotations are carried over to
+// the renumbered nodes.
+func (d *Dendrogram) Prune(minSize int) *Dendrogram {
+	nl := len(d.Leaves)
+	total := nl + len(d.Merges)
+	size := make([]int, total)
+	parent := make([]int, total)
+	for n := range parent {
+		parent[n] = -1
+	}
+	for n, items := range d.Leaves {
+		size[n] = len(items)
+	}
+	for k, m := range d.Merges {
+		size[nl+k] = m.Size
+		parent[m.A] = nl + k
+		parent[m.B] = nl + k
+	}
+
+	res := &Dendrogram{}
+	ids := make([]int, total)
+	var noise []int
+	for n := 0; n < total; n++ {
+		ids[n] = -1
+		small := size[n] < minSize
+		if small && parent[n] != -1 && size[parent[n]] < minSize {
+			// part of a larger subtree that is collapsed
+			continue
+		}
+		if n < nl {
+			ids[n] = len(res.Leaves)
+			res.Leaves = append(res.Leaves, d.Leaves[n])
+		} else if small {
+			ids[n] = len(res.Leaves)
+			res.Leaves = append(res.Leaves, d.Items(n))
+		} else {
+			continue
+		}
+		if small {
+			noise = append(noise, ids[n])
+		}
+	}
+	for k, m := range d.Merges {
+		if size[nl+k] < minSize {
+			continue
+		}
+		ids[nl+k] = len(res.Leaves) + len(res.Merges)
+		res.Merges = append(res.Merges, Merge{
+			A:      ids[m.A],
+			B:      ids[m.B],
+			Height: m.Height,
+			Size:   m.Size,
+		})
+	}
+
+	for n, ann := range d.Annotations {
+		if ids[n] == -1 {
+			continue
+		}
+		for k, v := range ann {
+			res.Annotate(ids[n], k, v)
+		}
+	}
+	for _, n := range noise {
+		res.Annotate(n, "noise", true)
+	}
+	return res
+}
@@ -0,0 +1,163 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ReferenceClusterSet is a small in-memory ClusterSet with fully deterministic
+// behavior, which checks its invariants on every call and panics if they are
+// violated. It is intended to be embedded in fuzz and property tests of code
+// built on top of this package.
+//
+// Items are the ints 0..n-1. Clusters are always enumerated in id order, items
+// within a cluster are enumerated in ascending order, and Merge always keeps
+// the lower cluster id and swaps the last cluster into the removed position.
+type ReferenceClusterSet struct {
+	dists    [][]float64
+	clusters [][]int
+}
+
+// NewReferenceClusterSet creates a ReferenceClusterSet with one singleton
+// cluster per row of the symmetric n×n distance matrix dists. It panics if the
+// matrix is not square and symmetric, or contains negative or NaN distances.
+func NewReferenceClusterSet(dists [][]float64) *ReferenceClusterSet {
+	n := len(dists)
+	for i, row := range dists {
+		if len(row) != n {
+			panic(fmt.Sprintf("clustering: reference distance row %d has length %d, want %d", i, len(row), n))
+		}
+		for j, d := range row {
+			if math.IsNaN(d) || d < 0 {
+				panic(fmt.Sprintf("clustering: invalid reference distance %v at (%d,%d)", d, i, j))
+			}
+			if dists[j][i] != d {
+				panic(fmt.Sprintf("clustering: reference distances are not symmetric at (%d,%d)", i, j))
+			}
+		}
+	}
+
+	r := &ReferenceClusterSet{dists: dists, clusters: make([][]int, n)}
+	for i := range r.clusters {
+		r.clusters[i] = []int{i}
+	}
+	return r
+}
+
+// Count returns the number of clusters in the set.
+func (r *ReferenceClusterSet) Count() int {
+	r.checkInvariants()
+	return len(r.clusters)
+}
+
+// EachCluster enumerates every cluster id "after" start, in order.
+func (r *ReferenceClusterSet) EachCluster(start int, cb func(cluster int)) {
+	r.checkInvariants()
+	if start < -1 {
+		panic(fmt.Sprintf("clustering: invalid EachCluster start %d", start))
+	}
+	n := len(r.clusters)
+	for i := start + 1; i < n; i++ {
+		cb(i)
+		if len(r.clusters) != n {
+			panic("clustering: ClusterSet modified during EachCluster")
+		}
+	}
+}
+
+// EachItem enumerates every item from the cluster, in ascending order.
+func (r *ReferenceClusterSet) EachItem(cluster int, cb func(item ClusterItem)) {
+	r.checkCluster(cluster)
+	for _, x := range r.clusters[cluster] {
+		cb(x)
+	}
+}
+
+// Distance returns the distance between two items, and verifies that they
+// belong to the given (separate) clusters.
+func (r *ReferenceClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	r.checkCluster(c1)
+	r.checkCluster(c2)
+	if c1 == c2 {
+		panic(fmt.Sprintf("clustering: Distance called within cluster %d", c1))
+	}
+	a, b := r.checkItem(c1, item1), r.checkItem(c2, item2)
+	return r.dists[a][b]
+}
+
+// ItemDistance implements ItemDistanceSet, and verifies that both items exist.
+func (r *ReferenceClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return r.dists[r.checkIndex(item1)][r.checkIndex(item2)], true
+}
+
+// Merge the two clusters together, keeping the lower id and swapping the last
+// cluster into the place of the higher id.
+func (r *ReferenceClusterSet) Merge(i, j int) (kept, swappedIn int) {
+	r.checkCluster(i)
+	r.checkCluster(j)
+	if i == j {
+		panic(fmt.Sprintf("clustering: cannot merge cluster %d with itself", i))
+	}
+	if j < i {
+		i, j = j, i
+	}
+
+	last := len(r.clusters) - 1
+	merged := append(r.clusters[i], r.clusters[j]...)
+	sort.Ints(merged)
+	r.clusters[i] = merged
+	r.clusters[j] = r.clusters[last]
+	r.clusters = r.clusters[:last]
+
+	r.checkInvariants()
+	return i, last
+}
+
+func (r *ReferenceClusterSet) checkCluster(cluster int) {
+	if cluster < 0 || cluster >= len(r.clusters) {
+		panic(fmt.Sprintf("clustering: cluster %d out of range [0,%d)", cluster, len(r.clusters)))
+	}
+}
+
+func (r *ReferenceClusterSet) checkIndex(item ClusterItem) int {
+	x, ok := item.(int)
+	if !ok {
+		panic(fmt.Sprintf("clustering: unexpected item type %T", item))
+	}
+	if x < 0 || x >= len(r.dists) {
+		panic(fmt.Sprintf("clustering: item %d out of range [0,%d)", x, len(r.dists)))
+	}
+	return x
+}
+
+func (r *ReferenceClusterSet) checkItem(cluster int, item ClusterItem) int {
+	x := r.checkIndex(item)
+	i := sort.SearchInts(r.clusters[cluster], x)
+	if i >= len(r.clusters[cluster]) || r.clusters[cluster][i] != x {
+		panic(fmt.Sprintf("clustering: item %d is not in cluster %d", x, cluster))
+	}
+	return x
+}
+
+// checkInvariants verifies that every item belongs to exactly one non-empty
+// cluster.
+func (r *ReferenceClusterSet) checkInvariants() {
+	seen := make([]bool, len(r.dists))
+	total := 0
+	for c, items := range r.clusters {
+		if len(items) == 0 {
+			panic(fmt.Sprintf("clustering: cluster %d is empty", c))
+		}
+		for _, x := range items {
+			if seen[x] {
+				panic(fmt.Sprintf("clustering: item %d is in more than one cluster", x))
+			}
+			seen[x] = true
+			total++
+		}
+	}
+	if total != len(r.dists) {
+		panic(fmt.Sprintf("clustering: %d items in clusters, want %d", total, len(r.dists)))
+	}
+}
@@ -0,0 +1,48 @@
+package clustering
+
+import "testing"
+
+func TestReferenceClusterSet(t *testing.T) {
+	// run every linkage over the reference set, which panics on any misuse
+	n := 12
+	dists := make([][]float64, n)
+	for i := range dists {
+		dists[i] = make([]float64, n)
+		for j := range dists[i] {
+			d := float64((i*7+j*7)%11) + float64(i-j)*float64(i-j)
+			if i == j {
+				d = 0
+			}
+			dists[i][j] = d
+		}
+	}
+
+	for _, lt := range []LinkageType{CompleteLinkage(), SingleLinkage(), AverageLinkage(), GeometricMeanLinkage()} {
+		for _, cache := range []bool{false, true} {
+			r := NewReferenceClusterSet(dists)
+			h := &HClustering{
+				ClusterSet:     r,
+				Checker:        MaxClusters(1),
+				LinkageType:    lt,
+				CacheDistances: cache,
+			}
+			h.Run()
+			if r.Count() != 1 || len(h.Dendrogram().Merges) != n-1 {
+				t.Errorf("%s: expected a single cluster after %d merges", Describe(lt).Name, n-1)
+			}
+		}
+	}
+
+	// distances within a cluster are only available from ItemDistance
+	r := NewReferenceClusterSet(dists)
+	r.Merge(0, 1)
+	if d, ok := r.ItemDistance(0, 1); !ok || d != dists[0][1] {
+		t.Errorf("expected item distance %g, got %g", dists[0][1], d)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a Distance within a cluster")
+		}
+	}()
+	r.Distance(0, 0, 0, 1)
+}
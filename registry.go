@@ -0,0 +1,215 @@
+package clustering
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CheckerFactory constructs a Checker from a declarative configuration. The
+// inner checkers described by the configuration have already been
+// constructed.
+type CheckerFactory func(params map[string]interface{}, inner []Checker) (Checker, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]CheckerFactory{}
+)
+
+// RegisterChecker makes a Checker available to NewChecker and ParseChecker
+// under the given name, replacing any previous registration. Every Checker
+// provided by this package is registered using the same name as its
+// Description. ContextChecker is available as "context", which is reserved and
+// bound to the context given to NewCheckerContext or ParseCheckerContext, so
+// RegisterChecker panics if it is used.
+func RegisterChecker(name string, f CheckerFactory) {
+	if name == "context" {
+		panic(`clustering: the checker name "context" is reserved`)
+	}
+	registryMu.Lock()
+	registry[name] = f
+	registryMu.Unlock()
+}
+
+// NewChecker constructs a (possibly composed) Checker from its configuration,
+// using the registered CheckerFactory for each name. Configurations use the
+// same schema as Description, so the Description of any Checker provided by
+// this package may be used to reconstruct it.
+func NewChecker(cfg Description) (Checker, error) {
+	return NewCheckerContext(context.Background(), cfg)
+}
+
+// NewCheckerContext is like NewChecker, but every "context" checker in the
+// configuration stops clustering once ctx is cancelled (see ContextChecker).
+// With NewChecker, "context" checkers are never cancelled.
+func NewCheckerContext(ctx context.Context, cfg Description) (Checker, error) {
+	registryMu.RLock()
+	f, ok := registry[cfg.Name]
+	registryMu.RUnlock()
+	if !ok && cfg.Name != "context" {
+		return nil, fmt.Errorf("clustering: unknown checker %q", cfg.Name)
+	}
+	inner := make([]Checker, len(cfg.Inner))
+	for i, x := range cfg.Inner {
+		var err error
+		if inner[i], err = NewCheckerContext(ctx, x); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Name == "context" {
+		if err := numInner(inner, 1); err != nil {
+			return nil, fmt.Errorf("clustering: checker %q: %s", cfg.Name, err)
+		}
+		return ContextChecker(ctx, inner[0]), nil
+	}
+	chk, err := f(cfg.Params, inner)
+	if err != nil {
+		return nil, fmt.Errorf("clustering: checker %q: %s", cfg.Name, err)
+	}
+	return chk, nil
+}
+
+// ParseChecker constructs a Checker from a JSON configuration, e.g.:
+//
+//	{"name": "and", "inner": [
+//	    {"name": "threshold", "params": {"threshold": 0.4}},
+//	    {"name": "max-clusters", "params": {"max": 10}}
+//	]}
+//
+// YAML configurations can be decoded into a Description and passed to
+// NewChecker instead.
+func ParseChecker(data []byte) (Checker, error) {
+	return ParseCheckerContext(context.Background(), data)
+}
+
+// ParseCheckerContext is like ParseChecker, but every "context" checker in the
+// configuration stops clustering once ctx is cancelled (see ContextChecker).
+func ParseCheckerContext(ctx context.Context, data []byte) (Checker, error) {
+	var cfg Description
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return NewCheckerContext(ctx, cfg)
+}
+
+/////////////
+
+func paramFloat(params map[string]interface{}, name string) (float64, error) {
+	switch v := params[name].(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case nil:
+		return 0, fmt.Errorf("missing parameter %q", name)
+	}
+	return 0, fmt.Errorf("parameter %q is not a number", name)
+}
+
+func paramInt(params map[string]interface{}, name string) (int, error) {
+	v, err := paramFloat(params, name)
+	if err == nil && v != float64(int(v)) {
+		err = fmt.Errorf("parameter %q is not an integer", name)
+	}
+	return int(v), err
+}
+
+func numInner(inner []Checker, n int) error {
+	if len(inner) != n {
+		return fmt.Errorf("expected %d inner checkers, got %d", n, len(inner))
+	}
+	return nil
+}
+
+func floatChecker(name string, f func(float64) Checker) CheckerFactory {
+	return func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		v, err := paramFloat(params, name)
+		if err != nil {
+			return nil, err
+		}
+		return f(v), numInner(inner, 0)
+	}
+}
+
+func intChecker(name string, f func(int) Checker) CheckerFactory {
+	return func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		v, err := paramInt(params, name)
+		if err != nil {
+			return nil, err
+		}
+		return f(v), numInner(inner, 0)
+	}
+}
+
+func init() {
+	RegisterChecker("threshold", floatChecker("threshold", Threshold))
+	RegisterChecker("max-clusters", intChecker("max", MaxClusters))
+	RegisterChecker("elbow", floatChecker("factor", Elbow))
+	RegisterChecker("kneedle", floatChecker("sensitivity", Kneedle))
+	RegisterChecker("max-merges", intChecker("max", MaxMerges))
+	RegisterChecker("relative-jump", floatChecker("ratio", RelativeJump))
+	RegisterChecker("percentile-threshold", floatChecker("percentile", PercentileThreshold))
+	RegisterChecker("max-merge-size", intChecker("size", MaxMergeSize))
+	RegisterChecker("max-variance", floatChecker("sd", MaxVariance))
+	RegisterChecker("silhouette", floatChecker("tolerance", Silhouette))
+
+	RegisterChecker("inconsistent", func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		k, err := paramFloat(params, "k")
+		if err != nil {
+			return nil, err
+		}
+		window, err := paramInt(params, "window")
+		if err != nil {
+			return nil, err
+		}
+		return Inconsistent(k, window), numInner(inner, 0)
+	})
+	RegisterChecker("adaptive-threshold", func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		k, err := paramFloat(params, "k")
+		if err != nil {
+			return nil, err
+		}
+		n, err := paramInt(params, "min-merges")
+		if err != nil {
+			return nil, err
+		}
+		return AdaptiveThreshold(k, n), numInner(inner, 0)
+	})
+
+	RegisterChecker("and", func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		return AndChecker(inner...), nil
+	})
+	RegisterChecker("or", func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		return OrChecker(inner...), nil
+	})
+	RegisterChecker("not", func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		if err := numInner(inner, 1); err != nil {
+			return nil, err
+		}
+		return NotChecker(inner[0]), nil
+	})
+	RegisterChecker("tree-log", func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		if err := numInner(inner, 1); err != nil {
+			return nil, err
+		}
+		return TreeLog(inner[0]), nil
+	})
+	RegisterChecker("time-budget", func(params map[string]interface{}, inner []Checker) (Checker, error) {
+		if err := numInner(inner, 1); err != nil {
+			return nil, err
+		}
+		s, ok := params["budget"].(string)
+		if !ok {
+			return nil, fmt.Errorf("parameter %q must be a duration string", "budget")
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		return TimeBudget(d, inner[0]), nil
+	})
+}
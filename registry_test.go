@@ -0,0 +1,49 @@
+package clustering
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseChecker(t *testing.T) {
+	chk, err := ParseChecker([]byte(`{"name": "and", "inner": [
+		{"name": "threshold", "params": {"threshold": 0.4}},
+		{"name": "not", "inner": [{"name": "max-clusters", "params": {"max": 10}}]}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseChecker failed: %s", err)
+	}
+	want := AndChecker(Threshold(0.4), NotChecker(MaxClusters(10)))
+	if !reflect.DeepEqual(Describe(chk), Describe(want)) {
+		t.Errorf("unexpected checker %+v", Describe(chk))
+	}
+
+	// descriptions round-trip through JSON
+	orig := OrChecker(Inconsistent(2, 5), AdaptiveThreshold(3, 2), RelativeJump(2))
+	b, _ := json.Marshal(Describe(orig))
+	if chk, err = ParseChecker(b); err != nil {
+		t.Fatalf("ParseChecker failed on %s: %s", b, err)
+	}
+	b2, _ := json.Marshal(Describe(chk))
+	if string(b) != string(b2) {
+		t.Errorf("description did not round-trip: %s vs %s", b, b2)
+	}
+
+	if _, err = ParseChecker([]byte(`{"name": "threshold"}`)); err == nil {
+		t.Errorf("expected error for missing parameter")
+	}
+	if _, err = ParseChecker([]byte(`{"name": "nonsense"}`)); err == nil {
+		t.Errorf("expected error for unknown checker")
+	}
+
+	// the reserved name cannot be replaced
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a context checker to panic")
+		}
+	}()
+	RegisterChecker("context", func(map[string]interface{}, []Checker) (Checker, error) {
+		return Threshold(1), nil
+	})
+}
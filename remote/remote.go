@@ -0,0 +1,305 @@
+// Package remote provides a clustering.ClusterSet that fetches pairwise
+// distances from a remote scoring service over HTTP, for similarity models that
+// run as a separate service. Requests are batched across items, and every
+// distance is cached so that it is only fetched once.
+//
+// The service must accept POST requests with a JSON body of the form
+//
+//	{"pairs": [["a", "b"], ["a", "c"]]}
+//
+// and respond with the distance of each pair, in the same order:
+//
+//	{"distances": [0.25, 0.75]}
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/pbnjay/clustering"
+)
+
+// DefaultBatchSize is the maximum number of pairs per request when
+// ClusterSet.BatchSize is not set.
+const DefaultBatchSize = 1000
+
+// ClusterSet is a ClusterSet of string item IDs, whose distances are fetched
+// from the remote service at URL.
+//
+// Since the ClusterSet interface cannot return errors, the first request error
+// is recorded and reported by Err; distances that cannot be fetched are
+// returned as +Inf, so clustering should be checked for errors afterwards.
+type ClusterSet struct {
+	// URL is the endpoint of the scoring service.
+	URL string
+
+	// Client is used to make requests, or http.DefaultClient if nil.
+	Client *http.Client
+
+	// BatchSize is the maximum number of pairs per request.
+	BatchSize int
+
+	// Context, if set, is used for the requests made while clustering, so
+	// that cancelling it aborts them. The error is then reported by Err.
+	Context context.Context
+
+	clusters [][]clustering.ClusterItem
+	cache    map[[2]string]float64
+	requests int
+	err      error
+}
+
+type request struct {
+	Pairs [][2]string `json:"pairs"`
+}
+
+type response struct {
+	Distances []float64 `json:"distances"`
+}
+
+// New creates a ClusterSet with one initial cluster for each item, which
+// fetches distances from the service at url.
+func New(url string, items []string) *ClusterSet {
+	s := &ClusterSet{
+		URL:      url,
+		clusters: make([][]clustering.ClusterItem, len(items)),
+		cache:    make(map[[2]string]float64),
+	}
+	for i, x := range items {
+		s.clusters[i] = []clustering.ClusterItem{x}
+	}
+	return s
+}
+
+// Err returns the first request error encountered, if any.
+func (s *ClusterSet) Err() error {
+	return s.err
+}
+
+// Requests returns the number of requests made to the service.
+func (s *ClusterSet) Requests() int {
+	return s.requests
+}
+
+// Count returns the number of clusters in the set.
+func (s *ClusterSet) Count() int {
+	return len(s.clusters)
+}
+
+// EachCluster enumerates every cluster id "after" start.
+func (s *ClusterSet) EachCluster(start int, cb func(cluster int)) {
+	for i := start + 1; i < len(s.clusters); i++ {
+		cb(i)
+	}
+}
+
+// EachItem enumerates every item in the cluster.
+func (s *ClusterSet) EachItem(cluster int, cb func(clustering.ClusterItem)) {
+	for _, x := range s.clusters[cluster] {
+		cb(x)
+	}
+}
+
+// Merge the two clusters together.
+func (s *ClusterSet) Merge(i, j int) (keep, swappedIn int) {
+	if j < i {
+		j, i = i, j
+	}
+
+	// move the to-be-merged cluster to the end of the array
+	x := len(s.clusters) - 1
+	if j < x {
+		s.clusters[x], s.clusters[j] = s.clusters[j], s.clusters[x]
+		j = x
+	}
+	s.clusters[i] = append(s.clusters[i], s.clusters[j]...)
+	s.clusters = s.clusters[:j]
+	return i, x
+}
+
+// Distance returns the distance between the two items, fetching it from the
+// service if it is not cached.
+func (s *ClusterSet) Distance(c1, c2 int, item1, item2 clustering.ClusterItem) float64 {
+	a, b := item1.(string), item2.(string)
+	s.fetch(s.context(), [][2]string{{a, b}}, nil)
+	return s.cached(a, b)
+}
+
+// EachItemDistance returns the distances from item1 to every item in c2. When
+// any of them is not cached, the distances between every item of c1 and c2 are
+// fetched together, and the last request is filled up to BatchSize with the
+// pairs of c1 and the following clusters, which are scored next (see
+// clustering.OptimizedClusterSet).
+func (s *ClusterSet) EachItemDistance(c1, c2 int, item1 clustering.ClusterItem, cb func(clustering.ClusterItem, float64)) {
+	a := item1.(string)
+	for _, x := range s.clusters[c2] {
+		if s.missing(a, x.(string)) {
+			s.fetchRow(c1, c2)
+			break
+		}
+	}
+	for _, x := range s.clusters[c2] {
+		cb(x, s.cached(a, x.(string)))
+	}
+}
+
+// Prefetch fetches the distances between every pair of items, in batches.
+// This is the fastest way to fill the cache before clustering. Cancelling ctx
+// aborts the outstanding requests.
+func (s *ClusterSet) Prefetch(ctx context.Context) error {
+	var items []clustering.ClusterItem
+	for _, c := range s.clusters {
+		items = append(items, c...)
+	}
+	var pairs [][2]string
+	for i, x := range items {
+		for _, y := range items[i+1:] {
+			pairs = append(pairs, [2]string{x.(string), y.(string)})
+		}
+	}
+	s.fetch(ctx, pairs, nil)
+	return s.err
+}
+
+func (s *ClusterSet) context() context.Context {
+	if s.Context == nil {
+		return context.Background()
+	}
+	return s.Context
+}
+
+func (s *ClusterSet) batchSize() int {
+	if s.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return s.BatchSize
+}
+
+func (s *ClusterSet) lookup(a, b string) (float64, bool) {
+	if a == b {
+		return 0.0, true
+	}
+	if d, ok := s.cache[[2]string{a, b}]; ok {
+		return d, true
+	}
+	d, ok := s.cache[[2]string{b, a}]
+	return d, ok
+}
+
+func (s *ClusterSet) missing(a, b string) bool {
+	_, ok := s.lookup(a, b)
+	return !ok
+}
+
+func (s *ClusterSet) cached(a, b string) float64 {
+	if d, ok := s.lookup(a, b); ok {
+		return d
+	}
+	return math.Inf(1)
+}
+
+// fetchRow fetches the distances between the items of c1 and c2, filling the
+// last request with the pairs of c1 and the clusters after c2.
+func (s *ClusterSet) fetchRow(c1, c2 int) {
+	var pairs, ahead [][2]string
+	for _, x := range s.clusters[c1] {
+		for _, y := range s.clusters[c2] {
+			pairs = append(pairs, [2]string{x.(string), y.(string)})
+		}
+	}
+	size := s.batchSize()
+	for c := c2 + 1; c < len(s.clusters) && len(ahead) < size; c++ {
+		for _, x := range s.clusters[c1] {
+			for _, y := range s.clusters[c] {
+				if s.missing(x.(string), y.(string)) {
+					ahead = append(ahead, [2]string{x.(string), y.(string)})
+				}
+			}
+		}
+	}
+	s.fetch(s.context(), pairs, ahead)
+}
+
+// fetch requests the distances of every uncached pair in pairs, in batches of
+// up to BatchSize pairs. The last batch is filled with the uncached pairs of
+// ahead.
+func (s *ClusterSet) fetch(ctx context.Context, pairs, ahead [][2]string) {
+	if s.err != nil {
+		return
+	}
+	size := s.batchSize()
+
+	var todo [][2]string
+	seen := make(map[[2]string]bool)
+	add := func(p [2]string) {
+		if s.missing(p[0], p[1]) && !seen[p] && !seen[[2]string{p[1], p[0]}] {
+			seen[p] = true
+			todo = append(todo, p)
+		}
+	}
+	for _, p := range pairs {
+		add(p)
+	}
+	if len(todo) == 0 {
+		return
+	}
+	for _, p := range ahead {
+		if len(todo)%size == 0 {
+			break
+		}
+		add(p)
+	}
+
+	for len(todo) > 0 {
+		n := size
+		if n > len(todo) {
+			n = len(todo)
+		}
+		if err := s.post(ctx, todo[:n]); err != nil {
+			s.err = err
+			return
+		}
+		todo = todo[n:]
+	}
+}
+
+func (s *ClusterSet) post(ctx context.Context, pairs [][2]string) error {
+	body, err := json.Marshal(request{Pairs: pairs})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	s.requests++
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote: %s returned %s", s.URL, resp.Status)
+	}
+
+	var res response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+	if len(res.Distances) != len(pairs) {
+		return fmt.Errorf("remote: %s returned %d distances for %d pairs", s.URL, len(res.Distances), len(pairs))
+	}
+	for k, p := range pairs {
+		s.cache[p] = res.Distances[k]
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pbnjay/clustering"
+)
+
+func TestClusterSet(t *testing.T) {
+	// items on a line at positions 0, 1, 3, 7 and 8
+	pos := map[string]float64{"a": 0, "b": 1, "c": 3, "d": 7, "e": 8}
+	items := []string{"a", "b", "c", "d", "e"}
+	pairs, requests := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var res response
+		for _, p := range req.Pairs {
+			pairs++
+			res.Distances = append(res.Distances, math.Abs(pos[p[0]]-pos[p[1]]))
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	// the pairs scored next fill each request: a-b with a-c and a-d, then
+	// a-e, b-c with b-d and b-e, c-d with c-e, and d-e
+	s := New(srv.URL, items)
+	s.BatchSize = 3
+	clustering.Cluster(s, clustering.Threshold(3), clustering.AverageLinkage())
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if s.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", s.Count())
+	}
+	if pairs != 10 || requests != 5 || s.Requests() != 5 {
+		t.Errorf("expected 10 pairs fetched once in 5 requests, got %d in %d", pairs, requests)
+	}
+
+	// prefetching fills every request
+	pairs, requests = 0, 0
+	s = New(srv.URL, items)
+	s.BatchSize = 3
+	if err := s.Prefetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	clustering.Cluster(s, clustering.Threshold(3), clustering.AverageLinkage())
+	if pairs != 10 || requests != 4 {
+		t.Errorf("expected 10 pairs prefetched in 4 requests, got %d in %d", pairs, requests)
+	}
+
+	// a cancelled context aborts the requests
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	requests = 0
+	s = New(srv.URL, items)
+	s.Context = ctx
+	clustering.Cluster(s, clustering.Threshold(3), clustering.AverageLinkage())
+	if s.Err() == nil || requests != 0 {
+		t.Errorf("expected a cancelled context to abort clustering, got %v after %d requests", s.Err(), requests)
+	}
+	if New(srv.URL, items).Prefetch(ctx) == nil {
+		t.Errorf("expected an error from a cancelled prefetch")
+	}
+
+	s = New(srv.URL+"/missing", []string{"a", "b"})
+	srv.Close()
+	if s.Prefetch(context.Background()) == nil {
+		t.Errorf("expected an error from a closed server")
+	}
+}
@@ -0,0 +1,145 @@
+package clustering
+
+import "math"
+
+// StopReason describes why clustering stopped.
+type StopReason int
+
+// The possible reasons for clustering to stop.
+const (
+	// NotStopped means clustering has not stopped yet.
+	NotStopped StopReason = iota
+
+	// SingleCluster means every item was merged into a single cluster.
+	SingleCluster
+
+	// CheckerStopped means the Checker decided to stop merging.
+	CheckerStopped
+
+	// NoCandidates means no remaining pair of clusters could be merged, e.g.
+	// because they are frozen or infinitely far apart.
+	NoCandidates
+
+	// LimitExceeded means one of the configured Limits was exceeded, see
+	// Result.Err for details.
+	LimitExceeded
+
+	// TrivialInput means the input contained fewer than two clusters (i.e. it
+	// was empty or had a single item), so there was nothing to merge.
+	TrivialInput
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case NotStopped:
+		return "not stopped"
+	case SingleCluster:
+		return "single cluster"
+	case CheckerStopped:
+		return "checker stopped"
+	case NoCandidates:
+		return "no candidates"
+	case LimitExceeded:
+		return "limit exceeded"
+	case TrivialInput:
+		return "trivial input"
+	}
+	return "unknown"
+}
+
+// Result summarizes a clustering run.
+//
+// Empty and single-item inputs are not errors: clustering stops immediately
+// with a StopReason of TrivialInput. If every pair of initial clusters has the
+// same linkage score, IdenticalDistances is set. Clustering proceeds as usual
+// in this case, with ties broken deterministically by the lowest cluster ids,
+// but the resulting hierarchy carries no information.
+type Result struct {
+	// StopReason describes why clustering stopped.
+	StopReason StopReason
+
+	// IdenticalDistances is true if there were at least 3 initial clusters,
+	// and all pairs had identical linkage scores.
+	IdenticalDistances bool
+
+	// Err is the error that stopped clustering, if any.
+	Err error
+
+	// StoppedBy describes the Checker that stopped clustering, when the
+	// StopReason is CheckerStopped. For composite checkers (e.g. AndChecker),
+	// this identifies the inner checker(s) responsible.
+	StoppedBy *Description
+
+	// FinalScore is the score of the last merge candidate considered, which
+	// is the score that was rejected if clustering was stopped by the Checker.
+	// It is NaN if no candidates were considered.
+	FinalScore float64
+
+	// FinalPair are the cluster ids of the last merge candidate considered.
+	FinalPair [2]int
+
+	// Merges is the number of merges performed.
+	Merges int
+}
+
+// Result returns a summary of the clustering run so far.
+func (h *HClustering) Result() Result {
+	r := Result{
+		StopReason:         h.stop,
+		IdenticalDistances: h.identical,
+		Err:                h.err,
+		FinalScore:         h.finalScore,
+		FinalPair:          h.finalPair,
+		Merges:             h.merges,
+	}
+	if !h.scored {
+		r.FinalScore = math.NaN()
+	}
+	if h.stop == CheckerStopped {
+		d := describeStop(h.Checker)
+		r.StoppedBy = &d
+	}
+	return r
+}
+
+// ClusterWithReport clusters the input set (in-place) exactly like Cluster,
+// and returns a Result describing why clustering stopped.
+func ClusterWithReport(c ClusterSet, chk Checker, lt LinkageType) Result {
+	h := HClustering{
+		ClusterSet:  c,
+		Checker:     chk,
+		LinkageType: lt,
+	}
+	h.Run()
+	return h.Result()
+}
+
+// stopCauser is implemented by Checkers that wrap other Checkers, to report
+// which of them caused the last decision to stop. Returning no checkers means
+// the wrapper itself caused the stop.
+type stopCauser interface {
+	stopCause() []Checker
+}
+
+// describeStop describes the checker(s) responsible for stopping clustering.
+func describeStop(c Checker) Description {
+	sc, ok := c.(stopCauser)
+	if !ok {
+		return Describe(c)
+	}
+	causes := sc.stopCause()
+	switch len(causes) {
+	case 0:
+		d := Describe(c)
+		d.Inner = nil
+		return d
+	case 1:
+		return describeStop(causes[0])
+	}
+	d := Describe(c)
+	d.Inner = make([]Description, len(causes))
+	for i, x := range causes {
+		d.Inner[i] = describeStop(x)
+	}
+	return d
+}
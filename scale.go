@@ -0,0 +1,115 @@
+package clustering
+
+import (
+	"math"
+	"sort"
+)
+
+// Scaler rescales every column of a point matrix as (x - Center)/Scale, so
+// that Euclidean-based clustering is not dominated by large-magnitude
+// features. A Scaler is fit on one set of points and can then transform new
+// points (e.g. before assigning them to existing clusters) consistently.
+type Scaler struct {
+	Center []float64
+	Scale  []float64
+}
+
+// FitZScore returns a Scaler that standardizes every column to zero mean and
+// unit (population) standard deviation.
+func FitZScore(points [][]float64) *Scaler {
+	s := newScaler(points)
+	for j := range s.Center {
+		mean, m2 := 0.0, 0.0
+		for i, p := range points {
+			d := p[j] - mean
+			mean += d / float64(i+1)
+			m2 += d * (p[j] - mean)
+		}
+		s.Center[j] = mean
+		s.Scale[j] = math.Sqrt(m2 / float64(len(points)))
+	}
+	return s.fixScale()
+}
+
+// FitMinMax returns a Scaler that maps every column onto [0, 1].
+func FitMinMax(points [][]float64) *Scaler {
+	s := newScaler(points)
+	for j := range s.Center {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, p := range points {
+			lo = math.Min(lo, p[j])
+			hi = math.Max(hi, p[j])
+		}
+		s.Center[j] = lo
+		s.Scale[j] = hi - lo
+	}
+	return s.fixScale()
+}
+
+// FitRobust returns a Scaler that centers every column on its median and
+// divides by its interquartile range, which is far less sensitive to outliers
+// than FitZScore.
+func FitRobust(points [][]float64) *Scaler {
+	s := newScaler(points)
+	col := make([]float64, len(points))
+	for j := range s.Center {
+		for i, p := range points {
+			col[i] = p[j]
+		}
+		sort.Float64s(col)
+		s.Center[j] = percentileOf(col, 50)
+		s.Scale[j] = percentileOf(col, 75) - percentileOf(col, 25)
+	}
+	return s.fixScale()
+}
+
+// Transform returns a scaled copy of the points.
+func (s *Scaler) Transform(points [][]float64) [][]float64 {
+	res := make([][]float64, len(points))
+	for i, p := range points {
+		res[i] = s.TransformPoint(p)
+	}
+	return res
+}
+
+// TransformPoint returns a scaled copy of a single point.
+func (s *Scaler) TransformPoint(p []float64) []float64 {
+	res := make([]float64, len(p))
+	for j, x := range p {
+		res[j] = (x - s.Center[j]) / s.Scale[j]
+	}
+	return res
+}
+
+// Inverse returns the original coordinates of a scaled point, e.g. to report
+// cluster centroids in the original units.
+func (s *Scaler) Inverse(p []float64) []float64 {
+	res := make([]float64, len(p))
+	for j, x := range p {
+		res[j] = x*s.Scale[j] + s.Center[j]
+	}
+	return res
+}
+
+/////////////
+
+func newScaler(points [][]float64) *Scaler {
+	d := 0
+	if len(points) > 0 {
+		d = len(points[0])
+	}
+	return &Scaler{
+		Center: make([]float64, d),
+		Scale:  make([]float64, d),
+	}
+}
+
+// fixScale leaves constant columns unscaled, rather than dividing by zero.
+func (s *Scaler) fixScale() *Scaler {
+	for j, x := range s.Scale {
+		if x == 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+			s.Scale[j] = 1
+		}
+	}
+	return s
+}
@@ -0,0 +1,29 @@
+package clustering
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScalers(t *testing.T) {
+	points := [][]float64{{1, 100, 5}, {2, 200, 5}, {3, 300, 5}, {4, 1000, 5}}
+
+	z := FitZScore(points)
+	if math.Abs(z.Center[0]-2.5) > 1e-12 || math.Abs(z.Scale[0]-math.Sqrt(1.25)) > 1e-12 || z.Scale[2] != 1 {
+		t.Errorf("unexpected z-score fit %+v", z)
+	}
+	mm := FitMinMax(points).Transform(points)
+	if mm[0][1] != 0 || mm[3][1] != 1 || mm[1][0] != 1.0/3.0 || mm[0][2] != 0 {
+		t.Errorf("unexpected min-max scaling %v", mm)
+	}
+
+	// the outlier 1000 does not affect the median
+	r := FitRobust(points)
+	if r.Center[1] != 250 || r.Scale[1] != 300 {
+		t.Errorf("unexpected robust fit %+v", r)
+	}
+	p := []float64{7, 400, 5}
+	if got := r.Inverse(r.TransformPoint(p)); math.Abs(got[0]-7) > 1e-12 || math.Abs(got[1]-400) > 1e-12 {
+		t.Errorf("inverse should restore the original point, got %v", got)
+	}
+}
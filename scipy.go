@@ -0,0 +1,91 @@
+package clustering
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DendrogramFromLinkageMatrix converts a linkage matrix z produced by SciPy
+// (scipy.cluster.hierarchy.linkage) into a Dendrogram, so that trees computed
+// elsewhere can be cut, queried and exported with this package. Each row of z
+// is [node1, node2, height, size], and labels[k] is the item for observation
+// k. If labels is nil, observations are labeled with their int index.
+func DendrogramFromLinkageMatrix(z [][]float64, labels []ClusterItem) (*Dendrogram, error) {
+	n := len(z) + 1
+	if labels == nil {
+		labels = make([]ClusterItem, n)
+		for i := range labels {
+			labels[i] = i
+		}
+	}
+	if len(labels) != n {
+		return nil, fmt.Errorf("clustering: %d labels for a linkage matrix of %d observations", len(labels), n)
+	}
+
+	d := &Dendrogram{Leaves: make([][]ClusterItem, n)}
+	for i, x := range labels {
+		d.Leaves[i] = []ClusterItem{x}
+	}
+	used := make([]bool, n+len(z))
+	size := func(node int) int {
+		if node < n {
+			return 1
+		}
+		return d.Merges[node-n].Size
+	}
+	for r, row := range z {
+		if len(row) < 3 {
+			return nil, fmt.Errorf("clustering: linkage matrix row %d has %d columns", r, len(row))
+		}
+		a, b := int(row[0]), int(row[1])
+		if a < 0 || b < 0 || a >= n+r || b >= n+r || a == b || float64(a) != row[0] || float64(b) != row[1] {
+			return nil, fmt.Errorf("clustering: linkage matrix row %d refers to invalid node", r)
+		}
+		if used[a] || used[b] {
+			return nil, fmt.Errorf("clustering: linkage matrix row %d merges a node twice", r)
+		}
+		used[a], used[b] = true, true
+		m := Merge{A: a, B: b, Height: row[2], Size: size(a) + size(b)}
+		if len(row) > 3 && int(row[3]) != m.Size {
+			return nil, fmt.Errorf("clustering: linkage matrix row %d has size %g, expected %d", r, row[3], m.Size)
+		}
+		d.Merges = append(d.Merges, m)
+	}
+	return d, nil
+}
+
+// ReadLinkageMatrix reads a linkage matrix saved as text, e.g. with
+// numpy.savetxt (whitespace separated) or as CSV, and converts it with
+// DendrogramFromLinkageMatrix. Blank lines and lines starting with # are
+// skipped.
+func ReadLinkageMatrix(r io.Reader, labels []ClusterItem) (*Dendrogram, error) {
+	var z [][]float64
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(s, func(c rune) bool {
+			return c == ',' || c == ' ' || c == '\t'
+		})
+		row := make([]float64, len(fields))
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("clustering: linkage matrix line %d: %v", line, err)
+			}
+			row[i] = v
+		}
+		z = append(z, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return DendrogramFromLinkageMatrix(z, labels)
+}
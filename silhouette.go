@@ -0,0 +1,153 @@
+package clustering
+
+import "math"
+
+// Silhouette returns a Checker that evaluates the average silhouette width of
+// the current partition, and stops when the proposed merge would decrease it
+// by more than tol. This finds the "best" number of clusters automatically.
+//
+// Every item-pair distance is computed once and kept in memory, and each
+// check is O(n^2) in the number of items, so this is best suited to small and
+// medium sized data sets. The distances between items that were already in the
+// same cluster at the first check are taken from an ItemDistanceSet, or left
+// out if the ClusterSet does not implement it.
+func Silhouette(tol float64) Checker {
+	return &silhouetteCheck{tol: tol}
+}
+
+/////////////
+
+type silhouetteCheck struct {
+	tol float64
+
+	index map[ClusterItem]int
+	dists [][]float64
+}
+
+func (c *silhouetteCheck) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	var items []int
+	var labels []int
+	nc := 0
+	clusters.EachCluster(-1, func(cluster int) {
+		if cluster >= nc {
+			nc = cluster + 1
+		}
+	})
+	if c.index == nil {
+		c.init(clusters)
+	}
+	clusters.EachCluster(-1, func(cluster int) {
+		clusters.EachItem(cluster, func(x ClusterItem) {
+			items = append(items, c.index[x])
+			labels = append(labels, cluster)
+		})
+	})
+
+	// label for the merged partition: j becomes part of i
+	merged := func(l int) int {
+		if l == j {
+			return i
+		}
+		return l
+	}
+
+	// distances that were unavailable (within clusters that were already
+	// merged at the first check) are left out of the averages
+	before, after := 0.0, 0.0
+	sums := make([]float64, nc)
+	sizes := make([]float64, nc)
+	for x, ix := range items {
+		for k := range sums {
+			sums[k], sizes[k] = 0.0, 0.0
+		}
+		sizes[labels[x]]++
+		for y, iy := range items {
+			if x != y && !math.IsNaN(c.dists[ix][iy]) {
+				sums[labels[y]] += c.dists[ix][iy]
+				sizes[labels[y]]++
+			}
+		}
+
+		before += silhouetteWidth(sums, sizes, labels[x], -1, -1)
+		after += silhouetteWidth(sums, sizes, merged(labels[x]), i, j)
+	}
+	if len(items) > 0 {
+		before /= float64(len(items))
+		after /= float64(len(items))
+	}
+	return after >= before-c.tol
+}
+
+func (c *silhouetteCheck) Describe() Description {
+	return Description{Name: "silhouette", Params: map[string]interface{}{"tolerance": c.tol}}
+}
+
+func (c *silhouetteCheck) init(clusters ClusterSet) {
+	var items []ClusterItem
+	var owner []int
+	c.index = make(map[ClusterItem]int)
+	clusters.EachCluster(-1, func(cluster int) {
+		clusters.EachItem(cluster, func(x ClusterItem) {
+			c.index[x] = len(items)
+			items = append(items, x)
+			owner = append(owner, cluster)
+		})
+	})
+
+	c.dists = make([][]float64, len(items))
+	for x := range items {
+		c.dists[x] = make([]float64, len(items))
+	}
+	for x := range items {
+		for y := x + 1; y < len(items); y++ {
+			d, ok := itemDistance(clusters, owner[x], owner[y], items[x], items[y])
+			if !ok {
+				d = math.NaN()
+			}
+			c.dists[x][y] = d
+			c.dists[y][x] = d
+		}
+	}
+}
+
+// silhouetteWidth computes the silhouette of an item given the sum of its
+// distances to the items of every cluster, and the number of those items
+// (including the item itself in its own cluster). If
+// mi and mj are non-negative, clusters mi and mj are treated as one cluster
+// (labelled mi).
+func silhouetteWidth(sums, sizes []float64, own, mi, mj int) float64 {
+	sum := func(l int) (float64, float64) {
+		if mi >= 0 && l == mi {
+			return sums[mi] + sums[mj], sizes[mi] + sizes[mj]
+		}
+		return sums[l], sizes[l]
+	}
+
+	s, n := sum(own)
+	if n <= 1 {
+		return 0.0
+	}
+	a := s / (n - 1)
+
+	b := -1.0
+	for l := range sums {
+		if l == own || sizes[l] == 0 || (mi >= 0 && l == mj) {
+			continue
+		}
+		s, n := sum(l)
+		if m := s / n; b < 0 || m < b {
+			b = m
+		}
+	}
+	if b < 0 {
+		// only one cluster, silhouette is undefined
+		return 0.0
+	}
+	if a > b {
+		return (b - a) / a
+	}
+	if b > 0 {
+		return (b - a) / b
+	}
+	return 0.0
+}
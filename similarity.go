@@ -0,0 +1,127 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+)
+
+// SimilarityTransform converts a similarity score into a distance, or returns
+// an error if the score is outside the valid range of the transform.
+type SimilarityTransform func(sim float64) (float64, error)
+
+// SimilarityOneMinus converts similarities in [0, 1] (e.g. cosine similarity
+// or Jaccard index) into distances 1-s.
+func SimilarityOneMinus(sim float64) (float64, error) {
+	if sim < 0 || sim > 1 || math.IsNaN(sim) {
+		return 0, fmt.Errorf("clustering: similarity %g is outside [0, 1]", sim)
+	}
+	return 1.0 - sim, nil
+}
+
+// SimilarityNegLog converts similarities in (0, 1] (e.g. probabilities) into
+// distances -log(s), which emphasizes differences between small similarities.
+func SimilarityNegLog(sim float64) (float64, error) {
+	if sim <= 0 || sim > 1 || math.IsNaN(sim) {
+		return 0, fmt.Errorf("clustering: similarity %g is outside (0, 1]", sim)
+	}
+	return -math.Log(sim), nil
+}
+
+// SimilarityMaxMinus returns a transform that converts similarities in
+// [0, max] (e.g. alignment scores or shared counts) into distances max-s.
+func SimilarityMaxMinus(max float64) SimilarityTransform {
+	return func(sim float64) (float64, error) {
+		if sim < 0 || sim > max || math.IsNaN(sim) {
+			return 0, fmt.Errorf("clustering: similarity %g is outside [0, %g]", sim, max)
+		}
+		return max - sim, nil
+	}
+}
+
+// SimilarityMapToDistances returns a new DistanceMap with every similarity in
+// sim converted to a distance, or the first range error.
+func SimilarityMapToDistances(sim DistanceMap, t SimilarityTransform) (DistanceMap, error) {
+	res := make(DistanceMap, len(sim))
+	for a, subs := range sim {
+		res[a] = make(map[ClusterItem]float64, len(subs))
+		for b, s := range subs {
+			d, err := t(s)
+			if err != nil {
+				return nil, fmt.Errorf("%v for %v and %v", err, a, b)
+			}
+			res[a][b] = d
+		}
+	}
+	return res, nil
+}
+
+// SimilaritiesToDistances returns a new condensed matrix (see
+// NewMatrixClusterSet) with every similarity converted to a distance, or the
+// first range error.
+func SimilaritiesToDistances(condensed []float64, t SimilarityTransform) ([]float64, error) {
+	res := make([]float64, len(condensed))
+	for x, s := range condensed {
+		d, err := t(s)
+		if err != nil {
+			return nil, fmt.Errorf("%v at index %d", err, x)
+		}
+		res[x] = d
+	}
+	return res, nil
+}
+
+/////////////
+
+// SimilarityClusterSet is a ClusterSet wrapper whose Distance converts the
+// similarity returned by the wrapped set, so that similarity data can be
+// clustered without copying it. Note that a DistanceMapClusterSet returns 1.0
+// for missing pairs, which is a maximal similarity rather than a distance, so
+// sparse similarity maps should be converted with SimilarityMapToDistances.
+//
+// Since the ClusterSet interface cannot return errors, the first range error
+// is recorded and reported by Err, and invalid similarities are returned as
+// +Inf distances.
+type SimilarityClusterSet struct {
+	ClusterSet
+
+	transform SimilarityTransform
+	err       error
+}
+
+// NewSimilarityClusterSet wraps cs, whose Distance returns similarities, with
+// the transform t.
+func NewSimilarityClusterSet(cs ClusterSet, t SimilarityTransform) *SimilarityClusterSet {
+	return &SimilarityClusterSet{ClusterSet: cs, transform: t}
+}
+
+// Distance returns the transformed similarity of the two items.
+func (s *SimilarityClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	return s.convert(s.ClusterSet.Distance(c1, c2, item1, item2), item1, item2)
+}
+
+// ItemDistance implements ItemDistanceSet when the underlying ClusterSet does,
+// transforming its similarity.
+func (s *SimilarityClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	sim, ok := itemDistanceOf(s.ClusterSet, item1, item2)
+	if !ok {
+		return 0, false
+	}
+	return s.convert(sim, item1, item2), true
+}
+
+// convert transforms the similarity of the two items, recording any error.
+func (s *SimilarityClusterSet) convert(sim float64, item1, item2 ClusterItem) float64 {
+	d, err := s.transform(sim)
+	if err != nil {
+		if s.err == nil {
+			s.err = fmt.Errorf("%v for %v and %v", err, item1, item2)
+		}
+		return math.Inf(1)
+	}
+	return d
+}
+
+// Err returns the first range error encountered, if any.
+func (s *SimilarityClusterSet) Err() error {
+	return s.err
+}
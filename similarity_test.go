@@ -0,0 +1,44 @@
+package clustering
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarityTransforms(t *testing.T) {
+	sim := DistanceMap{
+		"a": {"b": 0.9, "c": 0.1},
+		"b": {"c": 0.2},
+	}
+	d, err := SimilarityMapToDistances(sim, SimilarityOneMinus)
+	if err != nil || math.Abs(d["a"]["c"]-0.9) > 1e-12 {
+		t.Fatalf("unexpected distances %v (%v)", d, err)
+	}
+	cs := NewDistanceMapClusterSet(d)
+	Cluster(cs, Threshold(0.5), AverageLinkage())
+	if cs.Count() != 2 {
+		t.Errorf("expected 2 clusters, got %d", cs.Count())
+	}
+
+	if _, err := SimilaritiesToDistances([]float64{0.5, 0}, SimilarityNegLog); err == nil {
+		t.Errorf("expected a range error for -log(0)")
+	}
+	if d, _ := SimilaritiesToDistances([]float64{3, 10}, SimilarityMaxMinus(10)); d[0] != 7 || d[1] != 0 {
+		t.Errorf("unexpected max-minus distances %v", d)
+	}
+
+	// lazily transform alignment scores
+	m := NewSimilarityClusterSet(NewMatrixClusterSet([]string{"a", "b", "c"}, []float64{
+		90, 5,
+		10,
+	}), SimilarityMaxMinus(100))
+	Cluster(m, Threshold(50), CompleteLinkage())
+	if m.Count() != 2 || m.Err() != nil {
+		t.Errorf("expected 2 clusters, got %d (%v)", m.Count(), m.Err())
+	}
+
+	bad := NewSimilarityClusterSet(NewMatrixClusterSet([]string{"a", "b"}, []float64{2}), SimilarityOneMinus)
+	if !math.IsInf(bad.Distance(0, 1, 0, 1), 1) || bad.Err() == nil {
+		t.Errorf("expected a recorded range error")
+	}
+}
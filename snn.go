@@ -0,0 +1,98 @@
+package clustering
+
+import "sort"
+
+// SharedNeighborLinkage implements a shared-nearest-neighbor (SNN) linkage,
+// where the dissimilarity of two items is based on the overlap of their k
+// nearest neighbor sets rather than the raw distance between them:
+//
+//	snn(a, b) = 1 - |knn(a) ∩ knn(b)| / k
+//
+// Clusters are scored by the average SNN dissimilarity of all pairs of items.
+// Because neighbor overlap is insensitive to local scale, this handles data of
+// varying density much better than plain single or complete linkage.
+//
+// The neighbor sets are computed once from c when the linkage is created,
+// which requires the distance between every pair of items.
+func SharedNeighborLinkage(c ClusterSet, k int) LinkageType {
+	return &snnLinkage{
+		avgLinkage: avgLinkage{},
+		k:          k,
+		neighbors:  nearestNeighbors(c, k),
+	}
+}
+
+////////////////
+
+type snnLinkage struct {
+	avgLinkage
+
+	k         int
+	neighbors map[ClusterItem]map[ClusterItem]struct{}
+}
+
+func (c *snnLinkage) Put(a, b ClusterItem, dist float64) {
+	c.avgLinkage.Put(a, b, c.snnDistance(a, b))
+}
+
+func (c *snnLinkage) PutWeighted(a, b ClusterItem, dist, wa, wb float64) {
+	c.avgLinkage.PutWeighted(a, b, c.snnDistance(a, b), wa, wb)
+}
+
+func (c *snnLinkage) snnDistance(a, b ClusterItem) float64 {
+	if c.k <= 0 {
+		return 1.0
+	}
+	na, nb := c.neighbors[a], c.neighbors[b]
+	if len(nb) < len(na) {
+		na, nb = nb, na
+	}
+	shared := 0
+	for x := range na {
+		if _, ok := nb[x]; ok {
+			shared++
+		}
+	}
+	return 1.0 - float64(shared)/float64(c.k)
+}
+
+func (c *snnLinkage) Describe() Description {
+	return Description{Name: "shared-nearest-neighbor", Params: map[string]interface{}{"k": c.k}}
+}
+
+// nearestNeighbors returns the k nearest neighbors of every item in c.
+func nearestNeighbors(c ClusterSet, k int) map[ClusterItem]map[ClusterItem]struct{} {
+	var items []ClusterItem
+	var clusters []int
+	c.EachCluster(-1, func(cluster int) {
+		c.EachItem(cluster, func(x ClusterItem) {
+			items = append(items, x)
+			clusters = append(clusters, cluster)
+		})
+	})
+
+	type neighbor struct {
+		idx  int
+		dist float64
+	}
+	res := make(map[ClusterItem]map[ClusterItem]struct{}, len(items))
+	cands := make([]neighbor, 0, len(items))
+	for i, a := range items {
+		cands = cands[:0]
+		for j, b := range items {
+			if i != j {
+				cands = append(cands, neighbor{j, c.Distance(clusters[i], clusters[j], a, b)})
+			}
+		}
+		sort.SliceStable(cands, func(x, y int) bool {
+			return cands[x].dist < cands[y].dist
+		})
+
+		nn := make(map[ClusterItem]struct{}, k)
+		for x := 0; x < k && x < len(cands); x++ {
+			nn[items[cands[x].idx]] = struct{}{}
+		}
+		res[a] = nn
+	}
+	return res
+}
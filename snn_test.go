@@ -0,0 +1,51 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSharedNeighborLinkage(t *testing.T) {
+	// two groups of 5 items, a0..a4 near position 0 and b0..b4 near 10
+	pos := make(map[string]float64)
+	for i := 0; i < 5; i++ {
+		pos[fmt.Sprint("a", i)] = float64(i) * 0.125
+		pos[fmt.Sprint("b", i)] = 10 + float64(i)*0.25
+	}
+	dm := make(DistanceMap)
+	for a := range pos {
+		dm[a] = make(map[ClusterItem]float64)
+		for b := range pos {
+			if a < b {
+				dm[a][b] = math.Abs(pos[a] - pos[b])
+			}
+		}
+	}
+	d := NewDistanceMapClusterSet(dm)
+	lt := SharedNeighborLinkage(d, 4)
+
+	// every item's 4 nearest neighbors are the rest of its group
+	snn := lt.(*snnLinkage)
+	if x := snn.snnDistance("a0", "a1"); x != 0.25 {
+		t.Errorf("expected snn distance 0.25 within a group, got %g", x)
+	}
+	if x := snn.snnDistance("a0", "b0"); x != 1 {
+		t.Errorf("expected snn distance 1 between groups, got %g", x)
+	}
+
+	h := &HClustering{
+		ClusterSet:     d,
+		Checker:        Threshold(0.5),
+		LinkageType:    lt,
+		CacheDistances: true,
+	}
+	for h.MergeNext() {
+	}
+	if d.Count() != 2 || clusterSizes(d)[5] != 2 {
+		t.Errorf("expected 2 clusters of 5 items, got %v", clusterSizes(d))
+	}
+	if desc := Describe(lt); desc.Name != "shared-nearest-neighbor" || desc.Params["k"] != 4 {
+		t.Errorf("unexpected description %+v", desc)
+	}
+}
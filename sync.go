@@ -0,0 +1,165 @@
+package clustering
+
+import "sync"
+
+// SyncClusterSet is a ClusterSet wrapper that is safe to share between the
+// goroutine performing clustering and any number of concurrent readers, e.g.
+// a web UI polling progress during clustering.
+//
+// Locks are never held while callbacks are running: EachItem enumerates a
+// copy of the cluster's items, and EachCluster skips clusters that were
+// removed by a concurrent Merge. Readers that need a consistent view of every
+// cluster should use Snapshot. Only one goroutine should call Merge.
+type SyncClusterSet struct {
+	mu sync.RWMutex
+	cs ClusterSet
+}
+
+// SynchronizedClusterSet wraps cs with locking around enumeration, Distance
+// and Merge. The optional interfaces of cs (e.g. WeightedClusterSet and
+// ConnectedClusterSet) are forwarded under the same lock.
+func SynchronizedClusterSet(cs ClusterSet) *SyncClusterSet {
+	return &SyncClusterSet{cs: cs}
+}
+
+// Count returns the number of clusters in the set.
+func (s *SyncClusterSet) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cs.Count()
+}
+
+// EachCluster enumerates every cluster id "after" start.
+func (s *SyncClusterSet) EachCluster(start int, cb func(cluster int)) {
+	s.mu.RLock()
+	var ids []int
+	s.cs.EachCluster(start, func(cluster int) {
+		ids = append(ids, cluster)
+	})
+	s.mu.RUnlock()
+
+	for _, c := range ids {
+		if c < s.Count() {
+			cb(c)
+		}
+	}
+}
+
+// EachItem enumerates a copy of every item from the cluster.
+func (s *SyncClusterSet) EachItem(cluster int, cb func(ClusterItem)) {
+	for _, x := range s.items(cluster) {
+		cb(x)
+	}
+}
+
+func (s *SyncClusterSet) items(cluster int) []ClusterItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if cluster >= s.cs.Count() {
+		return nil
+	}
+	var res []ClusterItem
+	s.cs.EachItem(cluster, func(x ClusterItem) {
+		res = append(res, x)
+	})
+	return res
+}
+
+// Distance computes the distance between two items in separate clusters.
+func (s *SyncClusterSet) Distance(c1, c2 int, item1, item2 ClusterItem) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cs.Distance(c1, c2, item1, item2)
+}
+
+// EachItemDistance implements OptimizedClusterSet when the underlying
+// ClusterSet does. The distances are collected under the lock, and cb is
+// called after it is released.
+func (s *SyncClusterSet) EachItemDistance(c1, c2 int, item1 ClusterItem, cb func(ClusterItem, float64)) {
+	type itemDistance struct {
+		item ClusterItem
+		dist float64
+	}
+	var res []itemDistance
+	s.mu.RLock()
+	eachItemDistance(s.cs, c1, c2, item1, func(item2 ClusterItem, dist float64) {
+		res = append(res, itemDistance{item2, dist})
+	})
+	s.mu.RUnlock()
+
+	for _, x := range res {
+		cb(x.item, x.dist)
+	}
+}
+
+// Weight implements WeightedClusterSet when the underlying ClusterSet does,
+// and otherwise returns 1.
+func (s *SyncClusterSet) Weight(item ClusterItem) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return weightOf(s.cs, item)
+}
+
+// ClusterDistance implements ClusterDistanceSet when the underlying
+// ClusterSet does, and otherwise falls back to the LinkageType.
+func (s *SyncClusterSet) ClusterDistance(c1, c2 int) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return clusterDistanceOf(s.cs, c1, c2)
+}
+
+// Connected implements ConnectedClusterSet when the underlying ClusterSet
+// does, and otherwise allows every merge.
+func (s *SyncClusterSet) Connected(c1, c2 int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return connectedOf(s.cs, c1, c2)
+}
+
+// ItemDistance implements ItemDistanceSet when the underlying ClusterSet
+// does.
+func (s *SyncClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return itemDistanceOf(s.cs, item1, item2)
+}
+
+// Coordinates implements VectorClusterSet when the underlying ClusterSet does.
+func (s *SyncClusterSet) Coordinates(item ClusterItem) []float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return coordinatesOf(s.cs, item)
+}
+
+// Centroid implements VectorClusterSet when the underlying ClusterSet does.
+func (s *SyncClusterSet) Centroid(cluster int) []float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return centroidOf(s.cs, cluster)
+}
+
+func (s *SyncClusterSet) wrapped() ClusterSet {
+	return s.cs
+}
+
+// Merge the two clusters together, excluding all readers.
+func (s *SyncClusterSet) Merge(i, j int) (kept, swappedIn int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cs.Merge(i, j)
+}
+
+// Snapshot returns a consistent copy of the items of every cluster.
+func (s *SyncClusterSet) Snapshot() [][]ClusterItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make([][]ClusterItem, 0, s.cs.Count())
+	s.cs.EachCluster(-1, func(cluster int) {
+		var items []ClusterItem
+		s.cs.EachItem(cluster, func(x ClusterItem) {
+			items = append(items, x)
+		})
+		res = append(res, items)
+	})
+	return res
+}
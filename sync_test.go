@@ -0,0 +1,49 @@
+package clustering
+
+import "testing"
+
+func TestSynchronizedClusterSet(t *testing.T) {
+	s := SynchronizedClusterSet(NewDistanceMapClusterSet(testDistanceMap(40)))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Cluster(s, MaxClusters(3), AverageLinkage())
+	}()
+
+	polling := true
+	for polling {
+		select {
+		case <-done:
+			polling = false
+		default:
+		}
+		n := 0
+		for _, items := range s.Snapshot() {
+			n += len(items)
+		}
+		if n != 40 {
+			t.Fatalf("snapshot should contain every item, got %d", n)
+		}
+		s.EachCluster(-1, func(cluster int) {
+			s.EachItem(cluster, func(ClusterItem) {})
+		})
+	}
+	if s.Count() != 3 {
+		t.Errorf("expected 3 clusters, got %d", s.Count())
+	}
+}
+
+func TestSynchronizedClusterSetForwarding(t *testing.T) {
+	// item weights are used by the linkage
+	dm, weights := testWeightedDistanceMap(10)
+	sameHeights(t, "weighted", mergeHeights(NewWeightedDistanceMapClusterSet(dm, weights)),
+		mergeHeights(SynchronizedClusterSet(NewWeightedDistanceMapClusterSet(dm, weights))))
+
+	// only connected clusters are merged, over their edges
+	s := SynchronizedClusterSet(testGraph())
+	sameHeights(t, "graph", mergeHeights(testGraph()), mergeHeights(s))
+	if s.Count() != 2 {
+		t.Errorf("expected 2 unconnected clusters, got %d", s.Count())
+	}
+}
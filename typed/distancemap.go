@@ -0,0 +1,80 @@
+package typed
+
+// DistanceMap is the generic equivalent of clustering.DistanceMap.
+type DistanceMap[T comparable] map[T]map[T]float64
+
+type distMapClusterSet[T comparable] struct {
+	clusters [][]T
+	data     DistanceMap[T]
+}
+
+// NewDistanceMapClusterSet initializes a new ClusterSet from a distance map by
+// creating a singleton cluster for every unique item in the maps. Missing
+// pairs have a distance of 1.0, as in clustering.NewDistanceMapClusterSet.
+func NewDistanceMapClusterSet[T comparable](data map[T]map[T]float64) ClusterSet[T] {
+	d := &distMapClusterSet[T]{
+		data: data,
+	}
+
+	allItems := make(map[T]struct{})
+	for k1, subs := range data {
+		if _, done := allItems[k1]; !done {
+			allItems[k1] = struct{}{}
+			d.clusters = append(d.clusters, []T{k1})
+		}
+		for k2 := range subs {
+			if _, done := allItems[k2]; !done {
+				allItems[k2] = struct{}{}
+				d.clusters = append(d.clusters, []T{k2})
+			}
+		}
+	}
+
+	return d
+}
+
+func (d *distMapClusterSet[T]) Count() int {
+	return len(d.clusters)
+}
+
+func (d *distMapClusterSet[T]) EachCluster(start int, cb func(cluster int)) {
+	for i := start + 1; i < len(d.clusters); i++ {
+		cb(i)
+	}
+}
+
+func (d *distMapClusterSet[T]) EachItem(cluster int, cb func(item T)) {
+	for _, x := range d.clusters[cluster] {
+		cb(x)
+	}
+}
+
+func (d *distMapClusterSet[T]) Distance(c1, c2 int, item1, item2 T) float64 {
+	if x, ok := d.data[item1]; ok {
+		if y, ok := x[item2]; ok {
+			return y
+		}
+	}
+	if x, ok := d.data[item2]; ok {
+		if y, ok := x[item1]; ok {
+			return y
+		}
+	}
+	return 1.0
+}
+
+func (d *distMapClusterSet[T]) Merge(i, j int) (keep, swappedIn int) {
+	if j < i {
+		j, i = i, j
+	}
+
+	// move the to-be-merged cluster to the end of the array
+	x := len(d.clusters) - 1
+	if j < x {
+		d.clusters[x], d.clusters[j] = d.clusters[j], d.clusters[x]
+		j = x
+	}
+	d.clusters[i] = append(d.clusters[i], d.clusters[j]...)
+	d.clusters = d.clusters[:j]
+	return i, x
+}
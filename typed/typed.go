@@ -0,0 +1,84 @@
+// Package typed provides generic, type-safe alternatives to the ClusterSet
+// implementations of the clustering package, so that callers do not need to
+// type-assert clustering.ClusterItem values.
+//
+// Any ClusterSet[T] can be clustered with the linkage methods and Checkers of
+// the clustering package using Cluster, or adapted using Untyped.
+package typed
+
+import "github.com/pbnjay/clustering"
+
+// ClusterSet is the generic equivalent of clustering.ClusterSet, where every
+// item has type T.
+type ClusterSet[T comparable] interface {
+	// Count returns the number of clusters in the set.
+	Count() int
+
+	// EachCluster enumerates every cluster id "after" start. Use start=-1 to
+	// start enumeration from the beginning.
+	EachCluster(start int, cb func(cluster int))
+
+	// EachItem enumerates every item from the cluster.
+	EachItem(cluster int, cb func(item T))
+
+	// Distance computes the distance between two items in separate clusters.
+	Distance(c1, c2 int, item1, item2 T) float64
+
+	// Merge the two clusters together. Retuns the cluster that is merged into
+	// (kept) and the cluster that is swapped into the place of the merged
+	// cluster (typically the last cluster).
+	Merge(cluster1, cluster2 int) (kept, swappedIn int)
+}
+
+// Cluster clusters the input set (in-place) using the specified linkage type
+// until the Checker stops clustering. See clustering.Cluster.
+func Cluster[T comparable](c ClusterSet[T], chk clustering.Checker, lt clustering.LinkageType) {
+	clustering.Cluster(Untyped(c), chk, lt)
+}
+
+// Untyped adapts a ClusterSet[T] into a clustering.ClusterSet, for use with
+// the rest of the clustering package.
+func Untyped[T comparable](c ClusterSet[T]) clustering.ClusterSet {
+	return untyped[T]{c}
+}
+
+// Clusters returns the items of every cluster in c.
+func Clusters[T comparable](c ClusterSet[T]) [][]T {
+	res := make([][]T, 0, c.Count())
+	c.EachCluster(-1, func(cluster int) {
+		var items []T
+		c.EachItem(cluster, func(x T) {
+			items = append(items, x)
+		})
+		res = append(res, items)
+	})
+	return res
+}
+
+/////////////
+
+type untyped[T comparable] struct {
+	c ClusterSet[T]
+}
+
+func (u untyped[T]) Count() int {
+	return u.c.Count()
+}
+
+func (u untyped[T]) EachCluster(start int, cb func(cluster int)) {
+	u.c.EachCluster(start, cb)
+}
+
+func (u untyped[T]) EachItem(cluster int, cb func(item clustering.ClusterItem)) {
+	u.c.EachItem(cluster, func(x T) {
+		cb(x)
+	})
+}
+
+func (u untyped[T]) Distance(c1, c2 int, item1, item2 clustering.ClusterItem) float64 {
+	return u.c.Distance(c1, c2, item1.(T), item2.(T))
+}
+
+func (u untyped[T]) Merge(cluster1, cluster2 int) (kept, swappedIn int) {
+	return u.c.Merge(cluster1, cluster2)
+}
@@ -0,0 +1,28 @@
+package typed
+
+import (
+	"testing"
+
+	"github.com/pbnjay/clustering"
+)
+
+func TestDistanceMapClusterSet(t *testing.T) {
+	type id int
+	d := NewDistanceMapClusterSet(map[id]map[id]float64{
+		1: {2: 0.0, 3: 0.0, 4: 1.0, 5: 0.4},
+		2: {3: 0.1, 4: 0.9, 5: 0.4},
+		3: {4: 0.9, 5: 0.2},
+		4: {5: 0.1},
+	})
+	Cluster(d, clustering.Threshold(0.4), clustering.CompleteLinkage())
+
+	sum := 0
+	for _, items := range Clusters(d) {
+		for _, x := range items {
+			sum += int(x)
+		}
+	}
+	if d.Count() != 2 || sum != 15 {
+		t.Errorf("expected 2 clusters of all items, got %v", Clusters(d))
+	}
+}
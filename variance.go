@@ -0,0 +1,210 @@
+package clustering
+
+import "math"
+
+// ClusterStats summarizes the distances between every pair of items within a
+// single cluster.
+type ClusterStats struct {
+	// Pairs is the number of item pairs in the cluster.
+	Pairs int
+
+	// Mean is the mean distance between items in the cluster.
+	Mean float64
+
+	m2 float64
+}
+
+// Variance returns the sample variance of the distances between items in the
+// cluster, or 0 if there are fewer than 2 pairs.
+func (s ClusterStats) Variance() float64 {
+	if s.Pairs < 2 {
+		return 0.0
+	}
+	return s.m2 / float64(s.Pairs-1)
+}
+
+// add incorporates a single distance using Welford's online algorithm.
+func (s *ClusterStats) add(d float64) {
+	s.Pairs++
+	delta := d - s.Mean
+	s.Mean += delta / float64(s.Pairs)
+	s.m2 += delta * (d - s.Mean)
+}
+
+// combine returns the statistics of the union of the pairs in s and o, using
+// the parallel algorithm of Chan et al.
+func (s ClusterStats) combine(o ClusterStats) ClusterStats {
+	n := s.Pairs + o.Pairs
+	if n == 0 {
+		return s
+	}
+	delta := o.Mean - s.Mean
+	return ClusterStats{
+		Pairs: n,
+		Mean:  s.Mean + delta*float64(o.Pairs)/float64(n),
+		m2:    s.m2 + o.m2 + delta*delta*float64(s.Pairs)*float64(o.Pairs)/float64(n),
+	}
+}
+
+// StatsClusterSet is implemented by ClusterSets that track the distribution of
+// intra-cluster distances, which may be used by Checkers.
+type StatsClusterSet interface {
+	ClusterSet
+
+	// Stats returns the intra-cluster distance statistics of the cluster.
+	Stats(cluster int) ClusterStats
+
+	// MergedStats returns the intra-cluster distance statistics of the
+	// cluster that would result from merging clusters i and j.
+	MergedStats(i, j int) ClusterStats
+}
+
+// VarianceClusterSet is a ClusterSet wrapper that maintains the running mean
+// and variance of intra-cluster distances for every cluster. When clusters are
+// merged, their statistics are combined with those of the distances between
+// them, so intra-cluster distances are never recomputed. The optional
+// OptimizedClusterSet, WeightedClusterSet and VectorClusterSet interfaces are
+// forwarded to the wrapped set.
+type VarianceClusterSet struct {
+	ClusterSet
+
+	stats []ClusterStats
+
+	// pending holds the last result of MergedStats, so that the distances
+	// between the clusters are not computed again when they are merged.
+	pending      [2]int
+	pendingStats ClusterStats
+	hasPending   bool
+}
+
+// TrackVariance wraps c to track the intra-cluster distance statistics of
+// every cluster. The initial statistics of clusters with more than one item
+// are computed immediately, which requires c to implement ItemDistanceSet;
+// otherwise they start out empty.
+func TrackVariance(c ClusterSet) *VarianceClusterSet {
+	v := &VarianceClusterSet{
+		ClusterSet: c,
+		stats:      make([]ClusterStats, c.Count()),
+	}
+	c.EachCluster(-1, func(cluster int) {
+		var items []ClusterItem
+		c.EachItem(cluster, func(x ClusterItem) {
+			items = append(items, x)
+		})
+		for a := range items {
+			for b := a + 1; b < len(items); b++ {
+				if d, ok := itemDistanceOf(c, items[a], items[b]); ok {
+					v.stats[cluster].add(d)
+				}
+			}
+		}
+	})
+	return v
+}
+
+// Stats returns the intra-cluster distance statistics of the cluster.
+func (v *VarianceClusterSet) Stats(cluster int) ClusterStats {
+	return v.stats[cluster]
+}
+
+// MergedStats returns the intra-cluster distance statistics of the cluster
+// that would result from merging clusters i and j. Only the distances between
+// the two clusters are computed, and they are reused if the clusters are
+// merged next.
+func (v *VarianceClusterSet) MergedStats(i, j int) ClusterStats {
+	if j < i {
+		i, j = j, i
+	}
+	if v.hasPending && v.pending == [2]int{i, j} {
+		return v.pendingStats
+	}
+	var cross ClusterStats
+	v.ClusterSet.EachItem(i, func(x ClusterItem) {
+		v.ClusterSet.EachItem(j, func(y ClusterItem) {
+			cross.add(v.ClusterSet.Distance(i, j, x, y))
+		})
+	})
+	v.pending = [2]int{i, j}
+	v.pendingStats = v.stats[i].combine(v.stats[j]).combine(cross)
+	v.hasPending = true
+	return v.pendingStats
+}
+
+// Merge the two clusters together, and combine their statistics.
+func (v *VarianceClusterSet) Merge(i, j int) (kept, swappedIn int) {
+	merged := v.MergedStats(i, j)
+	v.hasPending = false
+	kept, swappedIn = v.ClusterSet.Merge(i, j)
+	removed := j
+	if kept == j {
+		removed = i
+	}
+	v.stats[kept] = merged
+	if swappedIn != removed {
+		v.stats[removed] = v.stats[swappedIn]
+	}
+	v.stats = v.stats[:len(v.stats)-1]
+	return kept, swappedIn
+}
+
+// EachItemDistance implements OptimizedClusterSet when the underlying
+// ClusterSet does.
+func (v *VarianceClusterSet) EachItemDistance(c1, c2 int, item1 ClusterItem, cb func(ClusterItem, float64)) {
+	if ocs, ok := v.ClusterSet.(OptimizedClusterSet); ok {
+		ocs.EachItemDistance(c1, c2, item1, cb)
+		return
+	}
+	v.ClusterSet.EachItem(c2, func(item2 ClusterItem) {
+		cb(item2, v.ClusterSet.Distance(c1, c2, item1, item2))
+	})
+}
+
+// Weight implements WeightedClusterSet when the underlying ClusterSet does,
+// and otherwise returns 1.
+func (v *VarianceClusterSet) Weight(item ClusterItem) float64 {
+	if wcs, ok := v.ClusterSet.(WeightedClusterSet); ok {
+		return wcs.Weight(item)
+	}
+	return 1.0
+}
+
+// ItemDistance implements ItemDistanceSet when the underlying ClusterSet does.
+func (v *VarianceClusterSet) ItemDistance(item1, item2 ClusterItem) (float64, bool) {
+	return itemDistanceOf(v.ClusterSet, item1, item2)
+}
+
+// Coordinates implements VectorClusterSet when the underlying ClusterSet does.
+func (v *VarianceClusterSet) Coordinates(item ClusterItem) []float64 {
+	return coordinatesOf(v.ClusterSet, item)
+}
+
+// Centroid implements VectorClusterSet when the underlying ClusterSet does.
+func (v *VarianceClusterSet) Centroid(cluster int) []float64 {
+	return centroidOf(v.ClusterSet, cluster)
+}
+
+/////////////
+
+// MaxVariance returns a Checker that stops clustering before a merge would
+// create a cluster whose intra-cluster distances have a standard deviation
+// above sd. The ClusterSet must implement StatsClusterSet (e.g. by wrapping it
+// with TrackVariance), otherwise clustering is never stopped.
+func MaxVariance(sd float64) Checker {
+	return maxVariance{sd}
+}
+
+type maxVariance struct {
+	sd float64
+}
+
+func (c maxVariance) Check(clusters ClusterSet, i, j int, nextScore float64) bool {
+	sc, ok := clusters.(StatsClusterSet)
+	if !ok {
+		return true
+	}
+	return math.Sqrt(sc.MergedStats(i, j).Variance()) <= c.sd
+}
+
+func (c maxVariance) Describe() Description {
+	return Description{Name: "max-variance", Params: map[string]interface{}{"sd": c.sd}}
+}
@@ -0,0 +1,81 @@
+package clustering
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVarianceTracking(t *testing.T) {
+	v := TrackVariance(NewDistanceMapClusterSet(twoGroups()))
+	Cluster(v, MaxVariance(1.0), AverageLinkage())
+	if v.Count() != 2 {
+		t.Fatalf("expected 2 clusters, got %d", v.Count())
+	}
+
+	v.EachCluster(-1, func(cluster int) {
+		var items []ClusterItem
+		v.EachItem(cluster, func(x ClusterItem) { items = append(items, x) })
+		var want ClusterStats
+		for a := range items {
+			for b := a + 1; b < len(items); b++ {
+				d, _ := v.ItemDistance(items[a], items[b])
+				want.add(d)
+			}
+		}
+		got := v.Stats(cluster)
+		if got.Pairs != want.Pairs || math.Abs(got.Mean-want.Mean) > 1e-9 ||
+			math.Abs(got.Variance()-want.Variance()) > 1e-9 {
+			t.Errorf("cluster %d: incremental stats %+v differ from recomputed %+v", cluster, got, want)
+		}
+	})
+
+	// the initial statistics of pre-merged clusters come from ItemDistance,
+	// or start out empty without it, but never from Distance
+	for _, items := range []bool{true, false} {
+		d := NewDistanceMapClusterSet(twoGroups())
+		mergeItemGroups(d, [][]ClusterItem{{"a0", "a1", "a2"}})
+		var cs ClusterSet = &strictClusterSet{d, t}
+		if !items {
+			cs = struct{ ClusterSet }{cs}
+		}
+		want := 0
+		if items {
+			want = 3
+		}
+		pairs := 0
+		v := TrackVariance(cs)
+		v.EachCluster(-1, func(cluster int) {
+			pairs += v.Stats(cluster).Pairs
+		})
+		if pairs != want {
+			t.Errorf("expected initial statistics of %d pairs, got %d", want, pairs)
+		}
+	}
+}
+
+func TestVarianceForwarding(t *testing.T) {
+	calls := 0
+	v := TrackVariance(&countingClusterSet{NewDistanceMapClusterSet(twoGroups()), &calls})
+	v.MergedStats(1, 0)
+	before := calls
+	v.Merge(0, 1)
+	if calls != before {
+		t.Errorf("expected Merge to reuse the checked stats, got %d more distance calls", calls-before)
+	}
+
+	weights := map[ClusterItem]float64{"a0": 3}
+	w := TrackVariance(NewWeightedDistanceMapClusterSet(twoGroups(), weights))
+	if w.Weight("a0") != 3 || w.Weight("b0") != 1 {
+		t.Errorf("expected weights to be forwarded, got %g and %g", w.Weight("a0"), w.Weight("b0"))
+	}
+
+	p := TrackVariance(NewPointsClusterSet([][]float64{{0}, {2}}, nil))
+	if c := p.Centroid(0); len(c) != 1 || c[0] != 0 {
+		t.Errorf("expected centroid to be forwarded, got %v", c)
+	}
+	n := 0
+	p.EachItemDistance(0, 1, 0, func(ClusterItem, float64) { n++ })
+	if n != 1 {
+		t.Errorf("expected 1 item distance, got %d", n)
+	}
+}
@@ -0,0 +1,229 @@
+package clustering
+
+import (
+	"math"
+
+	"github.com/pbnjay/clustering/metrics"
+)
+
+// VectorClusterSet is an optional interface for ClusterSets whose items are
+// points in a vector space, so that exact geometric computations (e.g. for
+// centroid or Ward linkage) can be made from coordinates instead of only
+// pairwise distances. ClusterSet wrappers forward it when the wrapped set
+// implements it, and otherwise return nil.
+type VectorClusterSet interface {
+	// Coordinates returns the feature vector of an item, or nil if it is not
+	// available.
+	Coordinates(item ClusterItem) []float64
+
+	// Centroid returns the mean of the feature vectors of every item in the
+	// cluster, or nil if they are not available.
+	Centroid(cluster int) []float64
+}
+
+// VectorLinkage is an optional interface for LinkageTypes that are computed
+// from cluster centroids. When the ClusterSet implements VectorClusterSet,
+// VectorScore is used instead of enumerating pairs of items. Otherwise the
+// distances between items are assumed to be Euclidean, and the distance
+// between the centroids is derived from them.
+type VectorLinkage interface {
+	// VectorScore returns the linkage of two clusters of sizes n1 and n2 with
+	// centroids c1 and c2. When item weights are used (see WeightedClusterSet)
+	// the centroids are weighted means, and the sizes are total weights.
+	VectorScore(c1, c2 []float64, n1, n2 float64) float64
+}
+
+// CentroidLinkage implements centroid (UPGMC) clustering, which is defined as
+// the Euclidean distance between the centroids of the two clusters. It is
+// exact for ClusterSets that implement VectorClusterSet, see VectorLinkage.
+// Note that centroid linkage is not monotone, see HClustering.MonotonicHeights.
+func CentroidLinkage() LinkageType {
+	return &vectorLinkage{name: "centroid"}
+}
+
+// WardLinkage implements Ward's minimum variance clustering, which merges the
+// pair of clusters that least increases the total within-cluster sum of
+// squares. Scores are sqrt(2*n1*n2/(n1+n2)) times the distance between
+// centroids, matching SciPy's ward linkage heights. It is exact for
+// ClusterSets that implement VectorClusterSet, see VectorLinkage.
+func WardLinkage() LinkageType {
+	return &vectorLinkage{name: "ward", ward: true}
+}
+
+/////////////
+
+type vectorLinkage struct {
+	name string
+	ward bool
+}
+
+func (c *vectorLinkage) VectorScore(c1, c2 []float64, n1, n2 float64) float64 {
+	d := metrics.Euclidean(c1, c2)
+	if c.ward {
+		d *= math.Sqrt(2.0 * n1 * n2 / (n1 + n2))
+	}
+	return d
+}
+
+// Reset, Put and Get are unused, as vector linkages are computed from
+// centroids by HClustering.
+func (c *vectorLinkage) Reset() {}
+
+func (c *vectorLinkage) Put(a, b ClusterItem, dist float64) {}
+
+func (c *vectorLinkage) Get() float64 {
+	return math.NaN()
+}
+
+// PutWeighted implements WeightedLinkage, so that item weights are used for
+// centroids and cluster sizes.
+func (c *vectorLinkage) PutWeighted(a, b ClusterItem, dist, wa, wb float64) {}
+
+// LWParams returns nil, as centroids are cheap to recompute exactly.
+func (c *vectorLinkage) LWParams() []float64 {
+	return nil
+}
+
+func (c *vectorLinkage) Describe() Description {
+	return Description{Name: c.name}
+}
+
+/////////////
+
+// vectorScore computes a VectorLinkage between clusters i and j. If their
+// centroids are not available, the items are placed on a line at the distance
+// between the centroids that is derived from the distances between items.
+func (h *HClustering) vectorScore(vl VectorLinkage, i, j int) float64 {
+	ni, nj := h.clusterWeight(i), h.clusterWeight(j)
+	ci, cj := h.centroid(i), h.centroid(j)
+	if ci == nil || cj == nil {
+		ci, cj = []float64{0}, []float64{h.centroidDistance(i, j)}
+	}
+	return vl.VectorScore(ci, cj, ni, nj)
+}
+
+// centroid returns the centroid of the cluster, weighted by item weights when
+// they are used by the linkage, or nil if coordinates are not available.
+func (h *HClustering) centroid(cluster int) []float64 {
+	vcs, ok := h.ClusterSet.(VectorClusterSet)
+	if !ok {
+		return nil
+	}
+	_, ok1 := h.ClusterSet.(WeightedClusterSet)
+	_, ok2 := h.LinkageType.(WeightedLinkage)
+	if !ok1 || !ok2 {
+		return vcs.Centroid(cluster)
+	}
+
+	var res []float64
+	total := 0.0
+	missing := false
+	h.ClusterSet.EachItem(cluster, func(x ClusterItem) {
+		pt := vcs.Coordinates(x)
+		if pt == nil {
+			missing = true
+			return
+		}
+		if res == nil {
+			res = make([]float64, len(pt))
+		}
+		w := h.itemWeight(x)
+		for k, v := range pt {
+			res[k] += w * v
+		}
+		total += w
+	})
+	if missing || res == nil {
+		return nil
+	}
+	for k := range res {
+		res[k] /= total
+	}
+	return res
+}
+
+// centroidDistance derives the distance between the centroids of clusters i
+// and j from the distances between items, assuming they are Euclidean:
+//
+//	|ci-cj|^2 = mean(d(a,b)^2) - sum(d(a,a')^2)/|i|^2 - sum(d(b,b')^2)/|j|^2
+//
+// where a and b are items of i and j, and a<a' and b<b' within each cluster.
+// The distances within each cluster require an ItemDistanceSet, without one
+// they are left out, which overestimates the distance of larger clusters.
+func (h *HClustering) centroidDistance(i, j int) float64 {
+	within := func(c int) float64 {
+		var items []ClusterItem
+		h.ClusterSet.EachItem(c, func(x ClusterItem) {
+			items = append(items, x)
+		})
+		sum, total := 0.0, 0.0
+		for a, x := range items {
+			wx := h.itemWeight(x)
+			total += wx
+			for _, y := range items[a+1:] {
+				d, ok := itemDistanceOf(h.ClusterSet, x, y)
+				if !ok {
+					continue
+				}
+				h.distEvals++
+				sum += wx * h.itemWeight(y) * d * d
+			}
+		}
+		return sum / (total * total)
+	}
+
+	cross, total := 0.0, 0.0
+	h.ClusterSet.EachItem(i, func(a ClusterItem) {
+		h.ClusterSet.EachItem(j, func(b ClusterItem) {
+			h.distEvals++
+			d := h.ClusterSet.Distance(i, j, a, b)
+			w := h.itemWeight(a) * h.itemWeight(b)
+			cross += w * d * d
+			total += w
+		})
+	})
+	return math.Sqrt(math.Max(0, cross/total-within(i)-within(j)))
+}
+
+// coordinatesOf returns the feature vector of an item, or nil if c does not
+// implement VectorClusterSet.
+func coordinatesOf(c ClusterSet, item ClusterItem) []float64 {
+	if vcs, ok := c.(VectorClusterSet); ok {
+		return vcs.Coordinates(item)
+	}
+	return nil
+}
+
+// centroidOf returns the centroid of a cluster, or nil if c does not
+// implement VectorClusterSet.
+func centroidOf(c ClusterSet, cluster int) []float64 {
+	if vcs, ok := c.(VectorClusterSet); ok {
+		return vcs.Centroid(cluster)
+	}
+	return nil
+}
+
+/////////////
+
+// Coordinates returns the feature vector of an item.
+func (p *PointsClusterSet) Coordinates(item ClusterItem) []float64 {
+	return p.points[item.(int)]
+}
+
+// Centroid returns the mean of the points in the cluster.
+func (p *PointsClusterSet) Centroid(cluster int) []float64 {
+	var res []float64
+	for _, x := range p.clusters[cluster] {
+		pt := p.points[x.(int)]
+		if res == nil {
+			res = make([]float64, len(pt))
+		}
+		for k, v := range pt {
+			res[k] += v
+		}
+	}
+	for k := range res {
+		res[k] /= float64(len(p.clusters[cluster]))
+	}
+	return res
+}
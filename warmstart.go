@@ -0,0 +1,36 @@
+package clustering
+
+import "sort"
+
+// WarmStart merges the clusters of c according to an existing partition, so
+// that clustering resumes from a previous coarse result or incorporates known
+// groupings instead of starting from singletons. Items with the same group id
+// in assignment are merged into one cluster, and items that are not assigned
+// are left in their current clusters. Returns c, e.g.:
+//
+//	c := clustering.WarmStart(clustering.NewDistanceMapClusterSet(data), previous)
+//	clustering.Cluster(c, clustering.Threshold(0.4), clustering.AverageLinkage())
+func WarmStart(c ClusterSet, assignment map[ClusterItem]int) ClusterSet {
+	byGroup := make(map[int][]ClusterItem)
+	c.EachCluster(-1, func(cluster int) {
+		c.EachItem(cluster, func(x ClusterItem) {
+			if g, ok := assignment[x]; ok {
+				byGroup[g] = append(byGroup[g], x)
+			}
+		})
+	})
+	ids := make([]int, 0, len(byGroup))
+	for g, items := range byGroup {
+		if len(items) > 1 {
+			ids = append(ids, g)
+		}
+	}
+	sort.Ints(ids)
+
+	groups := make([][]ClusterItem, len(ids))
+	for i, g := range ids {
+		groups[i] = byGroup[g]
+	}
+	mergeItemGroups(c, groups)
+	return c
+}
@@ -0,0 +1,17 @@
+package clustering
+
+import "testing"
+
+func TestWarmStart(t *testing.T) {
+	c := WarmStart(NewDistanceMapClusterSet(testDistanceMap(10)), map[ClusterItem]int{
+		"item0": 1, "item1": 1, "item2": 1,
+		"item3": 2, "item4": 2,
+		"item5": 3,
+	})
+	if c.Count() != 7 {
+		t.Errorf("expected 7 starting clusters, got %d", c.Count())
+	}
+	if sizes := clusterSizes(c); sizes[3] != 1 || sizes[2] != 1 || sizes[1] != 5 {
+		t.Errorf("unexpected cluster sizes %v", sizes)
+	}
+}
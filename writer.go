@@ -0,0 +1,166 @@
+package clustering
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ResultWriter receives final cluster memberships one item at a time, so
+// results can be streamed directly to their destination without building a
+// second in-memory copy of the partition.
+//
+// Parquet output is provided by the parquetwriter subpackage, so that this
+// package remains free of dependencies.
+type ResultWriter interface {
+	// WriteItem records that item is a member of cluster. Items are always
+	// written grouped by cluster.
+	WriteItem(cluster int, item ClusterItem) error
+
+	// Close finalizes the output format and flushes any buffered data. It does
+	// not close the underlying io.Writer.
+	Close() error
+}
+
+// WriteResults streams the memberships of every cluster in c to w, and then
+// closes w. The writer is closed even if writing an item fails, and the first
+// error encountered is returned.
+func WriteResults(c ClusterSet, w ResultWriter) error {
+	var err error
+	c.EachCluster(-1, func(cluster int) {
+		c.EachItem(cluster, func(x ClusterItem) {
+			if err == nil {
+				err = w.WriteItem(cluster, x)
+			}
+		})
+	})
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ClusterTo clusters the input set exactly like Cluster, and then streams the
+// final cluster memberships to w. If w implements RunInfoWriter, the linkage
+// and checker configuration is written first.
+func ClusterTo(c ClusterSet, chk Checker, lt LinkageType, w ResultWriter) error {
+	h := &HClustering{
+		ClusterSet:  c,
+		Checker:     chk,
+		LinkageType: lt,
+	}
+	if rw, ok := w.(RunInfoWriter); ok {
+		if err := rw.WriteRunInfo(h.RunInfo()); err != nil {
+			return err
+		}
+	}
+	h.Run()
+	return WriteResults(c, w)
+}
+
+// NewCSVResultWriter returns a ResultWriter that writes a "cluster,item"
+// header followed by one row per item. Items are formatted with fmt.Sprint.
+func NewCSVResultWriter(w io.Writer) ResultWriter {
+	return &csvResultWriter{w: csv.NewWriter(w)}
+}
+
+// NewJSONResultWriter returns a ResultWriter that writes a single JSON object
+// of the form {"clusters":[[item,...],...]}. Items are encoded with
+// encoding/json. The writer also implements RunInfoWriter, which adds a "run"
+// key describing the configuration.
+func NewJSONResultWriter(w io.Writer) ResultWriter {
+	return &jsonResultWriter{w: bufio.NewWriter(w), current: -1}
+}
+
+/////////////
+
+type csvResultWriter struct {
+	w         *csv.Writer
+	wroteHead bool
+}
+
+func (c *csvResultWriter) writeHeader() error {
+	if c.wroteHead {
+		return nil
+	}
+	c.wroteHead = true
+	return c.w.Write([]string{"cluster", "item"})
+}
+
+func (c *csvResultWriter) WriteItem(cluster int, item ClusterItem) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.w.Write([]string{strconv.Itoa(cluster), fmt.Sprint(item)})
+}
+
+func (c *csvResultWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+/////////////
+
+type jsonResultWriter struct {
+	w       *bufio.Writer
+	current int
+	opened  bool
+	started bool
+}
+
+func (j *jsonResultWriter) WriteRunInfo(info RunInfo) error {
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	j.opened = true
+	j.w.WriteString(`{"run":`)
+	j.w.Write(b)
+	_, err = j.w.WriteString(`,`)
+	return err
+}
+
+func (j *jsonResultWriter) open() {
+	if !j.opened {
+		j.opened = true
+		j.w.WriteByte('{')
+	}
+}
+
+func (j *jsonResultWriter) WriteItem(cluster int, item ClusterItem) error {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !j.started:
+		j.started = true
+		j.open()
+		j.w.WriteString(`"clusters":[[`)
+	case cluster != j.current:
+		j.w.WriteString(`],[`)
+	default:
+		j.w.WriteByte(',')
+	}
+	j.current = cluster
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonResultWriter) Close() error {
+	if !j.started {
+		j.started = true
+		j.open()
+		j.w.WriteString(`"clusters":[]}`)
+	} else {
+		j.w.WriteString(`]]}`)
+	}
+	return j.w.Flush()
+}
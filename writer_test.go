@@ -0,0 +1,94 @@
+package clustering
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResultWriters(t *testing.T) {
+	d := NewDistanceMapClusterSet(DistanceMap{"a": {"b": 0.0}})
+
+	var buf bytes.Buffer
+	if err := ClusterTo(d, Threshold(1.0), CompleteLinkage(), NewJSONResultWriter(&buf)); err != nil {
+		t.Errorf("ClusterTo with JSON writer failed: %s", err)
+	}
+	s := buf.String()
+	run := `{"run":{"linkage":{"name":"complete"},"checker":{"name":"threshold","params":{"threshold":1}}},`
+	if s != run+`"clusters":[["a","b"]]}` && s != run+`"clusters":[["b","a"]]}` {
+		t.Errorf("unexpected JSON result output: %s", s)
+	}
+
+	buf.Reset()
+	if err := WriteResults(d, NewCSVResultWriter(&buf)); err != nil {
+		t.Errorf("WriteResults with CSV writer failed: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[0] != "cluster,item" || !strings.HasPrefix(lines[1], "0,") {
+		t.Errorf("unexpected CSV result output: %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteResults(NewDistanceMapClusterSet(nil), NewJSONResultWriter(&buf)); err != nil {
+		t.Errorf("WriteResults on empty set failed: %s", err)
+	}
+	if buf.String() != `{"clusters":[]}` {
+		t.Errorf("unexpected empty JSON result output: %s", buf.String())
+	}
+}
+
+type failingResultWriter struct {
+	closed bool
+}
+
+func (f *failingResultWriter) WriteItem(cluster int, item ClusterItem) error {
+	return errors.New("write failed")
+}
+
+func (f *failingResultWriter) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestWriteResultsError(t *testing.T) {
+	w := &failingResultWriter{}
+	err := WriteResults(NewDistanceMapClusterSet(DistanceMap{"a": {"b": 0.0}}), w)
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("expected the write error, got %v", err)
+	}
+	if !w.closed {
+		t.Error("expected the writer to be closed after a write error")
+	}
+}
+
+func TestMergeLog(t *testing.T) {
+	h := &HClustering{
+		ClusterSet:  NewDistanceMapClusterSet(testLine()),
+		Checker:     Threshold(3),
+		LinkageType: SingleLinkage(),
+	}
+	h.Run()
+
+	log := h.MergeLog()
+	if len(log) != 3 || log[1].Node != 5 || log[2].Decision != "checker stopped" || log[2].Score != 4 {
+		t.Errorf("unexpected merge log %+v", log)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMergeLogCSV(&buf, h); err != nil {
+		t.Errorf("WriteMergeLogCSV failed: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 8 || !strings.HasSuffix(lines[1], ",leaf,"+h.Dendrogram().Leaves[0][0].(string)) {
+		t.Errorf("unexpected CSV merge log: %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteMergeLogJSON(&buf, h); err != nil {
+		t.Errorf("WriteMergeLogJSON failed: %s", err)
+	}
+	if !strings.Contains(buf.String(), `"decision":"merged"`) {
+		t.Errorf("unexpected JSON merge log: %s", buf.String())
+	}
+}